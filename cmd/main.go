@@ -2,8 +2,13 @@ package main
 
 import (
 	"log"
+	"time"
 	"vibanda-village-admin-backend/internal/config"
+	"vibanda-village-admin-backend/internal/customers"
 	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/handlers"
+	"vibanda-village-admin-backend/internal/middleware"
+	"vibanda-village-admin-backend/internal/payments"
 	"vibanda-village-admin-backend/internal/routes"
 
 	_ "vibanda-village-admin-backend/docs" // Import generated docs
@@ -46,8 +51,33 @@ func main() {
 	// Initialize database
 	database.InitDB(cfg.MongoURI, cfg.DatabaseName)
 
-	// Create Gin router
-	r := gin.Default()
+	// Bring data written by older versions up to date
+	database.MigrateMoneyToMinorUnits(cfg.Currency)
+	database.MigrateLegacyPayments()
+	database.SeedDefaultStations()
+	database.MigrateOpenOrderTickets()
+	database.MigrateLegacyOrderTypes()
+	customers.Backfill()
+
+	// Initialize the mobile money payment provider
+	if err := payments.Init(cfg); err != nil {
+		log.Fatal("Failed to initialize payment provider:", err)
+	}
+	if payments.Provider == nil {
+		log.Println("PAYMENT_PROVIDER is not set, mobile payments are disabled")
+	}
+	go handlers.ExpirePendingPayments(time.Minute)
+
+	// Create Gin router. Requests are logged with secret query parameters, such
+	// as the access token of kitchen displays, masked.
+	r := gin.New()
+	r.Use(middleware.LoggerMiddleware(), gin.Recovery())
+
+	// Only trust forwarding headers from known proxies, so that clients cannot
+	// choose the IP address they are rate limited by
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
 
 	// Serve static files for uploads
 	r.Static("/uploads", cfg.UploadPath)
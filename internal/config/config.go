@@ -3,33 +3,123 @@ package config
 import (
 	"log"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 )
 
+// TaxRate is a tax applied to orders. Rate is in basis points (16% = 1600).
+// Inclusive taxes are already contained in prices; exclusive taxes are added on top.
+type TaxRate struct {
+	Name      string
+	Rate      int64
+	Inclusive bool
+}
+
+// LoyaltyTier is a loyalty level reached by spending at least MinimumSpend, in
+// minor units, within the tier window
+type LoyaltyTier struct {
+	Name         string
+	MinimumSpend int64
+}
+
 type Config struct {
-	Port               string
-	GinMode            string
-	MongoURI           string
-	DatabaseName       string
-	JWTSecret          string
-	JWTExpirationHours int
-	AllowedOrigins     []string
-	MaxFileSize        string
-	UploadPath         string
+	Port                       string
+	GinMode                    string
+	MongoURI                   string
+	DatabaseName               string
+	JWTSecret                  string
+	AccessTokenMinutes         int
+	RefreshTokenDays           int
+	RegistrationEnabled        bool
+	AllowedOrigins             []string
+	TrustedProxies             []string
+	MaxFileSize                string
+	UploadPath                 string
+	ReservationDurationMinutes int
+	ReservationSlotMinutes     int
+	OpeningTime                string
+	ClosingTime                string
+	PublicReservationRateLimit int
+	ReportTimeZone             string
+	IdempotencyKeyHours        int
+	Currency                   string
+	ServiceChargeRate          int64
+	TaxRates                   []TaxRate
+	DeliveryFee                int64
+	PaymentProvider            string
+	PaymentCallbackURL         string
+	PaymentCallbackToken       string
+	PaymentTimeoutMinutes      int
+	MpesaEnvironment           string
+	MpesaConsumerKey           string
+	MpesaConsumerSecret        string
+	MpesaShortCode             string
+	MpesaPasskey               string
+	RestaurantName             string
+	RestaurantAddress          string
+	RestaurantPhone            string
+	RestaurantTaxPIN           string
+	ReceiptFooter              string
+	SMTPHost                   string
+	SMTPPort                   int
+	SMTPUsername               string
+	SMTPPassword               string
+	SMTPFrom                   string
+	PhoneCountryCode           string
+	LoyaltyEarnRate            int64
+	LoyaltyTiers               []LoyaltyTier
+	LoyaltyTierWindowDays      int
 }
 
 func Load() *Config {
 	return &Config{
-		Port:               getEnv("PORT", "8080"),
-		GinMode:            getEnv("GIN_MODE", "debug"),
-		MongoURI:           getEnv("MONGODB_URI", "mongodb://localhost:27017"),
-		DatabaseName:       getEnv("DATABASE_NAME", "vibanda_village"),
-		JWTSecret:          getEnv("JWT_SECRET", "your-super-secret-jwt-key-here"),
-		JWTExpirationHours: getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
-		AllowedOrigins:     getEnvAsSlice("ALLOWED_ORIGINS", []string{"http://localhost:3000", "http://localhost:5173", "http://localhost:5174"}),
-		MaxFileSize:        getEnv("MAX_FILE_SIZE", "10MB"),
-		UploadPath:         getEnv("UPLOAD_PATH", "uploads/"),
+		Port:                       getEnv("PORT", "8080"),
+		GinMode:                    getEnv("GIN_MODE", "debug"),
+		MongoURI:                   getEnv("MONGODB_URI", "mongodb://localhost:27017"),
+		DatabaseName:               getEnv("DATABASE_NAME", "vibanda_village"),
+		JWTSecret:                  getEnv("JWT_SECRET", "your-super-secret-jwt-key-here"),
+		AccessTokenMinutes:         getEnvAsInt("ACCESS_TOKEN_MINUTES", 15),
+		RefreshTokenDays:           getEnvAsInt("REFRESH_TOKEN_DAYS", 30),
+		RegistrationEnabled:        getEnvAsBool("REGISTRATION_ENABLED", true),
+		AllowedOrigins:             getEnvAsSlice("ALLOWED_ORIGINS", []string{"http://localhost:3000", "http://localhost:5173", "http://localhost:5174"}),
+		TrustedProxies:             getEnvAsSlice("TRUSTED_PROXIES", nil),
+		MaxFileSize:                getEnv("MAX_FILE_SIZE", "10MB"),
+		UploadPath:                 getEnv("UPLOAD_PATH", "uploads/"),
+		ReservationDurationMinutes: getEnvAsInt("RESERVATION_DURATION_MINUTES", 120),
+		ReservationSlotMinutes:     getEnvAsInt("RESERVATION_SLOT_MINUTES", 30),
+		OpeningTime:                getEnv("OPENING_TIME", "12:00"),
+		ClosingTime:                getEnv("CLOSING_TIME", "23:00"),
+		PublicReservationRateLimit: getEnvAsInt("PUBLIC_RESERVATION_RATE_LIMIT", 5),
+		ReportTimeZone:             getEnv("REPORT_TIMEZONE", "UTC"),
+		IdempotencyKeyHours:        getEnvAsInt("IDEMPOTENCY_KEY_HOURS", 24),
+		Currency:                   getEnv("CURRENCY", "KES"),
+		ServiceChargeRate:          int64(getEnvAsInt("SERVICE_CHARGE_BPS", 0)),
+		TaxRates:                   getEnvAsTaxRates("TAX_RATES", []TaxRate{{Name: "VAT", Rate: 1600, Inclusive: true}}),
+		DeliveryFee:                int64(getEnvAsInt("DELIVERY_FEE", 0)),
+		PaymentProvider:            getEnv("PAYMENT_PROVIDER", ""),
+		PaymentCallbackURL:         getEnv("PAYMENT_CALLBACK_URL", "http://localhost:8080/api/v1/payments/callback"),
+		PaymentCallbackToken:       getEnv("PAYMENT_CALLBACK_TOKEN", ""),
+		PaymentTimeoutMinutes:      getEnvAsInt("PAYMENT_TIMEOUT_MINUTES", 5),
+		MpesaEnvironment:           getEnv("MPESA_ENVIRONMENT", "sandbox"),
+		MpesaConsumerKey:           getEnv("MPESA_CONSUMER_KEY", ""),
+		MpesaConsumerSecret:        getEnv("MPESA_CONSUMER_SECRET", ""),
+		MpesaShortCode:             getEnv("MPESA_SHORTCODE", ""),
+		MpesaPasskey:               getEnv("MPESA_PASSKEY", ""),
+		RestaurantName:             getEnv("RESTAURANT_NAME", "Vibanda Village"),
+		RestaurantAddress:          getEnv("RESTAURANT_ADDRESS", ""),
+		RestaurantPhone:            getEnv("RESTAURANT_PHONE", ""),
+		RestaurantTaxPIN:           getEnv("RESTAURANT_TAX_PIN", ""),
+		ReceiptFooter:              getEnv("RECEIPT_FOOTER", "Thank you for visiting!"),
+		SMTPHost:                   getEnv("SMTP_HOST", ""),
+		SMTPPort:                   getEnvAsInt("SMTP_PORT", 587),
+		SMTPUsername:               getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:               getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:                   getEnv("SMTP_FROM", ""),
+		PhoneCountryCode:           getEnv("PHONE_COUNTRY_CODE", "254"),
+		LoyaltyEarnRate:            int64(getEnvAsInt("LOYALTY_EARN_RATE_BPS", 10000)),
+		LoyaltyTiers:               getEnvAsLoyaltyTiers("LOYALTY_TIERS", []LoyaltyTier{{Name: "Bronze", MinimumSpend: 0}, {Name: "Silver", MinimumSpend: 2000000}, {Name: "Gold", MinimumSpend: 5000000}}),
+		LoyaltyTierWindowDays:      getEnvAsInt("LOYALTY_TIER_WINDOW_DAYS", 365),
 	}
 }
 
@@ -52,6 +142,17 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+		log.Printf("Invalid boolean value for %s: %s, using default: %t", key, value, defaultValue)
+	}
+	log.Printf("Environment variable %s not set, using default: %t", key, defaultValue)
+	return defaultValue
+}
+
 func getEnvAsSlice(key string, defaultValue []string) []string {
 	if value := os.Getenv(key); value != "" {
 		return strings.Split(value, ",")
@@ -59,3 +160,63 @@ func getEnvAsSlice(key string, defaultValue []string) []string {
 	log.Printf("Environment variable %s not set, using default: %v", key, defaultValue)
 	return defaultValue
 }
+
+// getEnvAsTaxRates parses a comma separated list of NAME:BASIS_POINTS:inclusive|exclusive,
+// e.g. "VAT:1600:inclusive,LEVY:200:exclusive". Set the variable to "none" for no taxes.
+func getEnvAsTaxRates(key string, defaultValue []TaxRate) []TaxRate {
+	value := os.Getenv(key)
+	if value == "" {
+		log.Printf("Environment variable %s not set, using default: %v", key, defaultValue)
+		return defaultValue
+	}
+	if value == "none" {
+		return nil
+	}
+
+	var rates []TaxRate
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 3 {
+			log.Printf("Invalid tax rate %q in %s, using default: %v", entry, key, defaultValue)
+			return defaultValue
+		}
+		rate, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || rate < 0 || (parts[2] != "inclusive" && parts[2] != "exclusive") {
+			log.Printf("Invalid tax rate %q in %s, using default: %v", entry, key, defaultValue)
+			return defaultValue
+		}
+		rates = append(rates, TaxRate{Name: parts[0], Rate: rate, Inclusive: parts[2] == "inclusive"})
+	}
+	return rates
+}
+
+// getEnvAsLoyaltyTiers parses a comma separated list of NAME:MINIMUM_SPEND in minor
+// units, e.g. "Bronze:0,Silver:2000000,Gold:5000000". Tiers are returned from the
+// lowest to the highest. Set the variable to "none" for no tiers.
+func getEnvAsLoyaltyTiers(key string, defaultValue []LoyaltyTier) []LoyaltyTier {
+	value := os.Getenv(key)
+	if value == "" {
+		log.Printf("Environment variable %s not set, using default: %v", key, defaultValue)
+		return defaultValue
+	}
+	if value == "none" {
+		return nil
+	}
+
+	var tiers []LoyaltyTier
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 2 || parts[0] == "" {
+			log.Printf("Invalid loyalty tier %q in %s, using default: %v", entry, key, defaultValue)
+			return defaultValue
+		}
+		spend, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || spend < 0 {
+			log.Printf("Invalid loyalty tier %q in %s, using default: %v", entry, key, defaultValue)
+			return defaultValue
+		}
+		tiers = append(tiers, LoyaltyTier{Name: parts[0], MinimumSpend: spend})
+	}
+	sort.SliceStable(tiers, func(i, j int) bool { return tiers[i].MinimumSpend < tiers[j].MinimumSpend })
+	return tiers
+}
@@ -0,0 +1,334 @@
+// Package customers keeps the customer directory built from the contact details
+// on orders and reservations. Customers are recognised by their normalised phone
+// number or email address.
+package customers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"vibanda-village-admin-backend/internal/config"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// minPhoneDigits is the fewest digits a phone number can have
+const minPhoneDigits = 7
+
+// phoneCountryCode returns PHONE_COUNTRY_CODE, read once as phones are normalised
+// for every order and reservation
+var phoneCountryCode = sync.OnceValue(func() string {
+	return config.Load().PhoneCountryCode
+})
+
+// NormalizePhone returns a phone number as digits with the country code, so that
+// "0712 345 678", "+254712345678" and "254 712 345678" are the same number.
+// National numbers get PHONE_COUNTRY_CODE. It returns an empty string for
+// something that is not a phone number.
+func NormalizePhone(phone string) string {
+	phone = strings.TrimSpace(phone)
+	international := strings.HasPrefix(phone, "+")
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+
+	countryCode := phoneCountryCode()
+	switch {
+	case international:
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	case strings.HasPrefix(digits, "0"):
+		digits = countryCode + digits[1:]
+	case !strings.HasPrefix(digits, countryCode) && len(digits) < 10:
+		digits = countryCode + digits
+	}
+	if len(digits) < minPhoneDigits {
+		return ""
+	}
+	return digits
+}
+
+// NormalizeEmail returns an email address trimmed and in lower case
+func NormalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !strings.Contains(email, "@") {
+		return ""
+	}
+	return email
+}
+
+// NormalizeTags trims tags, puts them in lower case and drops empty and repeated ones
+func NormalizeTags(tags []string) []string {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// Link returns the customer with the phone or email, creating one if there is
+// none, and records that the customer was seen now. The phone is matched first.
+// Details not yet on the customer are added, unless they belong to another
+// customer. It returns a zero ID when there is neither a phone nor an email.
+func Link(ctx context.Context, name, phone, email string) (primitive.ObjectID, error) {
+	phone = NormalizePhone(phone)
+	email = NormalizeEmail(email)
+	if phone == "" && email == "" {
+		return primitive.NilObjectID, nil
+	}
+
+	id, err := link(ctx, strings.TrimSpace(name), phone, email)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent request created the customer first
+		id, err = link(ctx, strings.TrimSpace(name), phone, email)
+	}
+	return id, err
+}
+
+func link(ctx context.Context, name, phone, email string) (primitive.ObjectID, error) {
+	collection := database.DB.Collection("customers")
+
+	var match []bson.M
+	if phone != "" {
+		match = append(match, bson.M{"phones": phone})
+	}
+	if email != "" {
+		match = append(match, bson.M{"emails": email})
+	}
+	cursor, err := collection.Find(ctx, bson.M{"$or": match})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	var found []models.Customer
+	if err := cursor.All(ctx, &found); err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	now := time.Now()
+	if len(found) == 0 {
+		customer := models.Customer{
+			ID:         primitive.NewObjectID(),
+			Name:       name,
+			Phones:     []string{},
+			Emails:     []string{},
+			Tags:       []string{},
+			LastSeenAt: now,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		if phone != "" {
+			customer.Phones = append(customer.Phones, phone)
+		}
+		if email != "" {
+			customer.Emails = append(customer.Emails, email)
+		}
+		if _, err := collection.InsertOne(ctx, customer); err != nil {
+			return primitive.NilObjectID, err
+		}
+		return customer.ID, nil
+	}
+
+	customer := found[0]
+	for _, candidate := range found {
+		if phone != "" && contains(candidate.Phones, phone) {
+			customer = candidate
+			break
+		}
+	}
+
+	set := bson.M{"updated_at": now}
+	if customer.Name == "" && name != "" {
+		set["name"] = name
+	}
+	update := bson.M{"$set": set, "$max": bson.M{"last_seen_at": now}}
+	// With two matches the phone and email belong to different customers, who
+	// may be merged by hand
+	if len(found) == 1 {
+		add := bson.M{}
+		if phone != "" {
+			add["phones"] = phone
+		}
+		if email != "" {
+			add["emails"] = email
+		}
+		update["$addToSet"] = add
+	}
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": customer.ID}, update); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return customer.ID, nil
+}
+
+// Stats returns the order and reservation history of the customers, summed up
+func Stats(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]models.CustomerStats, error) {
+	stats := make(map[primitive.ObjectID]models.CustomerStats)
+	if len(ids) == 0 {
+		return stats, nil
+	}
+	lastVisits := make(map[primitive.ObjectID]time.Time)
+
+	notCancelled := bson.M{"$ne": bson.A{"$status", "cancelled"}}
+	orderPipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"customer_id": bson.M{"$in": ids}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         "$customer_id",
+			"order_count": bson.M{"$sum": bson.M{"$cond": bson.A{notCancelled, 1, 0}}},
+			"total_spent": bson.M{"$sum": bson.M{"$subtract": bson.A{
+				bson.M{"$ifNull": bson.A{"$amount_paid", 0}},
+				bson.M{"$ifNull": bson.A{"$amount_refunded", 0}},
+			}}},
+			"last_visit": bson.M{"$max": bson.M{"$cond": bson.A{notCancelled, "$created_at", nil}}},
+		}}},
+	}
+	cursor, err := database.DB.Collection("orders").Aggregate(ctx, orderPipeline)
+	if err != nil {
+		return nil, err
+	}
+	var orders []struct {
+		ID         primitive.ObjectID `bson:"_id"`
+		OrderCount int                `bson:"order_count"`
+		TotalSpent models.Money       `bson:"total_spent"`
+		LastVisit  *time.Time         `bson:"last_visit"`
+	}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	for _, row := range orders {
+		s := stats[row.ID]
+		s.OrderCount = row.OrderCount
+		s.TotalSpent = row.TotalSpent
+		stats[row.ID] = s
+		if row.LastVisit != nil {
+			lastVisits[row.ID] = *row.LastVisit
+		}
+	}
+
+	// A reservation is a visit once its confirmed slot has come
+	slot := bson.M{"$concat": bson.A{"$date", " ", "$time"}}
+	visited := bson.M{"$and": bson.A{
+		bson.M{"$eq": bson.A{"$status", "confirmed"}},
+		bson.M{"$lte": bson.A{slot, time.Now().Format(reservationSlotLayout)}},
+	}}
+	reservationPipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"customer_id": bson.M{"$in": ids}, "status": bson.M{"$ne": "cancelled"}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":               "$customer_id",
+			"reservation_count": bson.M{"$sum": 1},
+			"last_visit":        bson.M{"$max": bson.M{"$cond": bson.A{visited, slot, nil}}},
+		}}},
+	}
+	cursor, err = database.DB.Collection("reservations").Aggregate(ctx, reservationPipeline)
+	if err != nil {
+		return nil, err
+	}
+	var reservations []struct {
+		ID               primitive.ObjectID `bson:"_id"`
+		ReservationCount int                `bson:"reservation_count"`
+		LastVisit        string             `bson:"last_visit"`
+	}
+	if err := cursor.All(ctx, &reservations); err != nil {
+		return nil, err
+	}
+	for _, row := range reservations {
+		s := stats[row.ID]
+		s.ReservationCount = row.ReservationCount
+		stats[row.ID] = s
+		if visit, err := time.ParseInLocation(reservationSlotLayout, row.LastVisit, time.Local); err == nil && visit.After(lastVisits[row.ID]) {
+			lastVisits[row.ID] = visit
+		}
+	}
+
+	for id, visit := range lastVisits {
+		s := stats[id]
+		visit := visit
+		s.LastVisitAt = &visit
+		stats[id] = s
+	}
+	return stats, nil
+}
+
+// reservationSlotLayout is a reservation's date and time joined by a space, in
+// local time, which sorts in time order
+const reservationSlotLayout = "2006-01-02 15:04"
+
+// Backfill links the orders and reservations placed before the customer
+// directory existed to their customers. Only those without a customer_id field
+// are linked; linked ones, and ones whose customer was deleted, which have a
+// null customer_id, are left alone, so it is safe to run on every start.
+func Backfill() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	for _, name := range []string{"orders", "reservations"} {
+		collection := database.DB.Collection(name)
+		filter := bson.M{
+			"customer_id": bson.M{"$exists": false},
+			"$or": bson.A{
+				bson.M{"customer_phone": bson.M{"$nin": bson.A{"", nil}}},
+				bson.M{"customer_email": bson.M{"$nin": bson.A{"", nil}}},
+			},
+		}
+		cursor, err := collection.Find(ctx, filter)
+		if err != nil {
+			log.Printf("Failed to load %s to link to customers: %v", name, err)
+			continue
+		}
+
+		linked := 0
+		for cursor.Next(ctx) {
+			var doc struct {
+				ID            primitive.ObjectID `bson:"_id"`
+				CustomerName  string             `bson:"customer_name"`
+				CustomerPhone string             `bson:"customer_phone"`
+				CustomerEmail string             `bson:"customer_email"`
+			}
+			if err := cursor.Decode(&doc); err != nil {
+				log.Printf("Failed to decode %s to link to customers: %v", name, err)
+				continue
+			}
+			customerID, err := Link(ctx, doc.CustomerName, doc.CustomerPhone, doc.CustomerEmail)
+			if err != nil {
+				log.Printf("Failed to link %s %s to a customer: %v", name, doc.ID.Hex(), err)
+				continue
+			}
+			if customerID.IsZero() {
+				continue
+			}
+			if _, err := collection.UpdateOne(ctx, bson.M{"_id": doc.ID}, bson.M{"$set": bson.M{"customer_id": customerID}}); err != nil {
+				log.Printf("Failed to link %s %s to a customer: %v", name, doc.ID.Hex(), err)
+				continue
+			}
+			linked++
+		}
+		cursor.Close(ctx)
+
+		if linked > 0 {
+			log.Printf("Linked %d %s to customers", linked, name)
+		}
+	}
+}
+
+// contains reports whether values contains value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -57,6 +57,11 @@ func InitDB(mongoURI, databaseName string) {
 	DB = client.Database(databaseName)
 	log.Println("Database connection established")
 
+	// Ensure indexes required for data integrity. Duplicates left by older
+	// versions would stop the unique indexes from being built.
+	dedupeOrderNumbers()
+	createIndexes()
+
 	// Create test user if it doesn't exist
 	createTestUserIfNotExists()
 }
@@ -78,6 +83,102 @@ func CloseDB() {
 	}
 }
 
+// createIndexes creates the indexes the application relies on. Creating an
+// existing index is a no-op, so this is safe to run on every start.
+func createIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	indexes := map[string][]mongo.IndexModel{
+		"tickets": {
+			{Keys: bson.D{{Key: "code", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "event_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+		"refresh_tokens": {
+			{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+			{Keys: bson.D{{Key: "family_id", Value: 1}}},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"invitations": {
+			{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"revoked_tokens": {
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"orders": {
+			{Keys: bson.D{{Key: "order_number", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "tickets.station", Value: 1}, {Key: "tickets.status", Value: 1}}},
+			{Keys: bson.D{{Key: "order_type", Value: 1}, {Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "rider_id", Value: 1}, {Key: "status", Value: 1}}},
+			{Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+		"reservations": {
+			{Keys: bson.D{{Key: "customer_id", Value: 1}}},
+		},
+		"customers": {
+			{Keys: bson.D{{Key: "phones", Value: 1}}, Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"phones": bson.M{"$type": "string"}})},
+			{Keys: bson.D{{Key: "emails", Value: 1}}, Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"emails": bson.M{"$type": "string"}})},
+			{Keys: bson.D{{Key: "tags", Value: 1}}},
+			{Keys: bson.D{{Key: "last_seen_at", Value: -1}}},
+		},
+		"loyalty_entries": {
+			{Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "order_id", Value: 1}}},
+		},
+		"ingredients": {
+			{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"products": {
+			{Keys: bson.D{{Key: "recipe.ingredient_id", Value: 1}}},
+		},
+		"loyalty_rewards": {
+			{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"delivery_zones": {
+			{Keys: bson.D{{Key: "area", Value: "2dsphere"}}},
+			{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"stations": {
+			{Keys: bson.D{{Key: "code", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"idempotency_keys": {
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"counters": {
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"payments": {
+			{Keys: bson.D{{Key: "order_id", Value: 1}, {Key: "created_at", Value: 1}}},
+			{Keys: bson.D{{Key: "refund_of", Value: 1}}, Options: options.Index().SetSparse(true)},
+			{Keys: bson.D{{Key: "provider", Value: 1}, {Key: "checkout_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+		},
+		"promotions": {
+			{Keys: bson.D{{Key: "code", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"promotion_redemptions": {
+			{Keys: bson.D{{Key: "promotion_id", Value: 1}, {Key: "customer_phone", Value: 1}}},
+			{Keys: bson.D{{Key: "order_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"kitchen_events": {
+			{Keys: bson.D{{Key: "seq", Value: 1}}},
+			{Keys: bson.D{{Key: "stations", Value: 1}, {Key: "seq", Value: 1}}},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"audit_logs": {
+			{Keys: bson.D{{Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "entity_type", Value: 1}, {Key: "entity_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+	}
+
+	for collection, collectionIndexes := range indexes {
+		if _, err := DB.Collection(collection).Indexes().CreateMany(ctx, collectionIndexes); err != nil {
+			log.Printf("Failed to create indexes on %s: %v", collection, err)
+		}
+	}
+}
+
 func createTestUserIfNotExists() {
 	collection := DB.Collection("users")
 	ctx := context.Background()
@@ -0,0 +1,270 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"vibanda-village-admin-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// toMinorUnits returns an aggregation expression converting a decimal amount in
+// major units to a whole number of minor units
+func toMinorUnits(field string) bson.M {
+	return bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{field, 100}}, 0}}}
+}
+
+// MigrateMoneyToMinorUnits converts product, event and ticket prices and order
+// amounts stored as decimals in major units to integers in minor units. Documents
+// that were already converted are left alone, so it is safe to run on every start.
+func MigrateMoneyToMinorUnits(currency string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	products, err := DB.Collection("products").UpdateMany(ctx,
+		bson.M{"price": bson.M{"$type": "double"}},
+		bson.A{bson.M{"$set": bson.M{"price": toMinorUnits("$price")}}},
+	)
+	if err != nil {
+		log.Println("Failed to migrate product prices to minor units:", err)
+		return
+	}
+
+	// Legacy items had no discounts, so their total is simply price times quantity
+	item := bson.M{
+		"price":           toMinorUnits("$$item.price"),
+		"discount_amount": 0,
+		"total":           bson.M{"$multiply": bson.A{toMinorUnits("$$item.price"), "$$item.quantity"}},
+	}
+	orders, err := DB.Collection("orders").UpdateMany(ctx,
+		bson.M{"total_amount": bson.M{"$type": "double"}},
+		bson.A{bson.M{"$set": bson.M{
+			"total_amount": toMinorUnits("$total_amount"),
+			"currency":     bson.M{"$ifNull": bson.A{"$currency", currency}},
+			"items": bson.M{"$map": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$items", bson.A{}}},
+				"as":    "item",
+				"in":    bson.M{"$mergeObjects": bson.A{"$$item", item}},
+			}},
+		}}},
+	)
+	if err != nil {
+		log.Println("Failed to migrate order amounts to minor units:", err)
+		return
+	}
+
+	events, err := DB.Collection("events").UpdateMany(ctx,
+		bson.M{"price": bson.M{"$type": "double"}},
+		bson.A{bson.M{"$set": bson.M{"price": toMinorUnits("$price")}}},
+	)
+	if err != nil {
+		log.Println("Failed to migrate event prices to minor units:", err)
+		return
+	}
+
+	tickets, err := DB.Collection("tickets").UpdateMany(ctx,
+		bson.M{"price": bson.M{"$type": "double"}},
+		bson.A{bson.M{"$set": bson.M{"price": toMinorUnits("$price")}}},
+	)
+	if err != nil {
+		log.Println("Failed to migrate ticket prices to minor units:", err)
+		return
+	}
+
+	if products.ModifiedCount > 0 || orders.ModifiedCount > 0 || events.ModifiedCount > 0 || tickets.ModifiedCount > 0 {
+		log.Printf("Converted %d products, %d orders, %d events and %d tickets to minor units", products.ModifiedCount, orders.ModifiedCount, events.ModifiedCount, tickets.ModifiedCount)
+	}
+}
+
+// MigrateLegacyPayments sets the amount paid of orders that were marked paid by
+// hand before payments were recorded, so that their payment status stays paid
+// when it is next derived from the amounts.
+func MigrateLegacyPayments() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	result, err := DB.Collection("orders").UpdateMany(ctx,
+		bson.M{"payment_status": "paid", "amount_paid": bson.M{"$exists": false}},
+		bson.A{bson.M{"$set": bson.M{"amount_paid": "$total_amount", "amount_refunded": 0}}},
+	)
+	if err != nil {
+		log.Println("Failed to migrate paid orders:", err)
+		return
+	}
+
+	if result.ModifiedCount > 0 {
+		log.Printf("Set the amount paid of %d orders paid before payments were recorded", result.ModifiedCount)
+	}
+}
+
+// SeedDefaultStations creates a kitchen for food and a bar for drinks when no
+// prep stations have been set up yet
+func SeedDefaultStations() {
+	ctx := context.Background()
+	collection := DB.Collection("stations")
+
+	count, err := collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		log.Println("Failed to check prep stations:", err)
+		return
+	}
+	if count > 0 {
+		return
+	}
+
+	now := time.Now()
+	stations := []interface{}{
+		models.Station{
+			ID:         primitive.NewObjectID(),
+			Code:       "kitchen",
+			Name:       "Kitchen",
+			Categories: []models.ProductCategory{models.CategoryFood},
+			IsDefault:  true,
+			Active:     true,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		},
+		models.Station{
+			ID:         primitive.NewObjectID(),
+			Code:       "bar",
+			Name:       "Bar",
+			Categories: []models.ProductCategory{models.CategoryDrink},
+			Active:     true,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		},
+	}
+	if _, err := collection.InsertMany(ctx, stations); err != nil {
+		log.Println("Failed to create default prep stations:", err)
+		return
+	}
+	log.Println("Created the default kitchen and bar prep stations")
+}
+
+// MigrateOpenOrderTickets routes the items of open orders placed before prep
+// stations existed, so that they show up at the stations. Orders that already
+// have tickets are left alone, so it is safe to run on every start.
+func MigrateOpenOrderTickets() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	cursor, err := DB.Collection("stations").Find(ctx, bson.M{"active": true}, options.Find().SetSort(bson.M{"code": 1}))
+	if err != nil {
+		log.Println("Failed to load prep stations:", err)
+		return
+	}
+	var stations []models.Station
+	if err := cursor.All(ctx, &stations); err != nil {
+		log.Println("Failed to load prep stations:", err)
+		return
+	}
+
+	orders := DB.Collection("orders")
+	filter := bson.M{
+		"status":  bson.M{"$in": []models.OrderStatus{models.OrderStatusPending, models.OrderStatusConfirmed}},
+		"tickets": bson.M{"$exists": false},
+	}
+	cursor, err = orders.Find(ctx, filter)
+	if err != nil {
+		log.Println("Failed to load open orders:", err)
+		return
+	}
+	var open []models.Order
+	if err := cursor.All(ctx, &open); err != nil {
+		log.Println("Failed to load open orders:", err)
+		return
+	}
+
+	now := time.Now()
+	migrated := 0
+	for _, order := range open {
+		for i := range order.Items {
+			order.Items[i].Station = models.StationFor(stations, order.Items[i].Category, order.Items[i].Subcategory)
+		}
+		order.AssignTickets(now)
+		if len(order.Tickets) == 0 {
+			continue
+		}
+
+		update := bson.M{"$set": bson.M{"items": order.Items, "tickets": order.Tickets}}
+		result, err := orders.UpdateOne(ctx, bson.M{"_id": order.ID, "updated_at": order.UpdatedAt}, update)
+		if err != nil {
+			log.Println("Failed to route the items of an open order:", err)
+			return
+		}
+		migrated += int(result.ModifiedCount)
+	}
+
+	if migrated > 0 {
+		log.Printf("Routed the items of %d open orders to prep stations", migrated)
+	}
+}
+
+// MigrateLegacyOrderTypes marks orders placed before orders had a type as dine-in,
+// which is how they were served. Orders that already have a type are left alone,
+// so it is safe to run on every start.
+func MigrateLegacyOrderTypes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	result, err := DB.Collection("orders").UpdateMany(ctx,
+		bson.M{"order_type": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"order_type": models.OrderTypeDineIn}},
+	)
+	if err != nil {
+		log.Println("Failed to migrate order types:", err)
+		return
+	}
+
+	if result.ModifiedCount > 0 {
+		log.Printf("Marked %d orders placed before order types as dine-in", result.ModifiedCount)
+	}
+}
+
+// dedupeOrderNumbers renumbers orders that share an order number, which older
+// versions could give out twice (ORD-<unix time>), so that the unique index on
+// order numbers can be built. The first order keeps the number; the others get
+// -2, -3 and so on appended.
+func dedupeOrderNumbers() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	collection := DB.Collection("orders")
+	cursor, err := collection.Aggregate(ctx, bson.A{
+		bson.M{"$match": bson.M{"order_number": bson.M{"$type": "string"}}},
+		bson.M{"$sort": bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		bson.M{"$group": bson.M{"_id": "$order_number", "ids": bson.M{"$push": "$_id"}, "count": bson.M{"$sum": 1}}},
+		bson.M{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	})
+	if err != nil {
+		log.Println("Failed to find duplicate order numbers:", err)
+		return
+	}
+	var duplicates []struct {
+		Number string               `bson:"_id"`
+		IDs    []primitive.ObjectID `bson:"ids"`
+	}
+	if err := cursor.All(ctx, &duplicates); err != nil {
+		log.Println("Failed to find duplicate order numbers:", err)
+		return
+	}
+
+	renumbered := 0
+	for _, duplicate := range duplicates {
+		for i, id := range duplicate.IDs[1:] {
+			number := fmt.Sprintf("%s-%d", duplicate.Number, i+2)
+			if _, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"order_number": number}}); err != nil {
+				log.Printf("Failed to renumber order %s: %v", id.Hex(), err)
+				continue
+			}
+			renumbered++
+		}
+	}
+	if renumbered > 0 {
+		log.Printf("Renumbered %d orders with duplicate order numbers", renumbered)
+	}
+}
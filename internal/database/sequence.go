@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// sequenceRetention is how long a counter document is kept after it was last used.
+// Daily sequences are only needed for the day they number.
+const sequenceRetention = 7 * 24 * time.Hour
+
+// NextSequence atomically increments the named counter and returns its new value.
+// The first call for a name returns 1. The counter is dropped once it has not
+// been used for a week, so it suits sequences that restart, such as daily ones.
+func NextSequence(ctx context.Context, name string) (int64, error) {
+	return nextSequence(ctx, name, bson.M{
+		"$inc": bson.M{"seq": 1},
+		"$set": bson.M{"expires_at": time.Now().Add(sequenceRetention)},
+	})
+}
+
+// NextPermanentSequence is NextSequence for counters that must never restart,
+// such as the numbering of kitchen events that displays resume from
+func NextPermanentSequence(ctx context.Context, name string) (int64, error) {
+	return nextSequence(ctx, name, bson.M{
+		"$inc":   bson.M{"seq": 1},
+		"$unset": bson.M{"expires_at": ""},
+	})
+}
+
+// nextSequence applies update to the named counter and returns its new value
+func nextSequence(ctx context.Context, name string, update bson.M) (int64, error) {
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	collection := DB.Collection("counters")
+	err := collection.FindOneAndUpdate(ctx, bson.M{"_id": name}, update, opts).Decode(&counter)
+	if mongo.IsDuplicateKeyError(err) {
+		// Two first calls raced to insert the counter; the other one won, so increment it
+		err = collection.FindOneAndUpdate(ctx, bson.M{"_id": name}, update, opts).Decode(&counter)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return counter.Seq, nil
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"time"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// recentActivityLimit is the number of audit entries shown on the profile page
+const recentActivityLimit = 10
+
+// auditIgnoredFields are bookkeeping fields left out of audit diffs
+var auditIgnoredFields = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// recordAudit writes an audit entry for a change made by the authenticated user.
+// before is nil for creations and after is nil for deletions.
+func recordAudit(c *gin.Context, action models.AuditAction, entityType string, entityID primitive.ObjectID, before, after interface{}) {
+	recordAuditAs(c, currentUserID(c), action, entityType, entityID, before, after)
+}
+
+// recordAuditAs writes an audit entry on behalf of the given user. Failures are
+// logged rather than returned so that auditing never fails the request itself.
+func recordAuditAs(c *gin.Context, userID primitive.ObjectID, action models.AuditAction, entityType string, entityID primitive.ObjectID, before, after interface{}) {
+	entry := models.AuditLog{
+		ID:         primitive.NewObjectID(),
+		UserID:     userID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    auditChanges(before, after),
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		CreatedAt:  time.Now(),
+	}
+
+	if _, err := database.DB.Collection("audit_logs").InsertOne(context.Background(), entry); err != nil {
+		log.Printf("Failed to write audit log for %s %s %s: %v", action, entityType, entityID.Hex(), err)
+	}
+}
+
+// auditChanges returns the fields that differ between before and after, using
+// their JSON representation so fields hidden from the API (such as password
+// hashes) are never copied into the log
+func auditChanges(before, after interface{}) map[string]models.FieldChange {
+	beforeFields := auditFields(before)
+	afterFields := auditFields(after)
+
+	changes := make(map[string]models.FieldChange)
+	for field, value := range afterFields {
+		if previous, ok := beforeFields[field]; !ok || !reflect.DeepEqual(previous, value) {
+			changes[field] = models.FieldChange{From: beforeFields[field], To: value}
+		}
+	}
+	for field, value := range beforeFields {
+		if _, ok := afterFields[field]; !ok {
+			changes[field] = models.FieldChange{From: value, To: nil}
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// auditFields flattens an entity into its top-level JSON fields
+func auditFields(entity interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if entity == nil {
+		return fields
+	}
+
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return fields
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fields
+	}
+
+	for field := range auditIgnoredFields {
+		delete(fields, field)
+	}
+	return fields
+}
+
+// recentActivities returns the latest audit entries made by the user
+func recentActivities(ctx context.Context, userID primitive.ObjectID) ([]models.ProfileActivity, error) {
+	opts := options.Find()
+	opts.SetSort(bson.M{"created_at": -1})
+	opts.SetLimit(recentActivityLimit)
+
+	cursor, err := database.DB.Collection("audit_logs").Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []models.AuditLog
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	activities := make([]models.ProfileActivity, 0, len(entries))
+	for _, entry := range entries {
+		activities = append(activities, entry.ToActivity())
+	}
+	return activities, nil
+}
+
+// GetAuditLogs godoc
+// @Summary Get audit logs
+// @Description Retrieve audit log entries with pagination and filters
+// @Tags audit-logs
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param user_id query string false "Filter by acting user ID"
+// @Param action query string false "Filter by action (create, update, delete, login)"
+// @Param entity_type query string false "Filter by entity type (user, product, order, event, reservation)"
+// @Param entity_id query string false "Filter by entity ID"
+// @Param from query string false "Only entries on or after this date (YYYY-MM-DD)"
+// @Param to query string false "Only entries on or before this date (YYYY-MM-DD)"
+// @Success 200 {object} PaginatedResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /audit-logs [get]
+func GetAuditLogs(c *gin.Context) {
+	page := parseIntParam(c.Query("page"), 1)
+	limit := parseIntParam(c.Query("limit"), 10)
+
+	collection := database.DB.Collection("audit_logs")
+	ctx := context.Background()
+
+	// Build filter
+	filter := bson.M{}
+	if userID := c.Query("user_id"); userID != "" {
+		userObjectID, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+			return
+		}
+		filter["user_id"] = userObjectID
+	}
+	if action := c.Query("action"); action != "" {
+		filter["action"] = action
+	}
+	if entityType := c.Query("entity_type"); entityType != "" {
+		filter["entity_type"] = entityType
+	}
+	if entityID := c.Query("entity_id"); entityID != "" {
+		entityObjectID, err := primitive.ObjectIDFromHex(entityID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid entity ID"})
+			return
+		}
+		filter["entity_id"] = entityObjectID
+	}
+
+	createdAt := bson.M{}
+	if from := c.Query("from"); from != "" {
+		fromDate, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+		createdAt["$gte"] = fromDate
+	}
+	if to := c.Query("to"); to != "" {
+		toDate, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		createdAt["$lt"] = toDate.AddDate(0, 0, 1)
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	// Get total count
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to count audit logs"})
+		return
+	}
+
+	// Get paginated results
+	opts := options.Find()
+	opts.SetSkip(int64((page - 1) * limit))
+	opts.SetLimit(int64(limit))
+	opts.SetSort(bson.M{"created_at": -1})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch audit logs"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var entries []models.AuditLog
+	if err = cursor.All(ctx, &entries); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to decode audit logs"})
+		return
+	}
+
+	// Convert to response format
+	var entryResponses []models.AuditLogResponse
+	for _, entry := range entries {
+		entryResponses = append(entryResponses, entry.ToResponse())
+	}
+
+	response := PaginatedResponse{
+		Data:       entryResponses,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -2,8 +2,9 @@ package handlers
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 	"vibanda-village-admin-backend/internal/config"
 	"vibanda-village-admin-backend/internal/database"
@@ -13,43 +14,122 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var errInvitationUsed = errors.New("invitation already used")
+
 // LoginResponse represents login response
 type LoginResponse struct {
-	Token string              `json:"token"`
-	User  models.UserResponse `json:"user"`
+	Token        string              `json:"token"`
+	RefreshToken string              `json:"refresh_token"`
+	ExpiresIn    int64               `json:"expires_in"`
+	User         models.UserResponse `json:"user"`
+}
+
+// issueTokens creates a short-lived access token and a new refresh token for the user.
+// Refresh tokens rotated from the same login share familyID; pass primitive.NilObjectID
+// to start a new family.
+func issueTokens(ctx context.Context, c *gin.Context, user *models.User, familyID primitive.ObjectID) (LoginResponse, primitive.ObjectID, error) {
+	cfg := config.Load()
+
+	accessExpiration := time.Duration(cfg.AccessTokenMinutes) * time.Minute
+	token, err := utils.GenerateToken(user, cfg.JWTSecret, accessExpiration)
+	if err != nil {
+		return LoginResponse{}, primitive.NilObjectID, err
+	}
+
+	refreshToken, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return LoginResponse{}, primitive.NilObjectID, err
+	}
+
+	if familyID.IsZero() {
+		familyID = primitive.NewObjectID()
+	}
+
+	now := time.Now()
+	record := models.RefreshToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(refreshToken),
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+		ExpiresAt: now.AddDate(0, 0, cfg.RefreshTokenDays),
+		CreatedAt: now,
+	}
+	if _, err := database.DB.Collection("refresh_tokens").InsertOne(ctx, record); err != nil {
+		return LoginResponse{}, primitive.NilObjectID, err
+	}
+
+	response := LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(accessExpiration.Seconds()),
+		User:         user.ToResponse(),
+	}
+	return response, record.ID, nil
+}
+
+// revokeRefreshTokens revokes every unrevoked refresh token matching filter
+func revokeRefreshTokens(ctx context.Context, filter bson.M) error {
+	filter["revoked_at"] = nil
+	_, err := database.DB.Collection("refresh_tokens").UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	return err
 }
 
 // Register godoc
 // @Summary Register a new user
-// @Description Register a new user account
+// @Description Register a new user account using an invitation. The role is taken from the invitation and the email must match it.
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Param request body models.RegisterRequest true "Registration data"
+// @Param request body models.InvitedRegisterRequest true "Registration data"
 // @Success 201 {object} models.UserResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /auth/register [post]
 func Register(c *gin.Context) {
-	var req models.RegisterRequest
+	if !config.Load().RegistrationEnabled {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "Registration is currently disabled. Please contact an administrator."})
+		return
+	}
+
+	var req models.InvitedRegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format. Please check your input data and try again."})
 		return
 	}
+	if req.InviteToken == "" {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "An invitation is required to register. Please contact an administrator."})
+		return
+	}
 
-	fmt.Printf("Register payload: %+v\n", req)
-
-	// Check if user already exists
 	collection := database.DB.Collection("users")
+	invitations := database.DB.Collection("invitations")
 	ctx := context.Background()
 
+	// Look up the invitation
+	var invitation models.Invitation
+	err := invitations.FindOne(ctx, bson.M{"token_hash": utils.HashToken(req.InviteToken)}).Decode(&invitation)
+	if err != nil || invitation.Status() != models.InvitationStatusPending {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "This invitation is invalid or has expired. Please ask for a new one."})
+		return
+	}
+	if !strings.EqualFold(strings.TrimSpace(req.Email), invitation.Email) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "Please register with the email address the invitation was sent to."})
+		return
+	}
+
+	// Check if user already exists
 	var existingUser models.User
-	err := collection.FindOne(ctx, bson.M{
+	err = collection.FindOne(ctx, bson.M{
 		"$or": []bson.M{
-			{"email": req.Email},
+			{"email": invitation.Email},
 			{"username": req.Username},
 		},
 	}).Decode(&existingUser)
@@ -69,19 +149,50 @@ func Register(c *gin.Context) {
 	// Create user
 	now := time.Now()
 	user := models.User{
-		ID:        primitive.NewObjectID(),
-		Name:      req.Name,
-		Email:     req.Email,
-		Username:  req.Username,
-		Password:  hashedPassword,
-		Phone:     req.Phone,
-		Role:      req.Role,
-		Status:    models.StatusActive,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:         primitive.NewObjectID(),
+		Name:       req.Name,
+		Email:      invitation.Email,
+		Username:   req.Username,
+		Password:   hashedPassword,
+		Phone:      req.Phone,
+		Department: req.Department,
+		Bio:        req.Bio,
+		Role:       invitation.Role,
+		Status:     models.StatusActive,
+		CreatedAt:  now,
+		UpdatedAt:  now,
 	}
 
-	_, err = collection.InsertOne(ctx, user)
+	session, err := database.Client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "An error occurred while creating your account. Please try again later."})
+		return
+	}
+	defer session.EndSession(ctx)
+
+	// Consume the invitation and create the account together, so an invitation is used exactly once
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		result, err := invitations.UpdateOne(sessCtx,
+			bson.M{
+				"_id":        invitation.ID,
+				"used_at":    nil,
+				"revoked_at": nil,
+				"expires_at": bson.M{"$gt": now},
+			},
+			bson.M{"$set": bson.M{"used_at": now, "used_by": user.ID, "updated_at": now}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 0 {
+			return nil, errInvitationUsed
+		}
+		return collection.InsertOne(sessCtx, user)
+	})
+	if errors.Is(err, errInvitationUsed) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "This invitation is invalid or has expired. Please ask for a new one."})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "An error occurred while creating your account. Please try again later."})
 		return
@@ -92,7 +203,7 @@ func Register(c *gin.Context) {
 
 // Login godoc
 // @Summary Login user
-// @Description Authenticate user and return JWT token
+// @Description Authenticate user and return a short-lived JWT access token and a refresh token
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -109,8 +220,6 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	fmt.Printf("Login payload: %+v\n", req)
-
 	// Find user by email
 	collection := database.DB.Collection("users")
 	ctx := context.Background()
@@ -145,22 +254,142 @@ func Login(c *gin.Context) {
 		// Log error but don't fail login
 	}
 
-	// Generate JWT token
-	cfg := config.Load()
-	token, err := utils.GenerateToken(&user, cfg.JWTSecret, cfg.JWTExpirationHours)
+	// Generate access and refresh tokens
+	response, _, err := issueTokens(ctx, c, &user, primitive.NilObjectID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "An error occurred while logging you in. Please try again later."})
 		return
 	}
 
-	response := LoginResponse{
-		Token: token,
-		User:  user.ToResponse(),
+	recordAuditAs(c, user.ID, models.AuditActionLogin, "user", user.ID, nil, nil)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RefreshToken godoc
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and refresh token. Each refresh token can be used once; reusing one revokes every token issued from the same login.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/refresh [post]
+func RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format. Please check your input data and try again."})
+		return
+	}
+
+	collection := database.DB.Collection("refresh_tokens")
+	ctx := context.Background()
+
+	var stored models.RefreshToken
+	err := collection.FindOne(ctx, bson.M{"token_hash": utils.HashToken(req.RefreshToken)}).Decode(&stored)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Your session has expired. Please log in again."})
+		return
 	}
 
+	// A revoked token being presented again means it may have been stolen, so end the whole session
+	if stored.RevokedAt != nil {
+		_ = revokeRefreshTokens(ctx, bson.M{"family_id": stored.FamilyID})
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Your session has expired. Please log in again."})
+		return
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Your session has expired. Please log in again."})
+		return
+	}
+
+	// Claim the token so that concurrent refreshes with it cannot both succeed
+	now := time.Now()
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": stored.ID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": now}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "An error occurred while refreshing your session. Please try again later."})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Your session has expired. Please log in again."})
+		return
+	}
+
+	var user models.User
+	err = database.DB.Collection("users").FindOne(ctx, bson.M{"_id": stored.UserID}).Decode(&user)
+	if err != nil || user.Status != models.StatusActive {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Your account is currently inactive. Please contact support for assistance."})
+		return
+	}
+
+	response, replacementID, err := issueTokens(ctx, c, &user, stored.FamilyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "An error occurred while refreshing your session. Please try again later."})
+		return
+	}
+
+	_, _ = collection.UpdateOne(ctx, bson.M{"_id": stored.ID}, bson.M{"$set": bson.M{"replaced_by": replacementID}})
+
 	c.JSON(http.StatusOK, response)
 }
 
+// Logout godoc
+// @Summary Logout user
+// @Description Revoke the current access token and the given refresh token, or every refresh token of the user when all_devices is set
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.LogoutRequest false "Logout options"
+// @Success 204 {object} nil
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/logout [post]
+func Logout(c *gin.Context) {
+	var req models.LogoutRequest
+	// The body is optional; without it only the current access token is revoked
+	_ = c.ShouldBindJSON(&req)
+
+	userObjectID := currentUserID(c)
+	ctx := context.Background()
+
+	jti := c.GetString("jti")
+	expiresAt, _ := c.Get("token_expires_at")
+	revoked := models.RevokedToken{
+		ID:        jti,
+		UserID:    userObjectID,
+		ExpiresAt: expiresAt.(time.Time),
+		RevokedAt: time.Now(),
+	}
+	_, err := database.DB.Collection("revoked_tokens").UpdateOne(ctx,
+		bson.M{"_id": jti},
+		bson.M{"$setOnInsert": revoked},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "An error occurred while logging you out. Please try again later."})
+		return
+	}
+
+	if req.AllDevices {
+		err = revokeRefreshTokens(ctx, bson.M{"user_id": userObjectID})
+	} else if req.RefreshToken != "" {
+		err = revokeRefreshTokens(ctx, bson.M{"user_id": userObjectID, "token_hash": utils.HashToken(req.RefreshToken)})
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "An error occurred while logging you out. Please try again later."})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
 // GetProfile godoc
 // @Summary Get user profile
 // @Description Get current user profile information with role-based data
@@ -198,23 +427,11 @@ func GetProfile(c *gin.Context) {
 	// Build role display name
 	roleDisplay := getRoleDisplay(user.Role)
 
-	// Build recent activities (mock data for now - in real app, this would come from activity logs)
-	recentActivities := []models.ProfileActivity{
-		{
-			ID:          "1",
-			Description: "Logged into admin dashboard",
-			Timestamp:   time.Now().Add(-time.Hour * 2),
-		},
-		{
-			ID:          "2",
-			Description: "Updated profile information",
-			Timestamp:   time.Now().Add(-time.Hour * 24),
-		},
-		{
-			ID:          "3",
-			Description: "Created new user account",
-			Timestamp:   time.Now().Add(-time.Hour * 48),
-		},
+	// Build recent activities from the audit log
+	recentActivities, err := recentActivities(ctx, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "An error occurred while retrieving your profile. Please try again later."})
+		return
 	}
 
 	// Create comprehensive profile response
@@ -242,64 +459,23 @@ func GetProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, profileResponse)
 }
 
-// Helper function to get role-based permissions
+// Helper function to get role-based permissions, derived from models.RolePermissions
+// so the profile always matches what the API enforces
 func getRolePermissions(role models.UserRole) models.ProfilePermissions {
-	switch role {
-	case models.RoleAdmin:
-		return models.ProfilePermissions{
-			CanManageUsers:  true,
-			CanManageRoles:  true,
-			CanManageSystem: true,
-			AccessPermissions: []string{
-				"Full system access",
-				"User management",
-				"Role assignment",
-				"System configuration",
-				"Financial reports",
-				"Inventory management",
-				"Order processing",
-				"Reservation management",
-				"Event management",
-				"Customer data access",
-			},
-		}
-	case models.RoleManager:
-		return models.ProfilePermissions{
-			CanManageUsers:  true,
-			CanManageRoles:  false,
-			CanManageSystem: false,
-			AccessPermissions: []string{
-				"Dashboard access",
-				"Team management",
-				"Order processing",
-				"Reservation management",
-				"Event management",
-				"Inventory oversight",
-				"Staff scheduling",
-				"Basic reporting",
-			},
-		}
-	case models.RoleStaff:
-		return models.ProfilePermissions{
-			CanManageUsers:  false,
-			CanManageRoles:  false,
-			CanManageSystem: false,
-			AccessPermissions: []string{
-				"Dashboard access",
-				"Order processing",
-				"Reservation management",
-				"Event assistance",
-				"Inventory updates",
-				"Customer service",
-			},
-		}
-	default:
-		return models.ProfilePermissions{
-			CanManageUsers:    false,
-			CanManageRoles:    false,
-			CanManageSystem:   false,
-			AccessPermissions: []string{},
-		}
+	granted := role.Permissions()
+	accessPermissions := make([]string, 0, len(granted))
+	grantedNames := make([]string, 0, len(granted))
+	for _, permission := range granted {
+		accessPermissions = append(accessPermissions, models.PermissionDescriptions[permission])
+		grantedNames = append(grantedNames, string(permission))
+	}
+
+	return models.ProfilePermissions{
+		CanManageUsers:    role.HasPermission(models.PermissionUsersWrite),
+		CanManageRoles:    role.HasPermission(models.PermissionUsersAssignRole),
+		CanManageSystem:   role.HasPermission(models.PermissionSettingsManage),
+		AccessPermissions: accessPermissions,
+		Granted:           grantedNames,
 	}
 }
 
@@ -2,6 +2,10 @@ package handlers
 
 import (
 	"strconv"
+	"vibanda-village-admin-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // ErrorResponse represents a standard error response
@@ -28,3 +32,25 @@ func parseIntParam(param string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// currentUserRole returns the role of the authenticated user set by AuthMiddleware
+func currentUserRole(c *gin.Context) models.UserRole {
+	role, exists := c.Get("role")
+	if !exists {
+		return ""
+	}
+	return role.(models.UserRole)
+}
+
+// currentUserID returns the ID of the authenticated user set by AuthMiddleware
+func currentUserID(c *gin.Context) primitive.ObjectID {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return primitive.NilObjectID
+	}
+	userObjectID, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		return primitive.NilObjectID
+	}
+	return userObjectID
+}
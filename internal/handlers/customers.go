@@ -0,0 +1,663 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"vibanda-village-admin-backend/internal/customers"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var errCustomerNotFound = errors.New("customer not found")
+
+// linkCustomer returns the customer with the phone or email, creating one if
+// needed. A failure is logged rather than failing the order or reservation,
+// which is linked when the directory is next backfilled.
+func linkCustomer(ctx context.Context, name, phone, email string) primitive.ObjectID {
+	customerID, err := customers.Link(ctx, name, phone, email)
+	if err != nil {
+		log.Printf("Failed to link %s to a customer: %v", name, err)
+		return primitive.NilObjectID
+	}
+	return customerID
+}
+
+// normalizeCustomerContacts normalises phones and emails, dropping repeated ones.
+// It fails on a value that is not a phone number or an email address.
+func normalizeCustomerContacts(values []string, normalize func(string) string, kind string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, value := range values {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		key := normalize(value)
+		if key == "" {
+			return nil, fmt.Errorf("%s is not a valid %s", value, kind)
+		}
+		if !seen[key] {
+			seen[key] = true
+			normalized = append(normalized, key)
+		}
+	}
+	return normalized, nil
+}
+
+// unionStrings returns the values of a followed by those of b not in a
+func unionStrings(a, b []string) []string {
+	union := []string{}
+	seen := make(map[string]bool)
+	for _, value := range append(append([]string{}, a...), b...) {
+		if !seen[value] {
+			seen[value] = true
+			union = append(union, value)
+		}
+	}
+	return union
+}
+
+// customerResponses converts customers to responses with their stats
+func customerResponses(ctx context.Context, list []models.Customer) ([]models.CustomerResponse, error) {
+	ids := make([]primitive.ObjectID, 0, len(list))
+	for _, customer := range list {
+		ids = append(ids, customer.ID)
+	}
+	stats, err := customers.Stats(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []models.CustomerResponse{}
+	for _, customer := range list {
+		responses = append(responses, customer.ToResponse(stats[customer.ID]))
+	}
+	return responses, nil
+}
+
+// GetCustomers godoc
+// @Summary Get all customers
+// @Description Retrieve the customer directory with pagination, most recently seen first, with each customer's visits and spend
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param search query string false "Search by name, phone or email"
+// @Param tag query string false "Filter by tag"
+// @Success 200 {object} PaginatedResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /customers [get]
+func GetCustomers(c *gin.Context) {
+	page := parseIntParam(c.Query("page"), 1)
+	limit := parseIntParam(c.Query("limit"), 10)
+	search := strings.TrimSpace(c.Query("search"))
+	tag := strings.ToLower(strings.TrimSpace(c.Query("tag")))
+
+	collection := database.DB.Collection("customers")
+	ctx := context.Background()
+
+	// Build filter
+	filter := bson.M{}
+	if search != "" {
+		or := []bson.M{
+			{"name": bson.M{"$regex": search, "$options": "i"}},
+			{"phones": bson.M{"$regex": search, "$options": "i"}},
+			{"emails": bson.M{"$regex": search, "$options": "i"}},
+		}
+		// Phones are stored normalised, so "0712 345 678" finds 254712345678
+		if phone := customers.NormalizePhone(search); phone != "" {
+			or = append(or, bson.M{"phones": phone})
+		}
+		filter["$or"] = or
+	}
+	if tag != "" {
+		filter["tags"] = tag
+	}
+
+	// Get total count
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to count customers"})
+		return
+	}
+
+	// Get paginated results
+	opts := options.Find()
+	opts.SetSkip(int64((page - 1) * limit))
+	opts.SetLimit(int64(limit))
+	opts.SetSort(bson.M{"last_seen_at": -1})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch customers"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var list []models.Customer
+	if err = cursor.All(ctx, &list); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to decode customers"})
+		return
+	}
+
+	responses, err := customerResponses(ctx, list)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch customer history"})
+		return
+	}
+
+	response := PaginatedResponse{
+		Data:       responses,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetCustomer godoc
+// @Summary Get customer by ID
+// @Description Retrieve a customer with their visits, total spend and last visit
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Success 200 {object} models.CustomerResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /customers/{id} [get]
+func GetCustomer(c *gin.Context) {
+	id := c.Param("id")
+	customerObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid customer ID"})
+		return
+	}
+
+	ctx := context.Background()
+
+	var customer models.Customer
+	err = database.DB.Collection("customers").FindOne(ctx, bson.M{"_id": customerObjectID}).Decode(&customer)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Customer not found"})
+		return
+	}
+
+	stats, err := customers.Stats(ctx, []primitive.ObjectID{customer.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch customer history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, customer.ToResponse(stats[customer.ID]))
+}
+
+// GetCustomerHistory godoc
+// @Summary Get a customer's visit history
+// @Description Retrieve a customer's orders and reservations, newest first
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param limit query int false "Most orders and reservations to return" default(50)
+// @Success 200 {object} models.CustomerHistoryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /customers/{id}/history [get]
+func GetCustomerHistory(c *gin.Context) {
+	id := c.Param("id")
+	customerObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid customer ID"})
+		return
+	}
+	limit := parseIntParam(c.Query("limit"), 50)
+	if limit < 1 {
+		limit = 50
+	}
+
+	ctx := context.Background()
+
+	count, err := database.DB.Collection("customers").CountDocuments(ctx, bson.M{"_id": customerObjectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch customer"})
+		return
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Customer not found"})
+		return
+	}
+
+	filter := bson.M{"customer_id": customerObjectID}
+
+	orderOpts := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(int64(limit))
+	cursor, err := database.DB.Collection("orders").Find(ctx, filter, orderOpts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch orders"})
+		return
+	}
+	var orders []models.Order
+	if err = cursor.All(ctx, &orders); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to decode orders"})
+		return
+	}
+
+	reservationOpts := options.Find().SetSort(bson.D{{Key: "date", Value: -1}, {Key: "time", Value: -1}}).SetLimit(int64(limit))
+	cursor, err = database.DB.Collection("reservations").Find(ctx, filter, reservationOpts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch reservations"})
+		return
+	}
+	var reservations []models.Reservation
+	if err = cursor.All(ctx, &reservations); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to decode reservations"})
+		return
+	}
+
+	history := models.CustomerHistoryResponse{
+		Orders:       []models.OrderResponse{},
+		Reservations: []models.ReservationResponse{},
+	}
+	for _, order := range orders {
+		history.Orders = append(history.Orders, order.ToResponse())
+	}
+	for _, reservation := range reservations {
+		history.Reservations = append(history.Reservations, reservation.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// CreateCustomer godoc
+// @Summary Create a customer
+// @Description Add a customer to the directory by hand. Customers are also added when orders and reservations are placed.
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateCustomerRequest true "Customer data"
+// @Success 201 {object} models.CustomerResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /customers [post]
+func CreateCustomer(c *gin.Context) {
+	var req models.CreateCustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if len(name) < 2 || len(name) > 100 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Name must be between 2 and 100 characters"})
+		return
+	}
+	if len(req.Notes) > 2000 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Notes cannot be longer than 2000 characters"})
+		return
+	}
+	phones, err := normalizeCustomerContacts([]string{req.Phone}, customers.NormalizePhone, "phone number")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	emails, err := normalizeCustomerContacts([]string{req.Email}, customers.NormalizeEmail, "email address")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if len(phones) == 0 && len(emails) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "A phone number or an email address is required"})
+		return
+	}
+
+	now := time.Now()
+	customer := models.Customer{
+		ID:         primitive.NewObjectID(),
+		Name:       name,
+		Phones:     phones,
+		Emails:     emails,
+		Notes:      strings.TrimSpace(req.Notes),
+		Tags:       customers.NormalizeTags(req.Tags),
+		LastSeenAt: now,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	_, err = database.DB.Collection("customers").InsertOne(context.Background(), customer)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "A customer with this phone number or email address already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create customer"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionCreate, "customer", customer.ID, nil, customer)
+
+	c.JSON(http.StatusCreated, customer.ToResponse(models.CustomerStats{}))
+}
+
+// UpdateCustomer godoc
+// @Summary Update a customer
+// @Description Update a customer's name, contact details, notes or tags
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param request body models.UpdateCustomerRequest true "Customer update data"
+// @Success 200 {object} models.CustomerResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /customers/{id} [put]
+func UpdateCustomer(c *gin.Context) {
+	id := c.Param("id")
+	customerObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid customer ID"})
+		return
+	}
+
+	var req models.UpdateCustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	collection := database.DB.Collection("customers")
+	ctx := context.Background()
+
+	var customer models.Customer
+	err = collection.FindOne(ctx, bson.M{"_id": customerObjectID}).Decode(&customer)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Customer not found"})
+		return
+	}
+	before := customer
+
+	if name := strings.TrimSpace(req.Name); name != "" {
+		if len(name) < 2 || len(name) > 100 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Name must be between 2 and 100 characters"})
+			return
+		}
+		customer.Name = name
+	}
+	if req.Phones != nil {
+		phones, err := normalizeCustomerContacts(*req.Phones, customers.NormalizePhone, "phone number")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		customer.Phones = phones
+	}
+	if req.Emails != nil {
+		emails, err := normalizeCustomerContacts(*req.Emails, customers.NormalizeEmail, "email address")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		customer.Emails = emails
+	}
+	if len(customer.Phones) == 0 && len(customer.Emails) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "A phone number or an email address is required"})
+		return
+	}
+	if req.Notes != nil {
+		if len(*req.Notes) > 2000 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Notes cannot be longer than 2000 characters"})
+			return
+		}
+		customer.Notes = strings.TrimSpace(*req.Notes)
+	}
+	if req.Tags != nil {
+		customer.Tags = customers.NormalizeTags(*req.Tags)
+	}
+
+	customer.UpdatedAt = time.Now()
+
+	update := bson.M{"$set": bson.M{
+		"name":       customer.Name,
+		"phones":     customer.Phones,
+		"emails":     customer.Emails,
+		"notes":      customer.Notes,
+		"tags":       customer.Tags,
+		"updated_at": customer.UpdatedAt,
+	}}
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": customer.ID}, update)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Another customer has this phone number or email address; merge the two instead"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update customer"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionUpdate, "customer", customer.ID, before, customer)
+
+	stats, err := customers.Stats(ctx, []primitive.ObjectID{customer.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch customer history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, customer.ToResponse(stats[customer.ID]))
+}
+
+// DeleteCustomer godoc
+// @Summary Delete a customer
+// @Description Delete a customer from the directory. Their orders and reservations are kept but no longer linked to them.
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /customers/{id} [delete]
+func DeleteCustomer(c *gin.Context) {
+	id := c.Param("id")
+	customerObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid customer ID"})
+		return
+	}
+
+	collection := database.DB.Collection("customers")
+	ctx := context.Background()
+
+	var customer models.Customer
+	err = collection.FindOne(ctx, bson.M{"_id": customerObjectID}).Decode(&customer)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Customer not found"})
+		return
+	}
+
+	session, err := database.Client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete customer"})
+		return
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		if _, err := collection.DeleteOne(sessCtx, bson.M{"_id": customer.ID}); err != nil {
+			return nil, err
+		}
+		// A null customer marks them as unlinked on purpose, so that Backfill does
+		// not link them to a new customer
+		unlink := bson.M{"$set": bson.M{"customer_id": nil}}
+		for _, name := range []string{"orders", "reservations"} {
+			if _, err := database.DB.Collection(name).UpdateMany(sessCtx, bson.M{"customer_id": customer.ID}, unlink); err != nil {
+				return nil, err
+			}
+		}
+		_, err := database.DB.Collection("loyalty_entries").DeleteMany(sessCtx, bson.M{"customer_id": customer.ID})
+		return nil, err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete customer"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionDelete, "customer", customer.ID, customer, nil)
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// MergeCustomers godoc
+// @Summary Merge a duplicate customer into another
+// @Description Move the source customer's phones, emails, tags, notes, orders, reservations and loyalty points to this customer, then delete the source
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID to keep"
+// @Param request body models.MergeCustomersRequest true "Customer to merge in"
+// @Success 200 {object} models.CustomerResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /customers/{id}/merge [post]
+func MergeCustomers(c *gin.Context) {
+	id := c.Param("id")
+	targetObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid customer ID"})
+		return
+	}
+
+	var req models.MergeCustomersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	sourceObjectID, err := primitive.ObjectIDFromHex(req.SourceID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid source customer ID"})
+		return
+	}
+	if sourceObjectID == targetObjectID {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "A customer cannot be merged into itself"})
+		return
+	}
+
+	collection := database.DB.Collection("customers")
+	ctx := context.Background()
+
+	session, err := database.Client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to merge customers"})
+		return
+	}
+	defer session.EndSession(ctx)
+
+	var target, source, merged models.Customer
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		if err := collection.FindOne(sessCtx, bson.M{"_id": targetObjectID}).Decode(&target); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, errCustomerNotFound
+			}
+			return nil, err
+		}
+		if err := collection.FindOne(sessCtx, bson.M{"_id": sourceObjectID}).Decode(&source); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, errCustomerNotFound
+			}
+			return nil, err
+		}
+
+		merged = target
+		merged.Phones = unionStrings(target.Phones, source.Phones)
+		merged.Emails = unionStrings(target.Emails, source.Emails)
+		merged.Tags = unionStrings(target.Tags, source.Tags)
+		if merged.Name == "" {
+			merged.Name = source.Name
+		}
+		if source.Notes != "" {
+			if merged.Notes != "" {
+				merged.Notes += "\n\n"
+			}
+			merged.Notes += source.Notes
+		}
+		if source.CreatedAt.Before(merged.CreatedAt) {
+			merged.CreatedAt = source.CreatedAt
+		}
+		if source.LastSeenAt.After(merged.LastSeenAt) {
+			merged.LastSeenAt = source.LastSeenAt
+		}
+		merged.Points += source.Points
+		merged.UpdatedAt = time.Now()
+
+		// The source goes first, so its phones and emails are free for the target
+		if _, err := collection.DeleteOne(sessCtx, bson.M{"_id": source.ID}); err != nil {
+			return nil, err
+		}
+		update := bson.M{"$set": bson.M{
+			"name":         merged.Name,
+			"phones":       merged.Phones,
+			"emails":       merged.Emails,
+			"notes":        merged.Notes,
+			"tags":         merged.Tags,
+			"last_seen_at": merged.LastSeenAt,
+			"created_at":   merged.CreatedAt,
+			"updated_at":   merged.UpdatedAt,
+		}, "$inc": bson.M{"loyalty_points": source.Points}}
+		if _, err := collection.UpdateOne(sessCtx, bson.M{"_id": merged.ID}, update); err != nil {
+			return nil, err
+		}
+
+		relink := bson.M{"$set": bson.M{"customer_id": merged.ID}}
+		for _, name := range []string{"orders", "reservations", "loyalty_entries"} {
+			if _, err := database.DB.Collection(name).UpdateMany(sessCtx, bson.M{"customer_id": source.ID}, relink); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if errors.Is(err, errCustomerNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Customer not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to merge customers"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionUpdate, "customer", merged.ID, target, merged)
+	recordAudit(c, models.AuditActionDelete, "customer", source.ID, source, nil)
+
+	stats, err := customers.Stats(ctx, []primitive.ObjectID{merged.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch customer history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, merged.ToResponse(stats[merged.ID]))
+}
@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"vibanda-village-admin-backend/internal/config"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	errDeliveryLocationRequired = errors.New("delivery address location is required")
+	errOutsideDeliveryZones     = errors.New("delivery address is outside the delivery zones")
+	errDeliveryMinimumNotMet    = errors.New("delivery minimum order not met")
+)
+
+// geoKeysErrorCode is the MongoDB error code for a geometry that the 2dsphere
+// index cannot use, such as a polygon whose edges cross
+const geoKeysErrorCode = 16755
+
+// isGeoKeysError reports whether a write failed because of an unusable geometry
+func isGeoKeysError(err error) bool {
+	var writeErr mongo.WriteException
+	if errors.As(err, &writeErr) {
+		for _, e := range writeErr.WriteErrors {
+			if e.Code == geoKeysErrorCode {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findDeliveryZone returns the active delivery zone with the highest priority
+// that contains the point, or mongo.ErrNoDocuments
+func findDeliveryZone(ctx context.Context, point models.GeoPoint) (*models.DeliveryZone, error) {
+	filter := bson.M{
+		"active": true,
+		"area":   bson.M{"$geoIntersects": bson.M{"$geometry": point}},
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "fee", Value: 1}})
+
+	var zone models.DeliveryZone
+	if err := database.DB.Collection("delivery_zones").FindOne(ctx, filter, opts).Decode(&zone); err != nil {
+		return nil, err
+	}
+	return &zone, nil
+}
+
+// applyDeliveryZone sets the delivery fee of a delivery order from the zone its
+// address is in. Until any zone is set up, every address is accepted and charged
+// the configured delivery fee.
+func applyDeliveryZone(ctx context.Context, order *models.Order) error {
+	zones, err := database.DB.Collection("delivery_zones").CountDocuments(ctx, bson.M{"active": true})
+	if err != nil {
+		return err
+	}
+	if zones == 0 {
+		order.DeliveryFee = models.Money(config.Load().DeliveryFee)
+		order.DeliveryZone = nil
+		return nil
+	}
+
+	if order.DeliveryAddress == nil || order.DeliveryAddress.Location == nil {
+		return errDeliveryLocationRequired
+	}
+	zone, err := findDeliveryZone(ctx, *order.DeliveryAddress.Location)
+	if err == mongo.ErrNoDocuments {
+		return errOutsideDeliveryZones
+	}
+	if err != nil {
+		return err
+	}
+
+	order.DeliveryFee = zone.Fee
+	order.DeliveryZone = &models.AppliedDeliveryZone{
+		ZoneID:       zone.ID,
+		Name:         zone.Name,
+		MinimumOrder: zone.MinimumOrder,
+		EtaMinutes:   zone.EtaMinutes,
+	}
+	return nil
+}
+
+// checkDeliveryMinimum rejects a priced delivery order whose items come to less
+// than the minimum order of its zone
+func checkDeliveryMinimum(order *models.Order) error {
+	zone := order.DeliveryZone
+	if zone == nil || order.Pricing == nil || order.Pricing.Subtotal >= zone.MinimumOrder {
+		return nil
+	}
+	return fmt.Errorf("%w: delivery to %s needs at least %s of items", errDeliveryMinimumNotMet, zone.Name, zone.MinimumOrder)
+}
+
+// validateDeliveryZone checks the name, area and amounts of a delivery zone
+func validateDeliveryZone(zone *models.DeliveryZone) error {
+	switch {
+	case len(zone.Name) < 2 || len(zone.Name) > 50:
+		return errors.New("name must be between 2 and 50 characters")
+	case !zone.Area.IsValid():
+		return errors.New("area must be a GeoJSON Polygon of closed rings with at least four [longitude, latitude] positions")
+	case zone.Fee < 0, zone.MinimumOrder < 0:
+		return errors.New("fee and minimum order cannot be negative")
+	case zone.EtaMinutes < 0:
+		return errors.New("ETA cannot be negative")
+	}
+	return nil
+}
+
+// GetDeliveryZones godoc
+// @Summary Get delivery zones
+// @Description Retrieve the delivery zones, highest priority first
+// @Tags delivery-zones
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.DeliveryZoneResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /delivery-zones [get]
+func GetDeliveryZones(c *gin.Context) {
+	collection := database.DB.Collection("delivery_zones")
+	ctx := context.Background()
+
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "name", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch delivery zones"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var zones []models.DeliveryZone
+	if err = cursor.All(ctx, &zones); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to decode delivery zones"})
+		return
+	}
+
+	zoneResponses := []models.DeliveryZoneResponse{}
+	for _, zone := range zones {
+		zoneResponses = append(zoneResponses, zone.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, zoneResponses)
+}
+
+// GetDeliveryZone godoc
+// @Summary Get delivery zone by ID
+// @Description Retrieve a specific delivery zone by ID
+// @Tags delivery-zones
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Delivery zone ID"
+// @Success 200 {object} models.DeliveryZoneResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /delivery-zones/{id} [get]
+func GetDeliveryZone(c *gin.Context) {
+	id := c.Param("id")
+	zoneObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid delivery zone ID"})
+		return
+	}
+
+	var zone models.DeliveryZone
+	err = database.DB.Collection("delivery_zones").FindOne(context.Background(), bson.M{"_id": zoneObjectID}).Decode(&zone)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Delivery zone not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, zone.ToResponse())
+}
+
+// ResolveDeliveryZone godoc
+// @Summary Find the delivery zone of a location
+// @Description Find the active delivery zone that covers a location, with its fee, minimum order and ETA. Where zones overlap, the one with the highest priority applies.
+// @Tags delivery-zones
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param lat query number true "Latitude"
+// @Param lng query number true "Longitude"
+// @Success 200 {object} models.DeliveryZoneResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /delivery-zones/resolve [get]
+func ResolveDeliveryZone(c *gin.Context) {
+	latitude, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+	longitude, lngErr := strconv.ParseFloat(c.Query("lng"), 64)
+	point := models.NewGeoPoint(latitude, longitude)
+	if latErr != nil || lngErr != nil || !point.IsValid() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "lat and lng must be a valid latitude and longitude"})
+		return
+	}
+
+	zone, err := findDeliveryZone(context.Background(), point)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "No delivery zone covers this location"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to resolve delivery zone"})
+		return
+	}
+
+	c.JSON(http.StatusOK, zone.ToResponse())
+}
+
+// CreateDeliveryZone godoc
+// @Summary Create a delivery zone
+// @Description Add an area that delivery orders are accepted for, drawn as a GeoJSON polygon, with its delivery fee, minimum order and ETA
+// @Tags delivery-zones
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateDeliveryZoneRequest true "Delivery zone data"
+// @Success 201 {object} models.DeliveryZoneResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /delivery-zones [post]
+func CreateDeliveryZone(c *gin.Context) {
+	var req models.CreateDeliveryZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	now := time.Now()
+	zone := models.DeliveryZone{
+		ID:           primitive.NewObjectID(),
+		Name:         strings.TrimSpace(req.Name),
+		Area:         req.Area,
+		Fee:          req.Fee,
+		MinimumOrder: req.MinimumOrder,
+		EtaMinutes:   req.EtaMinutes,
+		Priority:     req.Priority,
+		Active:       active,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := validateDeliveryZone(&zone); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	_, err := database.DB.Collection("delivery_zones").InsertOne(context.Background(), zone)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "A delivery zone with this name already exists"})
+		return
+	}
+	if isGeoKeysError(err) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Area is not a valid polygon; check that its edges do not cross"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create delivery zone"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionCreate, "delivery_zone", zone.ID, nil, zone)
+
+	c.JSON(http.StatusCreated, zone.ToResponse())
+}
+
+// UpdateDeliveryZone godoc
+// @Summary Update a delivery zone
+// @Description Change a delivery zone's area, terms or status. Changes apply to orders placed afterwards.
+// @Tags delivery-zones
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Delivery zone ID"
+// @Param request body models.UpdateDeliveryZoneRequest true "Delivery zone update data"
+// @Success 200 {object} models.DeliveryZoneResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /delivery-zones/{id} [put]
+func UpdateDeliveryZone(c *gin.Context) {
+	id := c.Param("id")
+	zoneObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid delivery zone ID"})
+		return
+	}
+
+	var req models.UpdateDeliveryZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	collection := database.DB.Collection("delivery_zones")
+	ctx := context.Background()
+
+	var zone models.DeliveryZone
+	err = collection.FindOne(ctx, bson.M{"_id": zoneObjectID}).Decode(&zone)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Delivery zone not found"})
+		return
+	}
+	before := zone
+
+	if name := strings.TrimSpace(req.Name); name != "" {
+		zone.Name = name
+	}
+	if req.Area != nil {
+		zone.Area = *req.Area
+	}
+	if req.Fee != nil {
+		zone.Fee = *req.Fee
+	}
+	if req.MinimumOrder != nil {
+		zone.MinimumOrder = *req.MinimumOrder
+	}
+	if req.EtaMinutes != nil {
+		zone.EtaMinutes = *req.EtaMinutes
+	}
+	if req.Priority != nil {
+		zone.Priority = *req.Priority
+	}
+	if req.Active != nil {
+		zone.Active = *req.Active
+	}
+	if err := validateDeliveryZone(&zone); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	zone.UpdatedAt = time.Now()
+
+	update := bson.M{"$set": bson.M{
+		"name":          zone.Name,
+		"area":          zone.Area,
+		"fee":           zone.Fee,
+		"minimum_order": zone.MinimumOrder,
+		"eta_minutes":   zone.EtaMinutes,
+		"priority":      zone.Priority,
+		"active":        zone.Active,
+		"updated_at":    zone.UpdatedAt,
+	}}
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": zone.ID}, update)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "A delivery zone with this name already exists"})
+		return
+	}
+	if isGeoKeysError(err) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Area is not a valid polygon; check that its edges do not cross"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update delivery zone"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionUpdate, "delivery_zone", zone.ID, before, zone)
+
+	c.JSON(http.StatusOK, zone.ToResponse())
+}
+
+// DeleteDeliveryZone godoc
+// @Summary Delete a delivery zone
+// @Description Delete a delivery zone. Orders placed in it keep the zone's name and terms.
+// @Tags delivery-zones
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Delivery zone ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /delivery-zones/{id} [delete]
+func DeleteDeliveryZone(c *gin.Context) {
+	id := c.Param("id")
+	zoneObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid delivery zone ID"})
+		return
+	}
+
+	collection := database.DB.Collection("delivery_zones")
+	ctx := context.Background()
+
+	var zone models.DeliveryZone
+	err = collection.FindOne(ctx, bson.M{"_id": zoneObjectID}).Decode(&zone)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Delivery zone not found"})
+		return
+	}
+
+	_, err = collection.DeleteOne(ctx, bson.M{"_id": zone.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete delivery zone"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionDelete, "delivery_zone", zone.ID, zone, nil)
+
+	c.JSON(http.StatusNoContent, nil)
+}
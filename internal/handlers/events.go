@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 	"vibanda-village-admin-backend/internal/database"
@@ -10,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -174,6 +176,8 @@ func CreateEvent(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditActionCreate, "event", event.ID, nil, event)
+
 	c.JSON(http.StatusCreated, event.ToResponse())
 }
 
@@ -189,6 +193,7 @@ func CreateEvent(c *gin.Context) {
 // @Success 200 {object} models.EventResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /events/{id} [put]
 func UpdateEvent(c *gin.Context) {
@@ -214,6 +219,7 @@ func UpdateEvent(c *gin.Context) {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Event not found"})
 		return
 	}
+	before := event
 
 	// Update fields
 	if req.Title != "" {
@@ -232,6 +238,10 @@ func UpdateEvent(c *gin.Context) {
 		event.Location = req.Location
 	}
 	if req.Capacity != 0 {
+		if req.Capacity < event.TicketsSold {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("Capacity cannot be lower than the %d tickets already sold", event.TicketsSold)})
+			return
+		}
 		event.Capacity = req.Capacity
 	}
 	if req.Price != 0 {
@@ -246,9 +256,6 @@ func UpdateEvent(c *gin.Context) {
 	if req.ImageURL != "" {
 		event.ImageURL = req.ImageURL
 	}
-	if req.TicketsAvailable != nil {
-		event.TicketsAvailable = *req.TicketsAvailable
-	}
 	if req.Featured != nil {
 		event.Featured = *req.Featured
 	}
@@ -258,29 +265,69 @@ func UpdateEvent(c *gin.Context) {
 
 	event.UpdatedAt = time.Now()
 
-	update := bson.M{"$set": bson.M{
-		"title":             event.Title,
-		"description":       event.Description,
-		"date":              event.Date,
-		"time":              event.Time,
-		"location":          event.Location,
-		"capacity":          event.Capacity,
-		"price":             event.Price,
-		"category":          event.Category,
-		"organizer":         event.Organizer,
-		"tickets_available": event.TicketsAvailable,
-		"image_url":         event.ImageURL,
-		"featured":          event.Featured,
-		"published":         event.Published,
-		"updated_at":        event.UpdatedAt,
-	}}
-
-	_, err = collection.UpdateOne(ctx, bson.M{"_id": eventObjectID}, update)
+	fields := bson.M{
+		"title":       event.Title,
+		"description": event.Description,
+		"date":        event.Date,
+		"time":        event.Time,
+		"location":    event.Location,
+		"capacity":    event.Capacity,
+		"price":       event.Price,
+		"category":    event.Category,
+		"organizer":   event.Organizer,
+		"image_url":   event.ImageURL,
+		"featured":    event.Featured,
+		"published":   event.Published,
+		"updated_at":  event.UpdatedAt,
+	}
+	// Values are taken literally, so that text starting with $ is not read as a field
+	set := bson.M{}
+	for field, value := range fields {
+		set[field] = bson.M{"$literal": value}
+	}
+
+	// Tickets may be sold while the event is edited, so whether they are still
+	// on sale is worked out from the sold count in the update itself: an event
+	// sold out at the old capacity reopens, as when a ticket is cancelled, unless
+	// sales are switched on or off here, and it is closed if still sold out
+	available := interface{}("$tickets_available")
+	if req.TicketsAvailable != nil {
+		available = *req.TicketsAvailable
+	} else if req.Capacity != 0 {
+		available = bson.M{"$cond": bson.A{
+			bson.M{"$gte": bson.A{bson.M{"$ifNull": bson.A{"$tickets_sold", 0}}, "$capacity"}}, true, "$tickets_available",
+		}}
+	}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"tickets_available": available}}},
+		{{Key: "$set", Value: set}},
+		{{Key: "$set", Value: bson.M{
+			"tickets_available": bson.M{"$and": bson.A{
+				"$tickets_available",
+				bson.M{"$lt": bson.A{bson.M{"$ifNull": bson.A{"$tickets_sold", 0}}, "$capacity"}},
+			}},
+		}}},
+	}
+
+	filter := bson.M{"_id": eventObjectID}
+	if req.Capacity != 0 {
+		filter["$expr"] = bson.M{"$lte": bson.A{bson.M{"$ifNull": bson.A{"$tickets_sold", 0}}, event.Capacity}}
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	err = collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		// Tickets sold since the event was read took it over the new capacity
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Capacity cannot be lower than the tickets already sold"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update event"})
 		return
 	}
 
+	recordAudit(c, models.AuditActionUpdate, "event", event.ID, before, event)
+
 	c.JSON(http.StatusOK, event.ToResponse())
 }
 
@@ -321,5 +368,7 @@ func DeleteEvent(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditActionDelete, "event", event.ID, event, nil)
+
 	c.JSON(http.StatusNoContent, nil)
 }
@@ -0,0 +1,490 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/inventory"
+	"vibanda-village-admin-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// validateIngredient checks the name, unit and amounts of an ingredient
+func validateIngredient(ingredient *models.Ingredient) error {
+	switch {
+	case len(ingredient.Name) < 2 || len(ingredient.Name) > 100:
+		return errors.New("Name must be between 2 and 100 characters")
+	case !ingredient.Unit.IsValid():
+		return errors.New("Unit must be one of: g, kg, ml, l, piece")
+	case ingredient.OnHand < 0:
+		return errors.New("On hand quantity cannot be negative")
+	case ingredient.CostPerUnit < 0:
+		return errors.New("Cost per unit cannot be negative")
+	}
+	return nil
+}
+
+// ingredientInRecipes reports whether any product's recipe uses the ingredient
+func ingredientInRecipes(ctx context.Context, ingredientID primitive.ObjectID) (bool, error) {
+	count, err := database.DB.Collection("products").CountDocuments(ctx, bson.M{"recipe.ingredient_id": ingredientID})
+	return count > 0, err
+}
+
+// checkRecipeStock fails if the ingredients on hand are not enough for the
+// quantity of a dish. Ingredients are only taken once the order is confirmed,
+// so this does not hold them for the order.
+func checkRecipeStock(ctx context.Context, name string, recipe []models.RecipeItem, quantity int) error {
+	ingredients, err := inventory.Load(ctx, inventory.IngredientIDs(recipe))
+	if err != nil {
+		return err
+	}
+	if portions := inventory.Portions(recipe, ingredients); portions < quantity {
+		return fmt.Errorf("%w: only %d %s left", errInsufficientStock, portions, name)
+	}
+	return nil
+}
+
+// GetIngredients godoc
+// @Summary Get all ingredients
+// @Description Retrieve every ingredient with its unit, quantity on hand and cost, by name
+// @Tags ingredients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param search query string false "Search by name"
+// @Success 200 {array} models.IngredientResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /ingredients [get]
+func GetIngredients(c *gin.Context) {
+	collection := database.DB.Collection("ingredients")
+	ctx := context.Background()
+
+	filter := bson.M{}
+	if search := c.Query("search"); search != "" {
+		filter["name"] = bson.M{"$regex": search, "$options": "i"}
+	}
+
+	opts := options.Find().SetSort(bson.M{"name": 1})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch ingredients"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var ingredients []models.Ingredient
+	if err = cursor.All(ctx, &ingredients); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to decode ingredients"})
+		return
+	}
+
+	ingredientResponses := []models.IngredientResponse{}
+	for _, ingredient := range ingredients {
+		ingredientResponses = append(ingredientResponses, ingredient.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, ingredientResponses)
+}
+
+// GetIngredient godoc
+// @Summary Get ingredient by ID
+// @Description Retrieve a specific ingredient by ID
+// @Tags ingredients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Ingredient ID"
+// @Success 200 {object} models.IngredientResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /ingredients/{id} [get]
+func GetIngredient(c *gin.Context) {
+	id := c.Param("id")
+	ingredientObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ingredient ID"})
+		return
+	}
+
+	var ingredient models.Ingredient
+	err = database.DB.Collection("ingredients").FindOne(context.Background(), bson.M{"_id": ingredientObjectID}).Decode(&ingredient)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Ingredient not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ingredient.ToResponse())
+}
+
+// CreateIngredient godoc
+// @Summary Create an ingredient
+// @Description Add an ingredient with its unit of measure, quantity on hand and cost per unit
+// @Tags ingredients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateIngredientRequest true "Ingredient data"
+// @Success 201 {object} models.IngredientResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /ingredients [post]
+func CreateIngredient(c *gin.Context) {
+	var req models.CreateIngredientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	now := time.Now()
+	ingredient := models.Ingredient{
+		ID:          primitive.NewObjectID(),
+		Name:        strings.TrimSpace(req.Name),
+		Unit:        req.Unit,
+		OnHand:      req.OnHand,
+		CostPerUnit: req.CostPerUnit,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := validateIngredient(&ingredient); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	_, err := database.DB.Collection("ingredients").InsertOne(context.Background(), ingredient)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "An ingredient with this name already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create ingredient"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionCreate, "ingredient", ingredient.ID, nil, ingredient)
+
+	c.JSON(http.StatusCreated, ingredient.ToResponse())
+}
+
+// UpdateIngredient godoc
+// @Summary Update an ingredient
+// @Description Update an ingredient's name, unit or cost, or replace its quantity on hand after a stocktake. The unit cannot change while recipes use the ingredient, as their quantities are in it.
+// @Tags ingredients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Ingredient ID"
+// @Param request body models.UpdateIngredientRequest true "Ingredient update data"
+// @Success 200 {object} models.IngredientResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /ingredients/{id} [put]
+func UpdateIngredient(c *gin.Context) {
+	id := c.Param("id")
+	ingredientObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ingredient ID"})
+		return
+	}
+
+	var req models.UpdateIngredientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	collection := database.DB.Collection("ingredients")
+	ctx := context.Background()
+
+	var ingredient models.Ingredient
+	err = collection.FindOne(ctx, bson.M{"_id": ingredientObjectID}).Decode(&ingredient)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Ingredient not found"})
+		return
+	}
+	before := ingredient
+
+	if name := strings.TrimSpace(req.Name); name != "" {
+		ingredient.Name = name
+	}
+	if req.Unit != "" && req.Unit != ingredient.Unit {
+		used, err := ingredientInRecipes(ctx, ingredient.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update ingredient"})
+			return
+		}
+		if used {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "The unit of an ingredient used in recipes cannot be changed"})
+			return
+		}
+		ingredient.Unit = req.Unit
+	}
+	if req.OnHand != nil {
+		ingredient.OnHand = *req.OnHand
+	}
+	if req.CostPerUnit != nil {
+		ingredient.CostPerUnit = *req.CostPerUnit
+	}
+	if err := validateIngredient(&ingredient); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ingredient.UpdatedAt = time.Now()
+
+	set := bson.M{
+		"name":          ingredient.Name,
+		"unit":          ingredient.Unit,
+		"cost_per_unit": ingredient.CostPerUnit,
+		"updated_at":    ingredient.UpdatedAt,
+	}
+	// The count is only written when given, so orders confirmed meanwhile are not undone
+	if req.OnHand != nil {
+		set["on_hand"] = ingredient.OnHand
+	}
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": ingredient.ID}, bson.M{"$set": set})
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "An ingredient with this name already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update ingredient"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionUpdate, "ingredient", ingredient.ID, before, ingredient)
+
+	c.JSON(http.StatusOK, ingredient.ToResponse())
+}
+
+// AdjustIngredient godoc
+// @Summary Adjust an ingredient's quantity on hand
+// @Description Add a delivery to an ingredient's quantity on hand with a positive change, or take off waste with a negative one. The quantity cannot go below zero.
+// @Tags ingredients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Ingredient ID"
+// @Param request body models.AdjustIngredientRequest true "Change in the ingredient's unit"
+// @Success 200 {object} models.IngredientResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /ingredients/{id}/adjust [post]
+func AdjustIngredient(c *gin.Context) {
+	id := c.Param("id")
+	ingredientObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ingredient ID"})
+		return
+	}
+
+	var req models.AdjustIngredientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.Change == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Change cannot be zero"})
+		return
+	}
+
+	collection := database.DB.Collection("ingredients")
+	ctx := context.Background()
+
+	var before models.Ingredient
+	err = collection.FindOne(ctx, bson.M{"_id": ingredientObjectID}).Decode(&before)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Ingredient not found"})
+		return
+	}
+
+	// Changing the count in place keeps deductions made by orders meanwhile
+	filter := bson.M{"_id": ingredientObjectID}
+	if req.Change < 0 {
+		filter["on_hand"] = bson.M{"$gte": -req.Change}
+	}
+	update := bson.M{
+		"$inc": bson.M{"on_hand": req.Change},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var ingredient models.Ingredient
+	err = collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&ingredient)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("Only %g %s of %s left", before.OnHand, before.Unit, before.Name)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to adjust ingredient"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionUpdate, "ingredient", ingredient.ID, before, ingredient)
+
+	c.JSON(http.StatusOK, ingredient.ToResponse())
+}
+
+// DeleteIngredient godoc
+// @Summary Delete an ingredient
+// @Description Delete an ingredient that no recipe uses
+// @Tags ingredients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Ingredient ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /ingredients/{id} [delete]
+func DeleteIngredient(c *gin.Context) {
+	id := c.Param("id")
+	ingredientObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ingredient ID"})
+		return
+	}
+
+	collection := database.DB.Collection("ingredients")
+	ctx := context.Background()
+
+	var ingredient models.Ingredient
+	err = collection.FindOne(ctx, bson.M{"_id": ingredientObjectID}).Decode(&ingredient)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Ingredient not found"})
+		return
+	}
+
+	used, err := ingredientInRecipes(ctx, ingredient.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete ingredient"})
+		return
+	}
+	if used {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "The ingredient is used in recipes; remove it from them first"})
+		return
+	}
+
+	_, err = collection.DeleteOne(ctx, bson.M{"_id": ingredient.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete ingredient"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionDelete, "ingredient", ingredient.ID, ingredient, nil)
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// SetProductRecipe godoc
+// @Summary Set a product's recipe
+// @Description Replace the ingredients that go into one portion of a product, with quantities in each ingredient's unit. A product with a recipe is made from its ingredients instead of its own stock; an empty list removes the recipe. Orders already placed keep the recipe they were ordered with.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Product ID"
+// @Param request body models.SetRecipeRequest true "Recipe"
+// @Success 200 {object} models.ProductResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/recipe [put]
+func SetProductRecipe(c *gin.Context) {
+	id := c.Param("id")
+	productObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid product ID"})
+		return
+	}
+
+	var req models.SetRecipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var recipe []models.RecipeItem
+	for _, itemReq := range req.Items {
+		ingredientObjectID, err := primitive.ObjectIDFromHex(itemReq.IngredientID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("Invalid ingredient ID %q", itemReq.IngredientID)})
+			return
+		}
+		if itemReq.Quantity <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Recipe quantities must be greater than zero"})
+			return
+		}
+		for _, item := range recipe {
+			if item.IngredientID == ingredientObjectID {
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("Ingredient %s is listed more than once", itemReq.IngredientID)})
+				return
+			}
+		}
+		recipe = append(recipe, models.RecipeItem{IngredientID: ingredientObjectID, Quantity: itemReq.Quantity})
+	}
+
+	collection := database.DB.Collection("products")
+	ctx := context.Background()
+
+	var product models.Product
+	err = collection.FindOne(ctx, bson.M{"_id": productObjectID}).Decode(&product)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Product not found"})
+		return
+	}
+	before := product
+
+	ingredients, err := inventory.Load(ctx, inventory.IngredientIDs(recipe))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update recipe"})
+		return
+	}
+	for _, item := range recipe {
+		if _, ok := ingredients[item.IngredientID]; !ok {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("Ingredient %s not found", item.IngredientID.Hex())})
+			return
+		}
+	}
+
+	product.Recipe = recipe
+	product.UpdatedAt = time.Now()
+
+	set := bson.M{"updated_at": product.UpdatedAt}
+	update := bson.M{"$set": set}
+	if len(recipe) > 0 {
+		set["recipe"] = recipe
+	} else {
+		update["$unset"] = bson.M{"recipe": ""}
+	}
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": product.ID}, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update recipe"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionUpdate, "product", product.ID, before, product)
+
+	responses, err := productResponses(ctx, []models.Product{product})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to work out recipe cost"})
+		return
+	}
+
+	c.JSON(http.StatusOK, responses[0])
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/models"
+	"vibanda-village-admin-backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// defaultInvitationHours is how long an invitation stays valid when no expiry is given
+const defaultInvitationHours = 72
+
+// GetInvitations godoc
+// @Summary Get all invitations
+// @Description Retrieve a list of registration invitations with pagination
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param search query string false "Search by email"
+// @Success 200 {object} PaginatedResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /invitations [get]
+func GetInvitations(c *gin.Context) {
+	page := parseIntParam(c.Query("page"), 1)
+	limit := parseIntParam(c.Query("limit"), 10)
+	search := c.Query("search")
+
+	collection := database.DB.Collection("invitations")
+	ctx := context.Background()
+
+	// Build filter
+	filter := bson.M{}
+	if search != "" {
+		filter["email"] = bson.M{"$regex": search, "$options": "i"}
+	}
+
+	// Get total count
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to count invitations"})
+		return
+	}
+
+	// Get paginated results
+	opts := options.Find()
+	opts.SetSkip(int64((page - 1) * limit))
+	opts.SetLimit(int64(limit))
+	opts.SetSort(bson.M{"created_at": -1})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch invitations"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var invitations []models.Invitation
+	if err = cursor.All(ctx, &invitations); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to decode invitations"})
+		return
+	}
+
+	// Convert to response format
+	var invitationResponses []models.InvitationResponse
+	for _, invitation := range invitations {
+		invitationResponses = append(invitationResponses, invitation.ToResponse())
+	}
+
+	response := PaginatedResponse{
+		Data:       invitationResponses,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateInvitation godoc
+// @Summary Create an invitation
+// @Description Invite someone to register with the given role. The single-use token is only returned in this response.
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateInvitationRequest true "Invitation data"
+// @Success 201 {object} models.InvitationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /invitations [post]
+func CreateInvitation(c *gin.Context) {
+	var req models.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if email == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Email is required"})
+		return
+	}
+	// Admins cannot be invited, matching the rule that admins cannot create other admins
+	if req.Role != models.RoleManager && req.Role != models.RoleStaff {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Role must be one of: manager, staff"})
+		return
+	}
+	expiresInHours := req.ExpiresInHours
+	if expiresInHours <= 0 {
+		expiresInHours = defaultInvitationHours
+	}
+
+	ctx := context.Background()
+
+	// Check if user already exists
+	var existingUser models.User
+	err := database.DB.Collection("users").FindOne(ctx, bson.M{"email": email}).Decode(&existingUser)
+	if err == nil {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "A user with this email already exists"})
+		return
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create invitation"})
+		return
+	}
+
+	now := time.Now()
+	invitation := models.Invitation{
+		ID:        primitive.NewObjectID(),
+		Email:     email,
+		Role:      req.Role,
+		TokenHash: utils.HashToken(token),
+		InvitedBy: currentUserID(c),
+		ExpiresAt: now.Add(time.Duration(expiresInHours) * time.Hour),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	_, err = database.DB.Collection("invitations").InsertOne(ctx, invitation)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create invitation"})
+		return
+	}
+
+	response := invitation.ToResponse()
+	response.Token = token
+	c.JSON(http.StatusCreated, response)
+}
+
+// RevokeInvitation godoc
+// @Summary Revoke an invitation
+// @Description Revoke an unused invitation so it can no longer be used to register
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invitation ID"
+// @Success 204 {object} nil
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /invitations/{id} [delete]
+func RevokeInvitation(c *gin.Context) {
+	id := c.Param("id")
+	invitationObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid invitation ID"})
+		return
+	}
+
+	collection := database.DB.Collection("invitations")
+	ctx := context.Background()
+
+	var invitation models.Invitation
+	err = collection.FindOne(ctx, bson.M{"_id": invitationObjectID}).Decode(&invitation)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Invitation not found"})
+		return
+	}
+	if invitation.UsedAt != nil {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Invitation has already been used"})
+		return
+	}
+
+	now := time.Now()
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"_id": invitationObjectID, "used_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": now, "updated_at": now}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to revoke invitation"})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"vibanda-village-admin-backend/internal/kitchen"
+	"vibanda-village-admin-backend/internal/middleware"
+	"vibanda-village-admin-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// kitchenHeartbeatInterval keeps idle streams from being closed by proxies
+	kitchenHeartbeatInterval = 15 * time.Second
+
+	// kitchenAuthInterval is how often a connected display's token and account are checked again
+	kitchenAuthInterval = time.Minute
+)
+
+// publishOrderEvent pushes a change to an order to the kitchen feed. Failures are
+// logged rather than returned so that the feed never fails the request itself.
+func publishOrderEvent(eventType models.KitchenEventType, order *models.Order) {
+	if err := kitchen.Publish(eventType, order); err != nil {
+		log.Printf("Failed to publish %s for order %s: %v", eventType, order.ID.Hex(), err)
+	}
+}
+
+// orderUpdateEventType returns the kitchen event for an order update, which is a
+// status change when the status moved
+func orderUpdateEventType(before, after *models.Order) models.KitchenEventType {
+	if before.Status != after.Status {
+		return models.KitchenEventOrderStatusChanged
+	}
+	return models.KitchenEventOrderUpdated
+}
+
+// kitchenStreamToken reads the access token of a display from the Authorization
+// header, or from the access_token query parameter for browsers' EventSource,
+// which cannot send headers
+func kitchenStreamToken(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); header != "" {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	return c.Query("access_token")
+}
+
+// authenticateKitchenStream checks that the token belongs to an active user
+// allowed to view orders, returning when it expires
+func authenticateKitchenStream(ctx context.Context, token string) (time.Time, int, error) {
+	claims, user, err := middleware.Authenticate(ctx, token)
+	switch {
+	case err == nil:
+	case errors.Is(err, middleware.ErrInvalidToken):
+		return time.Time{}, http.StatusUnauthorized, errors.New("Invalid or expired token")
+	case errors.Is(err, middleware.ErrInactiveAccount):
+		return time.Time{}, http.StatusUnauthorized, errors.New("Account is inactive or no longer exists")
+	default:
+		return time.Time{}, http.StatusInternalServerError, errors.New("Failed to validate token")
+	}
+	if !user.Role.HasPermission(models.PermissionOrdersRead) {
+		return time.Time{}, http.StatusForbidden, errors.New("Insufficient permissions")
+	}
+	return claims.ExpiresAt.Time, http.StatusOK, nil
+}
+
+// writeKitchenEvent sends an event to a display as a server-sent event, with the
+// sequence number as its ID so the display can resume after it
+func writeKitchenEvent(c *gin.Context, event *models.KitchenEvent, station string) error {
+	data, err := json.Marshal(event.ForStation(station))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data)
+	return err
+}
+
+// KitchenStream godoc
+// @Summary Kitchen display feed
+// @Description Stream order created, updated, status changed and deleted events as Server-Sent Events. Each event's id is its sequence number; reconnect with the Last-Event-ID header or the after parameter to replay the events missed in between. Browsers' EventSource cannot send headers, so the access token may be given as the access_token parameter instead. The stream closes when the token expires.
+// @Tags kitchen
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param station query string false "Only orders with items for this station, e.g. food or drink"
+// @Param after query int false "Replay events after this sequence number"
+// @Param access_token query string false "Access token, when the Authorization header cannot be set"
+// @Success 200 {object} models.KitchenEventResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /kitchen/stream [get]
+func KitchenStream(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	token := kitchenStreamToken(c)
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Authorization header required"})
+		return
+	}
+	expiresAt, status, err := authenticateKitchenStream(ctx, token)
+	if err != nil {
+		c.JSON(status, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	station := strings.ToLower(strings.TrimSpace(c.Query("station")))
+
+	cursor := c.GetHeader("Last-Event-ID")
+	if cursor == "" {
+		cursor = c.Query("after")
+	}
+	var after int64
+	if cursor != "" {
+		after, err = strconv.ParseInt(cursor, 10, 64)
+		if err != nil || after < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid event cursor"})
+			return
+		}
+	}
+
+	// Subscribe before replaying so that nothing published in between is lost;
+	// events received both ways are sent once
+	sub := kitchen.Subscribe()
+	defer kitchen.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+
+	var lastSeq int64
+	if cursor != "" {
+		for {
+			events, err := kitchen.EventsAfter(ctx, after, station)
+			if err != nil {
+				log.Println("Failed to replay kitchen events:", err)
+				return
+			}
+			for i := range events {
+				if err := writeKitchenEvent(c, &events[i], station); err != nil {
+					return
+				}
+				after, lastSeq = events[i].Seq, events[i].Seq
+			}
+			if len(events) < kitchen.ReplayLimit {
+				break
+			}
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(kitchenHeartbeatInterval)
+	defer heartbeat.Stop()
+	reauth := time.NewTicker(kitchenAuthInterval)
+	defer reauth.Stop()
+	expired := time.NewTimer(time.Until(expiresAt))
+	defer expired.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-expired.C:
+			return
+		case <-reauth.C:
+			// Logging out or deactivating the account ends the stream
+			if _, _, err := authenticateKitchenStream(ctx, token); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case event, ok := <-sub.Events:
+			if !ok {
+				// Fell too far behind; the display reconnects and replays
+				return
+			}
+			if event.Seq <= lastSeq {
+				continue
+			}
+			if station != "" && !containsString(event.Stations, station) {
+				continue
+			}
+			if err := writeKitchenEvent(c, &event, station); err != nil {
+				return
+			}
+			lastSeq = event.Seq
+			c.Writer.Flush()
+		}
+	}
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
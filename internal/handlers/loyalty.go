@@ -0,0 +1,644 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"vibanda-village-admin-backend/internal/config"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/loyalty"
+	"vibanda-village-admin-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	errRewardNotFound      = errors.New("reward not found")
+	errRewardNotRedeemable = errors.New("reward cannot be redeemed")
+)
+
+// validateLoyaltyReward checks the name, cost, discount and tier of a reward
+func validateLoyaltyReward(reward *models.LoyaltyReward, tiers []models.LoyaltyTier) error {
+	switch {
+	case len(reward.Name) < 2 || len(reward.Name) > 100:
+		return errors.New("Name must be between 2 and 100 characters")
+	case len(reward.Description) > 500:
+		return errors.New("Description cannot be longer than 500 characters")
+	case reward.PointsCost < 1:
+		return errors.New("Points cost must be at least 1")
+	case !reward.Discount.IsValid() || reward.Discount.Value == 0:
+		return errors.New("Discount must be a percentage up to 10000 basis points or a positive fixed amount")
+	case reward.MinimumTier != "" && loyalty.TierRank(reward.MinimumTier, tiers) < 0:
+		return fmt.Errorf("Minimum tier must be one of the loyalty tiers")
+	}
+	return nil
+}
+
+// customerTier returns the tier a customer has reached with their spend over
+// the tier window, and the spend itself
+func customerTier(ctx context.Context, customerID primitive.ObjectID, cfg *config.Config) (models.Money, *models.LoyaltyTier, *models.LoyaltyTier, error) {
+	since := time.Now().AddDate(0, 0, -cfg.LoyaltyTierWindowDays)
+	spend, err := loyalty.RollingSpend(ctx, customerID, since)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	current, next := loyalty.TierFor(spend, loyalty.Tiers(cfg))
+	return spend, current, next, nil
+}
+
+// GetLoyaltyProgram godoc
+// @Summary Get the loyalty program
+// @Description How many points are earned per currency unit paid, and the tiers reached by spend over the tier window
+// @Tags loyalty
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.LoyaltyProgramResponse
+// @Router /loyalty/program [get]
+func GetLoyaltyProgram(c *gin.Context) {
+	cfg := config.Load()
+
+	c.JSON(http.StatusOK, models.LoyaltyProgramResponse{
+		EarnRate:       cfg.LoyaltyEarnRate,
+		TierWindowDays: cfg.LoyaltyTierWindowDays,
+		Tiers:          loyalty.Tiers(cfg),
+	})
+}
+
+// GetLoyaltyRewards godoc
+// @Summary Get loyalty rewards
+// @Description Retrieve the rewards catalogue, cheapest first
+// @Tags loyalty
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param active query bool false "Only active rewards"
+// @Success 200 {array} models.LoyaltyRewardResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /loyalty/rewards [get]
+func GetLoyaltyRewards(c *gin.Context) {
+	collection := database.DB.Collection("loyalty_rewards")
+	ctx := context.Background()
+
+	filter := bson.M{}
+	if c.Query("active") == "true" {
+		filter["active"] = true
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "points_cost", Value: 1}, {Key: "name", Value: 1}})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch rewards"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var rewards []models.LoyaltyReward
+	if err = cursor.All(ctx, &rewards); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to decode rewards"})
+		return
+	}
+
+	rewardResponses := []models.LoyaltyRewardResponse{}
+	for _, reward := range rewards {
+		rewardResponses = append(rewardResponses, reward.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, rewardResponses)
+}
+
+// GetLoyaltyReward godoc
+// @Summary Get loyalty reward by ID
+// @Description Retrieve a specific reward by ID
+// @Tags loyalty
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Reward ID"
+// @Success 200 {object} models.LoyaltyRewardResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /loyalty/rewards/{id} [get]
+func GetLoyaltyReward(c *gin.Context) {
+	id := c.Param("id")
+	rewardObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid reward ID"})
+		return
+	}
+
+	var reward models.LoyaltyReward
+	err = database.DB.Collection("loyalty_rewards").FindOne(context.Background(), bson.M{"_id": rewardObjectID}).Decode(&reward)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Reward not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, reward.ToResponse())
+}
+
+// CreateLoyaltyReward godoc
+// @Summary Create a loyalty reward
+// @Description Add a reward to the catalogue, bought with points and given as a discount on an order
+// @Tags loyalty
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateLoyaltyRewardRequest true "Reward data"
+// @Success 201 {object} models.LoyaltyRewardResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /loyalty/rewards [post]
+func CreateLoyaltyReward(c *gin.Context) {
+	var req models.CreateLoyaltyRewardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	now := time.Now()
+	reward := models.LoyaltyReward{
+		ID:          primitive.NewObjectID(),
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		PointsCost:  req.PointsCost,
+		Discount:    req.Discount,
+		MinimumTier: strings.TrimSpace(req.MinimumTier),
+		Active:      active,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := validateLoyaltyReward(&reward, loyalty.Tiers(config.Load())); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	_, err := database.DB.Collection("loyalty_rewards").InsertOne(context.Background(), reward)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "A reward with this name already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create reward"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionCreate, "loyalty_reward", reward.ID, nil, reward)
+
+	c.JSON(http.StatusCreated, reward.ToResponse())
+}
+
+// UpdateLoyaltyReward godoc
+// @Summary Update a loyalty reward
+// @Description Change a reward's cost, discount, tier or status. Orders it was already redeemed on keep the terms they were given.
+// @Tags loyalty
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Reward ID"
+// @Param request body models.UpdateLoyaltyRewardRequest true "Reward update data"
+// @Success 200 {object} models.LoyaltyRewardResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /loyalty/rewards/{id} [put]
+func UpdateLoyaltyReward(c *gin.Context) {
+	id := c.Param("id")
+	rewardObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid reward ID"})
+		return
+	}
+
+	var req models.UpdateLoyaltyRewardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	collection := database.DB.Collection("loyalty_rewards")
+	ctx := context.Background()
+
+	var reward models.LoyaltyReward
+	err = collection.FindOne(ctx, bson.M{"_id": rewardObjectID}).Decode(&reward)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Reward not found"})
+		return
+	}
+	before := reward
+
+	if name := strings.TrimSpace(req.Name); name != "" {
+		reward.Name = name
+	}
+	if req.Description != nil {
+		reward.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.PointsCost != nil {
+		reward.PointsCost = *req.PointsCost
+	}
+	if req.Discount != nil {
+		reward.Discount = *req.Discount
+	}
+	if req.MinimumTier != nil {
+		reward.MinimumTier = strings.TrimSpace(*req.MinimumTier)
+	}
+	if req.Active != nil {
+		reward.Active = *req.Active
+	}
+	if err := validateLoyaltyReward(&reward, loyalty.Tiers(config.Load())); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	reward.UpdatedAt = time.Now()
+
+	update := bson.M{"$set": bson.M{
+		"name":         reward.Name,
+		"description":  reward.Description,
+		"points_cost":  reward.PointsCost,
+		"discount":     reward.Discount,
+		"minimum_tier": reward.MinimumTier,
+		"active":       reward.Active,
+		"updated_at":   reward.UpdatedAt,
+	}}
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": reward.ID}, update)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "A reward with this name already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update reward"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionUpdate, "loyalty_reward", reward.ID, before, reward)
+
+	c.JSON(http.StatusOK, reward.ToResponse())
+}
+
+// DeleteLoyaltyReward godoc
+// @Summary Delete a loyalty reward
+// @Description Remove a reward from the catalogue. Orders it was redeemed on keep it.
+// @Tags loyalty
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Reward ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /loyalty/rewards/{id} [delete]
+func DeleteLoyaltyReward(c *gin.Context) {
+	id := c.Param("id")
+	rewardObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid reward ID"})
+		return
+	}
+
+	collection := database.DB.Collection("loyalty_rewards")
+	ctx := context.Background()
+
+	var reward models.LoyaltyReward
+	err = collection.FindOne(ctx, bson.M{"_id": rewardObjectID}).Decode(&reward)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Reward not found"})
+		return
+	}
+
+	_, err = collection.DeleteOne(ctx, bson.M{"_id": reward.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete reward"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionDelete, "loyalty_reward", reward.ID, reward, nil)
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetCustomerLoyalty godoc
+// @Summary Get a customer's loyalty balance
+// @Description Retrieve a customer's points balance, their tier from what they spent over the tier window, and how far the next tier is
+// @Tags loyalty
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Success 200 {object} models.LoyaltyAccountResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /customers/{id}/loyalty [get]
+func GetCustomerLoyalty(c *gin.Context) {
+	id := c.Param("id")
+	customerObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid customer ID"})
+		return
+	}
+
+	ctx := context.Background()
+
+	var customer models.Customer
+	err = database.DB.Collection("customers").FindOne(ctx, bson.M{"_id": customerObjectID}).Decode(&customer)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Customer not found"})
+		return
+	}
+
+	spend, tier, next, err := customerTier(ctx, customer.ID, config.Load())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to work out loyalty tier"})
+		return
+	}
+
+	response := models.LoyaltyAccountResponse{
+		CustomerID:   customer.ID.Hex(),
+		Points:       customer.Points,
+		RollingSpend: spend,
+		NextTier:     next,
+	}
+	if tier != nil {
+		response.Tier = tier.Name
+	}
+	if next != nil {
+		response.SpendToNext = next.MinimumSpend - spend
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetCustomerLoyaltyHistory godoc
+// @Summary Get a customer's loyalty history
+// @Description Retrieve the points a customer earned, spent and had taken back, newest first
+// @Tags loyalty
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} PaginatedResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /customers/{id}/loyalty/history [get]
+func GetCustomerLoyaltyHistory(c *gin.Context) {
+	id := c.Param("id")
+	customerObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid customer ID"})
+		return
+	}
+	page := parseIntParam(c.Query("page"), 1)
+	limit := parseIntParam(c.Query("limit"), 10)
+
+	collection := database.DB.Collection("loyalty_entries")
+	ctx := context.Background()
+
+	filter := bson.M{"customer_id": customerObjectID}
+
+	// Get total count
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to count loyalty history"})
+		return
+	}
+
+	// Get paginated results
+	opts := options.Find()
+	opts.SetSkip(int64((page - 1) * limit))
+	opts.SetLimit(int64(limit))
+	opts.SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch loyalty history"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var entries []models.LoyaltyEntry
+	if err = cursor.All(ctx, &entries); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to decode loyalty history"})
+		return
+	}
+
+	entryResponses := []models.LoyaltyEntryResponse{}
+	for _, entry := range entries {
+		entryResponses = append(entryResponses, entry.ToResponse())
+	}
+
+	response := PaginatedResponse{
+		Data:       entryResponses,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// changeOrderReward loads the order from the path and, inside a transaction,
+// changes its reward with change, reprices it and brings the customer's points
+// in line. Orders that are closed or fully paid cannot change their reward.
+func changeOrderReward(c *gin.Context, change func(ctx mongo.SessionContext, order *models.Order) error) {
+	id := c.Param("id")
+	orderObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID"})
+		return
+	}
+
+	collection := database.DB.Collection("orders")
+	ctx := context.Background()
+
+	session, err := database.Client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update order reward"})
+		return
+	}
+	defer session.EndSession(ctx)
+
+	var before, order models.Order
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		order = models.Order{}
+		err := collection.FindOne(sessCtx, bson.M{"_id": orderObjectID}).Decode(&order)
+		if err == mongo.ErrNoDocuments {
+			return nil, errOrderNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		before = order
+		if order.Status.IsFinal() {
+			return nil, fmt.Errorf("%w: the order is %s", errRewardNotRedeemable, order.Status)
+		}
+		if order.PaymentStatus == models.PaymentStatusPaid || order.PaymentStatus == models.PaymentStatusRefunded {
+			return nil, fmt.Errorf("%w: the order is already %s", errRewardNotRedeemable, order.PaymentStatus)
+		}
+
+		if err := change(sessCtx, &order); err != nil {
+			return nil, err
+		}
+		priceOrder(&order)
+		order.RefreshPaymentStatus()
+		order.UpdatedAt = time.Now()
+
+		filter := bson.M{"_id": order.ID, "updated_at": before.UpdatedAt}
+		update := bson.M{"$set": bson.M{
+			"loyalty_reward": order.LoyaltyReward,
+			"items":          order.Items,
+			"currency":       order.Currency,
+			"pricing":        order.Pricing,
+			"total_amount":   order.TotalAmount,
+			"payment_status": order.PaymentStatus,
+			"updated_at":     order.UpdatedAt,
+		}}
+		result, err := collection.UpdateOne(sessCtx, filter, update)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 0 {
+			return nil, errOrderModified
+		}
+		return nil, loyalty.Sync(sessCtx, &order)
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, errOrderNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found"})
+		return
+	case errors.Is(err, errRewardNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Reward not found"})
+		return
+	case errors.Is(err, errRewardNotRedeemable):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		return
+	case errors.Is(err, errOrderModified):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Order was modified by another request, please reload and try again"})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update order reward"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionUpdate, "order", order.ID, before, order)
+	publishOrderEvent(models.KitchenEventOrderUpdated, &order)
+
+	c.JSON(http.StatusOK, order.ToResponse())
+}
+
+// RedeemLoyaltyReward godoc
+// @Summary Redeem a loyalty reward on an order
+// @Description Spend the customer's points on a reward and take its discount off the order. The order must be linked to a customer with enough points and the reward's tier, and not be closed or paid.
+// @Tags loyalty
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Param request body models.RedeemLoyaltyRewardRequest true "Reward to redeem"
+// @Success 200 {object} models.OrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders/{id}/loyalty-reward [post]
+func RedeemLoyaltyReward(c *gin.Context) {
+	var req models.RedeemLoyaltyRewardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	rewardObjectID, err := primitive.ObjectIDFromHex(req.RewardID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid reward ID"})
+		return
+	}
+
+	cfg := config.Load()
+
+	changeOrderReward(c, func(ctx mongo.SessionContext, order *models.Order) error {
+		if order.CustomerID.IsZero() {
+			return fmt.Errorf("%w: the order has no customer; add their phone or email first", errRewardNotRedeemable)
+		}
+		if order.LoyaltyReward != nil {
+			return fmt.Errorf("%w: %s is already redeemed on the order", errRewardNotRedeemable, order.LoyaltyReward.Name)
+		}
+
+		var reward models.LoyaltyReward
+		err := database.DB.Collection("loyalty_rewards").FindOne(ctx, bson.M{"_id": rewardObjectID}).Decode(&reward)
+		if err == mongo.ErrNoDocuments {
+			return errRewardNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !reward.Active {
+			return fmt.Errorf("%w: %s is not active", errRewardNotRedeemable, reward.Name)
+		}
+
+		// Reading the balance here and changing it in loyalty.Sync makes concurrent
+		// redemptions for the same customer conflict, so points cannot be spent twice
+		var customer models.Customer
+		if err := database.DB.Collection("customers").FindOne(ctx, bson.M{"_id": order.CustomerID}).Decode(&customer); err != nil {
+			return err
+		}
+		if customer.Points < reward.PointsCost {
+			return fmt.Errorf("%w: %s costs %d points and the customer has %d", errRewardNotRedeemable, reward.Name, reward.PointsCost, customer.Points)
+		}
+		if reward.MinimumTier != "" {
+			tiers := loyalty.Tiers(cfg)
+			_, tier, _, err := customerTier(ctx, customer.ID, cfg)
+			if err != nil {
+				return err
+			}
+			if tier == nil || loyalty.TierRank(tier.Name, tiers) < loyalty.TierRank(reward.MinimumTier, tiers) {
+				return fmt.Errorf("%w: %s is for %s customers and above", errRewardNotRedeemable, reward.Name, reward.MinimumTier)
+			}
+		}
+
+		order.LoyaltyReward = reward.Applied()
+		return nil
+	})
+}
+
+// RemoveLoyaltyReward godoc
+// @Summary Remove the loyalty reward from an order
+// @Description Take the reward off an order that is not closed or paid, giving the customer their points back
+// @Tags loyalty
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Success 200 {object} models.OrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders/{id}/loyalty-reward [delete]
+func RemoveLoyaltyReward(c *gin.Context) {
+	changeOrderReward(c, func(ctx mongo.SessionContext, order *models.Order) error {
+		if order.LoyaltyReward == nil {
+			return fmt.Errorf("%w: the order has no reward", errRewardNotRedeemable)
+		}
+		order.LoyaltyReward = nil
+		return nil
+	})
+}
@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"vibanda-village-admin-backend/internal/config"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/loyalty"
+	"vibanda-village-admin-backend/internal/models"
+	"vibanda-village-admin-backend/internal/payments"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// errPaymentAlreadySettled is returned when a callback arrives for a payment
+// whose outcome has already been recorded
+var errPaymentAlreadySettled = errors.New("payment already settled")
+
+// paymentTimedOut is the failure reason of pending payments the provider never
+// reported on
+const paymentTimedOut = "No response from the payment provider"
+
+// awaitingCallback matches the payments a callback may still settle: pending
+// ones, and ones that timed out but which the customer may yet have approved
+var awaitingCallback = bson.A{
+	bson.M{"status": models.PaymentRecordPending},
+	bson.M{"status": models.PaymentRecordFailed, "failure_reason": paymentTimedOut},
+}
+
+// expirePendingPayments fails the pending mobile money payments matching filter
+// that have waited longer than PAYMENT_TIMEOUT_MINUTES, so that they stop
+// holding back the balance of their order
+func expirePendingPayments(ctx context.Context, filter bson.M) error {
+	timeout := time.Duration(config.Load().PaymentTimeoutMinutes) * time.Minute
+	now := time.Now()
+
+	expired := bson.M{"status": models.PaymentRecordPending, "created_at": bson.M{"$lt": now.Add(-timeout)}}
+	for key, value := range filter {
+		expired[key] = value
+	}
+	update := bson.M{"$set": bson.M{
+		"status":         models.PaymentRecordFailed,
+		"failure_reason": paymentTimedOut,
+		"completed_at":   now,
+	}}
+	_, err := database.DB.Collection("payments").UpdateMany(ctx, expired, update)
+	return err
+}
+
+// ExpirePendingPayments fails stale pending payments every interval. It runs
+// for the life of the server.
+func ExpirePendingPayments(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := expirePendingPayments(context.Background(), bson.M{}); err != nil {
+			log.Println("Failed to expire pending payments:", err)
+		}
+	}
+}
+
+// RequestMobilePayment godoc
+// @Summary Request a mobile money payment
+// @Description Send the customer an M-Pesa prompt to pay for an order. The payment stays pending until the provider reports the outcome to the callback endpoint, which updates the payment status of the order.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Param request body models.MobilePaymentRequest true "Phone number and amount"
+// @Success 202 {object} models.MobilePaymentResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /orders/{id}/payments/mobile-money [post]
+func RequestMobilePayment(c *gin.Context) {
+	provider := payments.Provider
+	if provider == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Mobile payments are not configured"})
+		return
+	}
+
+	id := c.Param("id")
+	orderObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID"})
+		return
+	}
+
+	var req models.MobilePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	phone := strings.TrimSpace(req.Phone)
+	if phone == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Phone number is required"})
+		return
+	}
+	if req.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Amount must be greater than zero"})
+		return
+	}
+
+	collection := database.DB.Collection("orders")
+	ctx := context.Background()
+
+	session, err := database.Client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to request payment"})
+		return
+	}
+	defer session.EndSession(ctx)
+
+	// The pending payment is stored before the prompt is sent, so that the
+	// balance it covers cannot be requested twice
+	var order models.Order
+	var payment models.Payment
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		order = models.Order{}
+		err := collection.FindOne(sessCtx, bson.M{"_id": orderObjectID}).Decode(&order)
+		if err == mongo.ErrNoDocuments {
+			return nil, errOrderNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if order.Status == models.OrderStatusCancelled {
+			return nil, fmt.Errorf("%w: order is cancelled", errOrderNotPayable)
+		}
+
+		pending, err := pendingPaymentsTotal(sessCtx, order.ID)
+		if err != nil {
+			return nil, err
+		}
+		if due := order.BalanceDue() - pending; req.Amount > due {
+			return nil, fmt.Errorf("%w of %s", errPaymentExceedsDue, due)
+		}
+
+		payment = models.Payment{
+			ID:        primitive.NewObjectID(),
+			OrderID:   order.ID,
+			Type:      models.PaymentTypePayment,
+			Method:    models.PaymentMethodMobileMoney,
+			Amount:    req.Amount,
+			Currency:  order.Currency,
+			Status:    models.PaymentRecordPending,
+			Provider:  provider.Name(),
+			Phone:     phone,
+			CashierID: currentUserID(c),
+			CreatedAt: time.Now(),
+		}
+		if _, err := database.DB.Collection("payments").InsertOne(sessCtx, payment); err != nil {
+			return nil, err
+		}
+
+		// Touching the order makes concurrent requests for it conflict and retry
+		return collection.UpdateOne(sessCtx, bson.M{"_id": orderObjectID}, bson.M{"$set": bson.M{"updated_at": time.Now()}})
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, errOrderNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found"})
+		return
+	case errors.Is(err, errOrderNotPayable):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		return
+	case errors.Is(err, errPaymentExceedsDue):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to request payment"})
+		return
+	}
+
+	requestCtx, cancel := context.WithTimeout(ctx, 45*time.Second)
+	defer cancel()
+	initiation, err := provider.InitiatePayment(requestCtx, payments.PaymentRequest{
+		Reference:   order.OrderNumber,
+		Phone:       phone,
+		Amount:      req.Amount,
+		Description: "Order " + order.OrderNumber,
+		PaymentID:   payment.ID.Hex(),
+	})
+	if err != nil {
+		payment.Status = models.PaymentRecordFailed
+		payment.FailureReason = err.Error()
+		update := bson.M{"$set": bson.M{"status": payment.Status, "failure_reason": payment.FailureReason}}
+		if _, updateErr := database.DB.Collection("payments").UpdateOne(ctx, bson.M{"_id": payment.ID}, update); updateErr != nil {
+			log.Println("Failed to mark payment as failed:", updateErr)
+		}
+
+		if errors.Is(err, payments.ErrInvalidAmount) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, ErrorResponse{Error: "Payment provider error: " + err.Error()})
+		return
+	}
+
+	// The callback finds the payment by its ID if it arrives before this is saved
+	payment.CheckoutID = initiation.CheckoutID
+	_, err = database.DB.Collection("payments").UpdateOne(ctx, bson.M{"_id": payment.ID}, bson.M{"$set": bson.M{"checkout_id": payment.CheckoutID}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to save payment request"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionCreate, "payment", payment.ID, nil, payment)
+
+	c.JSON(http.StatusAccepted, models.MobilePaymentResponse{
+		Payment:         payment.ToResponse(),
+		CustomerMessage: initiation.CustomerMessage,
+	})
+}
+
+// settleMobilePayment records the outcome of a pending payment reported by the
+// provider and updates the paid amount and payment status of its order
+func settleMobilePayment(ctx context.Context, payment *models.Payment, result *payments.PaymentResult) error {
+	session, err := database.Client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	pending := *payment
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		*payment = pending
+		now := time.Now()
+		payment.CheckoutID = result.CheckoutID
+		payment.CompletedAt = &now
+		payment.FailureReason = ""
+
+		switch {
+		case result.Success && result.Amount == payment.Amount:
+			payment.Status = models.PaymentRecordCompleted
+			payment.Reference = result.Receipt
+		case result.Success:
+			// The amount was set by us when the prompt was sent, so a different amount is not trusted
+			payment.Status = models.PaymentRecordFailed
+			payment.FailureReason = fmt.Sprintf("provider reported %s instead of %s", result.Amount, payment.Amount)
+			log.Printf("Payment %s: %s", payment.ID.Hex(), payment.FailureReason)
+		default:
+			payment.Status = models.PaymentRecordFailed
+			payment.FailureReason = result.Description
+		}
+
+		update := bson.M{"$set": bson.M{
+			"status":         payment.Status,
+			"checkout_id":    payment.CheckoutID,
+			"reference":      payment.Reference,
+			"failure_reason": payment.FailureReason,
+			"completed_at":   payment.CompletedAt,
+		}}
+		paymentResult, err := database.DB.Collection("payments").UpdateOne(sessCtx, bson.M{"_id": payment.ID, "$or": awaitingCallback}, update)
+		if err != nil {
+			return nil, err
+		}
+		if paymentResult.MatchedCount == 0 {
+			return nil, errPaymentAlreadySettled
+		}
+
+		orders := database.DB.Collection("orders")
+		var order models.Order
+		if err := orders.FindOne(sessCtx, bson.M{"_id": payment.OrderID}).Decode(&order); err != nil {
+			return nil, err
+		}
+		if payment.Status == models.PaymentRecordCompleted {
+			order.AmountPaid += payment.Amount
+			order.RefreshPaymentStatus()
+		} else if order.AmountPaid == 0 {
+			order.PaymentStatus = models.PaymentStatusFailed
+		}
+
+		update = bson.M{"$set": bson.M{
+			"amount_paid":    order.AmountPaid,
+			"payment_status": order.PaymentStatus,
+			"updated_at":     now,
+		}}
+		if _, err := orders.UpdateOne(sessCtx, bson.M{"_id": order.ID}, update); err != nil {
+			return nil, err
+		}
+		return nil, loyalty.Sync(sessCtx, &order)
+	})
+	return err
+}
+
+// PaymentCallback godoc
+// @Summary Payment provider callback
+// @Description Receives the outcome of a mobile money payment from the payment provider. Not for use by clients.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} ErrorResponse
+// @Router /payments/callback [post]
+func PaymentCallback(c *gin.Context) {
+	provider := payments.Provider
+	if provider == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Mobile payments are not configured"})
+		return
+	}
+
+	result, err := provider.ParseCallback(c.Request)
+	if err != nil {
+		log.Println("Rejected payment callback:", err)
+		c.JSON(http.StatusBadRequest, provider.CallbackResponse(false))
+		return
+	}
+
+	ctx := context.Background()
+
+	var payment models.Payment
+	filter := bson.M{"provider": provider.Name(), "checkout_id": result.CheckoutID}
+	if paymentID, err := primitive.ObjectIDFromHex(result.PaymentID); err == nil {
+		// The checkout ID is not saved yet if the callback came quickly
+		filter = bson.M{"_id": paymentID, "provider": provider.Name(), "checkout_id": bson.M{"$in": bson.A{result.CheckoutID, nil}}}
+	}
+	err = database.DB.Collection("payments").FindOne(ctx, filter).Decode(&payment)
+	if err != nil {
+		log.Printf("Payment callback for unknown checkout %s", result.CheckoutID)
+		c.JSON(http.StatusNotFound, provider.CallbackResponse(false))
+		return
+	}
+	before := payment
+
+	// Providers may deliver a callback more than once
+	if payment.Status != models.PaymentRecordPending && payment.FailureReason != paymentTimedOut {
+		c.JSON(http.StatusOK, provider.CallbackResponse(true))
+		return
+	}
+
+	err = settleMobilePayment(ctx, &payment, result)
+	if errors.Is(err, errPaymentAlreadySettled) {
+		c.JSON(http.StatusOK, provider.CallbackResponse(true))
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to record payment callback for %s: %v", result.CheckoutID, err)
+		c.JSON(http.StatusInternalServerError, provider.CallbackResponse(false))
+		return
+	}
+
+	recordAuditAs(c, primitive.NilObjectID, models.AuditActionUpdate, "payment", payment.ID, before, payment)
+
+	c.JSON(http.StatusOK, provider.CallbackResponse(true))
+}
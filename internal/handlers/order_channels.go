@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// pickupTimeGrace allows a pickup time a moment in the past, for clients whose
+// clocks run slightly behind
+const pickupTimeGrace = time.Minute
+
+// normalizeDeliveryAddress trims a delivery address and checks that it says
+// where to go
+func normalizeDeliveryAddress(address models.DeliveryAddress) (models.DeliveryAddress, error) {
+	address.Line1 = strings.TrimSpace(address.Line1)
+	address.Line2 = strings.TrimSpace(address.Line2)
+	address.Area = strings.TrimSpace(address.Area)
+	address.City = strings.TrimSpace(address.City)
+	address.Landmark = strings.TrimSpace(address.Landmark)
+	address.Instructions = strings.TrimSpace(address.Instructions)
+
+	switch {
+	case address.Line1 == "":
+		return address, errors.New("Delivery address line1 is required")
+	case address.Area == "":
+		return address, errors.New("Delivery address area is required")
+	case len(address.Line1) > 200, len(address.Line2) > 200, len(address.Landmark) > 200:
+		return address, errors.New("Delivery address lines and landmark must be at most 200 characters")
+	case len(address.Area) > 100, len(address.City) > 100:
+		return address, errors.New("Delivery address area and city must be at most 100 characters")
+	case len(address.Instructions) > 500:
+		return address, errors.New("Delivery instructions must be at most 500 characters")
+	case address.Location != nil && !address.Location.IsValid():
+		return address, errors.New("Delivery address location must be a GeoJSON Point with [longitude, latitude] coordinates")
+	}
+	return address, nil
+}
+
+// setOrderChannel validates the details that go with an order's type and sets
+// them on the order. Details left empty are kept as they are, details of another
+// type are rejected, and the order must end up with the details its type needs.
+// It returns the HTTP status to respond with when the details are not valid.
+func setOrderChannel(ctx context.Context, order *models.Order, tableID string, pickupTime *time.Time, address *models.DeliveryAddress) (int, error) {
+	if !order.OrderType.IsValid() {
+		return http.StatusBadRequest, errors.New("Order type must be one of dine_in, takeaway or delivery")
+	}
+
+	if tableID != "" {
+		if order.OrderType != models.OrderTypeDineIn {
+			return http.StatusBadRequest, errors.New("A table can only be set on dine-in orders")
+		}
+		tableObjectID, err := primitive.ObjectIDFromHex(tableID)
+		if err != nil {
+			return http.StatusBadRequest, errors.New("Invalid table ID")
+		}
+		var table models.Table
+		err = database.DB.Collection("tables").FindOne(ctx, bson.M{"_id": tableObjectID}).Decode(&table)
+		if err == mongo.ErrNoDocuments {
+			return http.StatusBadRequest, errors.New("Table not found")
+		}
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if !table.Active {
+			return http.StatusConflict, fmt.Errorf("Table %d is not in use", table.Number)
+		}
+		order.TableID = table.ID
+		order.TableNumber = table.Number
+	}
+
+	if pickupTime != nil {
+		if order.OrderType != models.OrderTypeTakeaway {
+			return http.StatusBadRequest, errors.New("A pickup time can only be set on takeaway orders")
+		}
+		if pickupTime.Before(time.Now().Add(-pickupTimeGrace)) {
+			return http.StatusBadRequest, errors.New("Pickup time cannot be in the past")
+		}
+		pickup := pickupTime.UTC()
+		order.PickupTime = &pickup
+	}
+
+	if address != nil {
+		if order.OrderType != models.OrderTypeDelivery {
+			return http.StatusBadRequest, errors.New("A delivery address can only be set on delivery orders")
+		}
+		normalized, err := normalizeDeliveryAddress(*address)
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		order.DeliveryAddress = &normalized
+	}
+
+	switch {
+	case order.OrderType == models.OrderTypeDineIn && order.TableID.IsZero():
+		return http.StatusBadRequest, errors.New("Dine-in orders need a table_id")
+	case order.OrderType == models.OrderTypeTakeaway && order.PickupTime == nil:
+		return http.StatusBadRequest, errors.New("Takeaway orders need a pickup_time")
+	case order.OrderType == models.OrderTypeDelivery && order.DeliveryAddress == nil:
+		return http.StatusBadRequest, errors.New("Delivery orders need a delivery_address")
+	}
+	return http.StatusOK, nil
+}
+
+// AssignOrderRider godoc
+// @Summary Assign a delivery rider
+// @Description Assign the staff member taking a delivery order out, or unassign them with an empty rider_id. A rider must be assigned before the order can go out for delivery.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Param request body models.AssignRiderRequest true "Rider"
+// @Success 200 {object} models.OrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders/{id}/rider [put]
+func AssignOrderRider(c *gin.Context) {
+	id := c.Param("id")
+	orderObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID"})
+		return
+	}
+
+	var req models.AssignRiderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	collection := database.DB.Collection("orders")
+	ctx := context.Background()
+
+	var order models.Order
+	err = collection.FindOne(ctx, bson.M{"_id": orderObjectID}).Decode(&order)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found"})
+		return
+	}
+	if order.OrderType != models.OrderTypeDelivery {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Riders can only be assigned to delivery orders"})
+		return
+	}
+	if order.Status.IsFinal() {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("The rider of a %s order cannot be changed", order.Status)})
+		return
+	}
+	before := order
+
+	order.UpdatedAt = time.Now()
+	set := bson.M{"updated_at": order.UpdatedAt}
+	update := bson.M{"$set": set}
+	if req.RiderID == "" {
+		if order.Status == models.OrderStatusOutForDelivery {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "An order out for delivery must keep a rider"})
+			return
+		}
+		order.RiderID = primitive.NilObjectID
+		order.RiderName = ""
+		update["$unset"] = bson.M{"rider_id": "", "rider_name": ""}
+	} else {
+		riderObjectID, err := primitive.ObjectIDFromHex(req.RiderID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid rider ID"})
+			return
+		}
+		var rider models.User
+		err = database.DB.Collection("users").FindOne(ctx, bson.M{"_id": riderObjectID}).Decode(&rider)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Rider not found"})
+			return
+		}
+		if rider.Status != models.StatusActive {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Rider account is inactive"})
+			return
+		}
+		order.RiderID = rider.ID
+		order.RiderName = rider.Name
+		set["rider_id"] = order.RiderID
+		set["rider_name"] = order.RiderName
+	}
+
+	// Matching on the status guards against the order being closed in between
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": orderObjectID, "status": before.Status}, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to assign rider"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Order was modified by another request, please reload and try again"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionUpdate, "order", order.ID, before, order)
+	publishOrderEvent(models.KitchenEventOrderUpdated, &order)
+
+	c.JSON(http.StatusOK, order.ToResponse())
+}
@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/inventory"
+	"vibanda-village-admin-backend/internal/loyalty"
+	"vibanda-village-admin-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	errOrderItemNotFound      = errors.New("order item not found")
+	errOrderItemVoided        = errors.New("order item has been voided")
+	errOrderItemInPreparation = errors.New("order item is already being prepared")
+)
+
+// findOrderItem returns the index of the item with the given ID in the order
+func findOrderItem(order *models.Order, itemID string) (int, error) {
+	itemObjectID, err := primitive.ObjectIDFromHex(itemID)
+	if err != nil {
+		return -1, fmt.Errorf("%w: invalid item ID %q", errInvalidOrderItem, itemID)
+	}
+	for i, item := range order.Items {
+		if item.ID == itemObjectID {
+			if item.Voided {
+				return -1, errOrderItemVoided
+			}
+			return i, nil
+		}
+	}
+	return -1, errOrderItemNotFound
+}
+
+// takeProductStock decrements a product's stock, failing if not enough is left
+func takeProductStock(ctx context.Context, productID primitive.ObjectID, quantity int) error {
+	filter := bson.M{
+		"_id":       productID,
+		"available": true,
+		"stock":     bson.M{"$gte": quantity},
+	}
+	update := bson.M{
+		"$inc": bson.M{"stock": -quantity},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := database.DB.Collection("products").UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return unavailableProductError(ctx, productID)
+	}
+	return nil
+}
+
+// editOrderItems loads the order from the path, applies edit to its items inside a
+// transaction, recalculates the total and saves it. Delivered and cancelled orders
+// and orders out for delivery cannot be edited. The save only succeeds if nobody
+// changed the order in between.
+func editOrderItems(c *gin.Context, edit func(ctx mongo.SessionContext, order *models.Order) error) {
+	id := c.Param("id")
+	orderObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID"})
+		return
+	}
+
+	collection := database.DB.Collection("orders")
+	ctx := context.Background()
+
+	var order models.Order
+	err = collection.FindOne(ctx, bson.M{"_id": orderObjectID}).Decode(&order)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found"})
+		return
+	}
+	if order.Status.IsFinal() {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("Items of a %s order cannot be changed", order.Status)})
+		return
+	}
+	if order.Status == models.OrderStatusOutForDelivery {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Items of an order out for delivery cannot be changed"})
+		return
+	}
+
+	before := order
+	before.Items = append([]models.OrderItem(nil), order.Items...)
+	before.Tickets = append([]models.StationTicket(nil), order.Tickets...)
+	previousUpdatedAt := order.UpdatedAt
+
+	session, err := database.Client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update order items"})
+		return
+	}
+	defer session.EndSession(ctx)
+
+	// Stock and ingredient changes and the order update commit together
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		// Start from the loaded order on every attempt, as the transaction may be retried
+		order = before
+		order.Items = append([]models.OrderItem(nil), before.Items...)
+		order.Tickets = append([]models.StationTicket(nil), before.Tickets...)
+		order.StatusHistory = append([]models.OrderStatusChange(nil), before.StatusHistory...)
+		if err := edit(sessCtx, &order); err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		order.AssignTickets(now)
+		statusChange := order.SyncStatusWithTickets(currentUserID(c), now)
+		priceOrder(&order)
+		order.RefreshPaymentStatus()
+		order.UpdatedAt = now
+
+		filter := bson.M{"_id": orderObjectID, "status": before.Status, "updated_at": previousUpdatedAt}
+		update := bson.M{"$set": bson.M{
+			"items":          order.Items,
+			"tickets":        order.Tickets,
+			"status":         order.Status,
+			"currency":       order.Currency,
+			"pricing":        order.Pricing,
+			"total_amount":   order.TotalAmount,
+			"payment_status": order.PaymentStatus,
+			"updated_at":     order.UpdatedAt,
+		}}
+		if statusChange != nil {
+			update["$push"] = bson.M{"status_history": statusChange}
+		}
+		result, err := collection.UpdateOne(sessCtx, filter, update)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 0 {
+			return nil, errOrderModified
+		}
+		// Items added to or voided from a confirmed order change the ingredients it uses
+		if err := inventory.Sync(sessCtx, &order); err != nil {
+			return nil, err
+		}
+		// A new total can settle the order or leave a balance
+		return result, loyalty.Sync(sessCtx, &order)
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, errOrderItemNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order item not found"})
+		return
+	case errors.Is(err, errOrderItemVoided):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Order item has already been voided"})
+		return
+	case errors.Is(err, errOrderItemInPreparation):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Order item is already being prepared; void it or add a new item instead"})
+		return
+	case errors.Is(err, errOrderModified):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Order was modified by another request, please reload and try again"})
+		return
+	default:
+		status := orderItemErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			c.JSON(status, ErrorResponse{Error: "Failed to update order items"})
+			return
+		}
+		c.JSON(status, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	recordAudit(c, models.AuditActionUpdate, "order", order.ID, before, order)
+	publishOrderEvent(orderUpdateEventType(&before, &order), &order)
+
+	c.JSON(http.StatusOK, order.ToResponse())
+}
+
+// AddOrderItem godoc
+// @Summary Add an item to an order
+// @Description Add a product to an open order. The price comes from the product and stock is decremented.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Param request body models.OrderItemRequest true "Item to add"
+// @Success 200 {object} models.OrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders/{id}/items [post]
+func AddOrderItem(c *gin.Context) {
+	var req models.OrderItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.Discount != nil && !currentUserRole(c).HasPermission(models.PermissionOrdersDiscount) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "Insufficient permissions to apply discounts"})
+		return
+	}
+
+	editOrderItems(c, func(ctx mongo.SessionContext, order *models.Order) error {
+		items, err := reserveOrderItems(ctx, order.ID, []models.OrderItemRequest{req})
+		if err != nil {
+			return err
+		}
+		order.Items = append(order.Items, items...)
+		return nil
+	})
+}
+
+// UpdateOrderItem godoc
+// @Summary Change the quantity of an order item
+// @Description Change how many of an item are on an open order. Stock is adjusted by the difference. Use the void endpoint to remove an item.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Param itemId path string true "Order item ID"
+// @Param request body models.UpdateOrderItemRequest true "New quantity"
+// @Success 200 {object} models.OrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders/{id}/items/{itemId} [put]
+func UpdateOrderItem(c *gin.Context) {
+	var req models.UpdateOrderItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.Quantity < 1 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Quantity must be at least 1; void the item to remove it"})
+		return
+	}
+
+	editOrderItems(c, func(ctx mongo.SessionContext, order *models.Order) error {
+		i, err := findOrderItem(order, c.Param("itemId"))
+		if err != nil {
+			return err
+		}
+
+		item := &order.Items[i]
+		if ticket := order.Ticket(item.TicketID); ticket != nil && ticket.Status != models.StationTicketQueued {
+			return errOrderItemInPreparation
+		}
+		delta := req.Quantity - item.Quantity
+		if len(item.Recipe) > 0 {
+			if delta > 0 {
+				if err := checkRecipeStock(ctx, item.Name, item.Recipe, delta); err != nil {
+					return err
+				}
+			}
+		} else if !item.ProductID.IsZero() {
+			if delta > 0 {
+				if err := takeProductStock(ctx, item.ProductID, delta); err != nil {
+					return err
+				}
+			} else if delta < 0 {
+				returned := *item
+				returned.Quantity = -delta
+				if err := restoreOrderStock(ctx, []models.OrderItem{returned}); err != nil {
+					return err
+				}
+			}
+		}
+		item.Quantity = req.Quantity
+		return nil
+	})
+}
+
+// VoidOrderItem godoc
+// @Summary Void an order item
+// @Description Void an item on an open order. The item is kept with the reason but no longer charged, and its stock is returned.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Param itemId path string true "Order item ID"
+// @Param request body models.VoidOrderItemRequest true "Void reason"
+// @Success 200 {object} models.OrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders/{id}/items/{itemId}/void [post]
+func VoidOrderItem(c *gin.Context) {
+	var req models.VoidOrderItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "A reason is required to void an item"})
+		return
+	}
+
+	editOrderItems(c, func(ctx mongo.SessionContext, order *models.Order) error {
+		i, err := findOrderItem(order, c.Param("itemId"))
+		if err != nil {
+			return err
+		}
+
+		if err := restoreOrderStock(ctx, []models.OrderItem{order.Items[i]}); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		item := &order.Items[i]
+		item.Voided = true
+		item.VoidReason = reason
+		item.VoidedBy = currentUserID(c)
+		item.VoidedAt = &now
+		return nil
+	})
+}
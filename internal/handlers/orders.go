@@ -2,21 +2,241 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
+	"vibanda-village-admin-backend/internal/config"
 	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/inventory"
+	"vibanda-village-admin-backend/internal/loyalty"
 	"vibanda-village-admin-backend/internal/models"
+	"vibanda-village-admin-backend/internal/pricing"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// generateOrderNumber generates a unique order number
-func generateOrderNumber() string {
-	return fmt.Sprintf("ORD-%d", time.Now().Unix())
+var (
+	errInvalidOrderItem   = errors.New("invalid order item")
+	errProductNotFound    = errors.New("product not found")
+	errProductUnavailable = errors.New("product is not available")
+	errInsufficientStock  = errors.New("insufficient stock")
+	errOrderModified      = errors.New("order was modified concurrently")
+	errOrderHasPayments   = errors.New("order has payments")
+)
+
+// generateOrderNumber returns the next order number for today, e.g. VV-20240115-0042.
+// Numbers restart at 1 each day in REPORT_TIMEZONE and are drawn from an atomic
+// counter, so they are unique even when orders are created concurrently.
+func generateOrderNumber(ctx context.Context) (string, error) {
+	location, err := time.LoadLocation(config.Load().ReportTimeZone)
+	if err != nil {
+		location = time.UTC
+	}
+	day := time.Now().In(location).Format("20060102")
+	seq, err := database.NextSequence(ctx, "order:"+day)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("VV-%s-%04d", day, seq), nil
+}
+
+// reserveOrderItems resolves requested items against the product catalog and
+// decrements stock for each one, and routes them to their prep station. Products
+// made from a recipe keep their stock; their ingredients are checked here and
+// taken when the order is confirmed. It must run inside a transaction so that a
+// failure on a later item rolls back the decrements already made. The items
+// still need to be priced with priceOrder and put on station tickets with
+// AssignTickets.
+func reserveOrderItems(ctx context.Context, orderID primitive.ObjectID, reqItems []models.OrderItemRequest) ([]models.OrderItem, error) {
+	collection := database.DB.Collection("products")
+
+	var items []models.OrderItem
+	for _, itemReq := range reqItems {
+		productObjectID, err := primitive.ObjectIDFromHex(itemReq.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid product ID %q", errInvalidOrderItem, itemReq.ProductID)
+		}
+		if itemReq.Quantity < 1 {
+			return nil, fmt.Errorf("%w: quantity must be at least 1", errInvalidOrderItem)
+		}
+		if itemReq.Discount != nil && !itemReq.Discount.IsValid() {
+			return nil, fmt.Errorf("%w: invalid discount", errInvalidOrderItem)
+		}
+
+		var product models.Product
+		err = collection.FindOne(ctx, bson.M{"_id": productObjectID, "available": true, "recipe.0": bson.M{"$exists": true}}).Decode(&product)
+		switch {
+		case err == nil:
+			if err := checkRecipeStock(ctx, product.Name, product.Recipe, itemReq.Quantity); err != nil {
+				return nil, err
+			}
+		case err == mongo.ErrNoDocuments:
+			filter := bson.M{
+				"_id":       productObjectID,
+				"available": true,
+				"stock":     bson.M{"$gte": itemReq.Quantity},
+			}
+			update := bson.M{
+				"$inc": bson.M{"stock": -itemReq.Quantity},
+				"$set": bson.M{"updated_at": time.Now()},
+			}
+
+			err = collection.FindOneAndUpdate(ctx, filter, update).Decode(&product)
+			if err == mongo.ErrNoDocuments {
+				return nil, unavailableProductError(ctx, productObjectID)
+			}
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, err
+		}
+
+		items = append(items, models.OrderItem{
+			ID:          primitive.NewObjectID(),
+			OrderID:     orderID,
+			ProductID:   product.ID,
+			Name:        product.Name,
+			Category:    product.Category,
+			Subcategory: product.Subcategory,
+			Quantity:    itemReq.Quantity,
+			Price:       product.Price,
+			Discount:    itemReq.Discount,
+			Recipe:      product.Recipe,
+		})
+	}
+
+	if err := routeOrderItems(ctx, items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// priceOrder recalculates the line totals, price breakdown and total of an order.
+// Orders keep the service charge and tax rates they were first priced with. The
+// service charge only applies to dine-in orders.
+func priceOrder(order *models.Order) {
+	cfg := config.Load()
+
+	settings := pricing.SettingsFromConfig(cfg)
+	if order.OrderType != models.OrderTypeDineIn {
+		settings.ServiceChargeRate = 0
+	}
+	if order.Pricing != nil {
+		settings = pricing.SettingsFromBreakdown(order.Pricing)
+	}
+	settings.DeliveryFee = order.DeliveryFee
+	if order.Currency == "" {
+		order.Currency = cfg.Currency
+	}
+
+	// Voided items are not charged
+	var lines []pricing.Line
+	var indexes []int
+	for i, item := range order.Items {
+		if item.Voided {
+			continue
+		}
+		lines = append(lines, pricing.Line{
+			UnitPrice:  item.Price,
+			Quantity:   item.Quantity,
+			Discount:   item.Discount,
+			Promotable: order.Promotion != nil && order.Promotion.Target.Matches(item),
+		})
+		indexes = append(indexes, i)
+	}
+
+	var promotion *pricing.Promotion
+	if order.Promotion != nil {
+		promotion = &pricing.Promotion{Discount: order.Promotion.Discount, MinimumSpend: order.Promotion.MinimumSpend}
+	}
+
+	var reward *models.Discount
+	if order.LoyaltyReward != nil {
+		reward = &order.LoyaltyReward.Discount
+	}
+
+	result := pricing.Calculate(lines, order.Discount, promotion, reward, settings)
+	for n, i := range indexes {
+		order.Items[i].DiscountAmount = result.Lines[n].Discount
+		order.Items[i].Total = result.Lines[n].Total
+	}
+	order.Pricing = &result.Breakdown
+	order.TotalAmount = result.Breakdown.GrandTotal
+}
+
+// hasManualDiscount reports whether an order request sets any discount
+func hasManualDiscount(orderDiscount *models.Discount, items []models.OrderItemRequest) bool {
+	if orderDiscount != nil {
+		return true
+	}
+	for _, item := range items {
+		if item.Discount != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// unavailableProductError explains why a product could not be reserved
+func unavailableProductError(ctx context.Context, productID primitive.ObjectID) error {
+	var product models.Product
+	err := database.DB.Collection("products").FindOne(ctx, bson.M{"_id": productID}).Decode(&product)
+	if err == mongo.ErrNoDocuments {
+		return fmt.Errorf("%w: %s", errProductNotFound, productID.Hex())
+	}
+	if err != nil {
+		return err
+	}
+	if !product.Available {
+		return fmt.Errorf("%w: %s", errProductUnavailable, product.Name)
+	}
+	return fmt.Errorf("%w: only %d %s left", errInsufficientStock, product.Stock, product.Name)
+}
+
+// restoreOrderStock puts the quantities of an order's items back into stock.
+// Items created before orders were linked to the catalog have no product and are
+// skipped, as are voided items whose stock was already returned and items made
+// from a recipe, whose ingredients are returned by inventory.Sync.
+func restoreOrderStock(ctx context.Context, items []models.OrderItem) error {
+	collection := database.DB.Collection("products")
+	for _, item := range items {
+		if item.ProductID.IsZero() || item.Voided || len(item.Recipe) > 0 {
+			continue
+		}
+		update := bson.M{
+			"$inc": bson.M{"stock": item.Quantity},
+			"$set": bson.M{"updated_at": time.Now()},
+		}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": item.ProductID}, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orderItemErrorStatus maps an item reservation or promo code error to an HTTP status code
+func orderItemErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errInvalidOrderItem), errors.Is(err, errProductNotFound):
+		return http.StatusBadRequest
+	case errors.Is(err, errPromotionNotFound), errors.Is(err, errPromotionNotApplicable):
+		return http.StatusBadRequest
+	case errors.Is(err, errDeliveryLocationRequired), errors.Is(err, errOutsideDeliveryZones), errors.Is(err, errDeliveryMinimumNotMet):
+		return http.StatusBadRequest
+	case errors.Is(err, errProductUnavailable), errors.Is(err, errInsufficientStock), errors.Is(err, inventory.ErrInsufficientIngredients):
+		return http.StatusConflict
+	case errors.Is(err, errPromotionLimitReached):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 // GetOrders godoc
@@ -31,7 +251,11 @@ func generateOrderNumber() string {
 // @Param search query string false "Search term"
 // @Param status query string false "Filter by status"
 // @Param payment_status query string false "Filter by payment status"
+// @Param order_type query string false "Filter by order type: dine_in, takeaway or delivery"
+// @Param table_id query string false "Filter by table"
+// @Param rider_id query string false "Filter by delivery rider"
 // @Success 200 {object} PaginatedResponse
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /orders [get]
@@ -59,6 +283,29 @@ func GetOrders(c *gin.Context) {
 	if paymentStatusFilter != "" {
 		filter["payment_status"] = paymentStatusFilter
 	}
+	if orderType := models.OrderType(c.Query("order_type")); orderType != "" {
+		if !orderType.IsValid() {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order type"})
+			return
+		}
+		filter["order_type"] = orderType
+	}
+	if tableID := c.Query("table_id"); tableID != "" {
+		tableObjectID, err := primitive.ObjectIDFromHex(tableID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid table ID"})
+			return
+		}
+		filter["table_id"] = tableObjectID
+	}
+	if riderID := c.Query("rider_id"); riderID != "" {
+		riderObjectID, err := primitive.ObjectIDFromHex(riderID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid rider ID"})
+			return
+		}
+		filter["rider_id"] = riderObjectID
+	}
 
 	// Get total count
 	total, err := collection.CountDocuments(ctx, filter)
@@ -137,9 +384,37 @@ func GetOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, order.ToResponse())
 }
 
+// GetOrderByNumber godoc
+// @Summary Get order by number
+// @Description Retrieve a specific order by its order number, e.g. VV-20240115-0042
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param number path string true "Order number"
+// @Success 200 {object} models.OrderResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders/by-number/{number} [get]
+func GetOrderByNumber(c *gin.Context) {
+	number := strings.ToUpper(strings.TrimSpace(c.Param("number")))
+
+	collection := database.DB.Collection("orders")
+	ctx := context.Background()
+
+	var order models.Order
+	err := collection.FindOne(ctx, bson.M{"order_number": number}).Decode(&order)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order.ToResponse())
+}
+
 // CreateOrder godoc
 // @Summary Create a new order
-// @Description Create a new order
+// @Description Create a new order. Items are priced from the catalog; an optional promo_code is redeemed with the order. Dine-in orders need a table_id, takeaway orders a pickup_time and delivery orders a delivery_address inside a delivery zone, whose fee they are charged.
 // @Tags orders
 // @Accept json
 // @Produce json
@@ -147,6 +422,7 @@ func GetOrder(c *gin.Context) {
 // @Param request body models.CreateOrderRequest true "Order data"
 // @Success 201 {object} models.OrderResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /orders [post]
 func CreateOrder(c *gin.Context) {
@@ -155,52 +431,122 @@ func CreateOrder(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
+	if len(req.Items) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Order must contain at least one item"})
+		return
+	}
+	if req.Discount != nil && !req.Discount.IsValid() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid discount"})
+		return
+	}
+	if hasManualDiscount(req.Discount, req.Items) && !currentUserRole(c).HasPermission(models.PermissionOrdersDiscount) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "Insufficient permissions to apply discounts"})
+		return
+	}
 
 	collection := database.DB.Collection("orders")
 	ctx := context.Background()
 
-	// Convert request items to order items
-	var items []models.OrderItem
-	totalAmount := 0.0
-	for _, itemReq := range req.Items {
-		item := models.OrderItem{
-			ID:       primitive.NewObjectID(),
-			Name:     itemReq.Name,
-			Quantity: itemReq.Quantity,
-			Price:    itemReq.Price,
-		}
-		items = append(items, item)
-		totalAmount += itemReq.Price * float64(itemReq.Quantity)
+	orderNumber, err := generateOrderNumber(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create order"})
+		return
 	}
 
 	now := time.Now()
 	order := models.Order{
 		ID:             primitive.NewObjectID(),
-		OrderNumber:    generateOrderNumber(),
+		OrderNumber:    orderNumber,
 		CustomerName:   req.CustomerName,
 		CustomerPhone:  req.CustomerPhone,
 		CustomerEmail:  req.CustomerEmail,
-		TotalAmount:    totalAmount,
+		OrderType:      req.OrderType,
 		Status:         models.OrderStatusPending,
 		PaymentStatus:  models.PaymentStatusPending,
 		SpecialRequest: req.SpecialRequest,
-		Items:          items,
-		CreatedAt:      now,
-		UpdatedAt:      now,
+		Discount:       req.Discount,
+		StatusHistory: []models.OrderStatusChange{
+			{To: models.OrderStatusPending, ChangedBy: currentUserID(c), ChangedAt: now},
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if status, err := setOrderChannel(ctx, &order, req.TableID, req.PickupTime, req.DeliveryAddress); err != nil {
+		if status == http.StatusInternalServerError {
+			c.JSON(status, ErrorResponse{Error: "Failed to create order"})
+			return
+		}
+		c.JSON(status, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if order.OrderType == models.OrderTypeDelivery {
+		if err := applyDeliveryZone(ctx, &order); err != nil {
+			status := orderItemErrorStatus(err)
+			if status == http.StatusInternalServerError {
+				c.JSON(status, ErrorResponse{Error: "Failed to create order"})
+				return
+			}
+			c.JSON(status, ErrorResponse{Error: err.Error()})
+			return
+		}
 	}
+	order.CustomerID = linkCustomer(ctx, order.CustomerName, order.CustomerPhone, order.CustomerEmail)
 
-	_, err := collection.InsertOne(ctx, order)
+	session, err := database.Client.StartSession()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create order"})
 		return
 	}
+	defer session.EndSession(ctx)
+
+	// Price the items, decrement stock and redeem the promo code in the same transaction as the insert
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		items, err := reserveOrderItems(sessCtx, order.ID, req.Items)
+		if err != nil {
+			return nil, err
+		}
+		order.Items = items
+		order.Tickets = nil
+		order.AssignTickets(now)
+
+		order.Promotion = nil
+		if req.PromoCode != "" {
+			if err := redeemPromotion(sessCtx, &order, req.PromoCode); err != nil {
+				return nil, err
+			}
+		}
+
+		priceOrder(&order)
+		if err := checkPromotionApplies(&order); err != nil {
+			return nil, err
+		}
+		if err := checkDeliveryMinimum(&order); err != nil {
+			return nil, err
+		}
+		// An order discounted to nothing is paid from the start
+		order.RefreshPaymentStatus()
+
+		return collection.InsertOne(sessCtx, order)
+	})
+	if err != nil {
+		status := orderItemErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			c.JSON(status, ErrorResponse{Error: "Failed to create order"})
+			return
+		}
+		c.JSON(status, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	recordAudit(c, models.AuditActionCreate, "order", order.ID, nil, order)
+	publishOrderEvent(models.KitchenEventOrderCreated, &order)
 
 	c.JSON(http.StatusCreated, order.ToResponse())
 }
 
 // UpdateOrder godoc
 // @Summary Update order
-// @Description Update an existing order
+// @Description Update an existing order. Status changes must follow pending -> confirmed -> ready -> delivered, with ready optional and cancellation allowed before delivery. Delivery orders go out_for_delivery, which needs an assigned rider, before they are delivered. The table, pickup time or delivery address can be changed on orders of the matching type.
 // @Tags orders
 // @Accept json
 // @Produce json
@@ -210,6 +556,7 @@ func CreateOrder(c *gin.Context) {
 // @Success 200 {object} models.OrderResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /orders/{id} [put]
 func UpdateOrder(c *gin.Context) {
@@ -226,15 +573,75 @@ func UpdateOrder(c *gin.Context) {
 		return
 	}
 
+	updateOrder(c, orderObjectID, req)
+}
+
+// UpdateOrderStatus godoc
+// @Summary Update order status
+// @Description Move an order through its workflow without editing any other field
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Param request body models.UpdateOrderStatusRequest true "New status"
+// @Success 200 {object} models.OrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders/{id}/status [put]
+func UpdateOrderStatus(c *gin.Context) {
+	id := c.Param("id")
+	orderObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID"})
+		return
+	}
+
+	var req models.UpdateOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.Status == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Status is required"})
+		return
+	}
+
+	updateOrder(c, orderObjectID, models.UpdateOrderRequest{Status: req.Status, StatusReason: req.Reason})
+}
+
+// updateOrder applies an order update, enforcing status transitions, taking the
+// ingredients of the order when it is confirmed and returning stock when it is
+// cancelled
+func updateOrder(c *gin.Context, orderObjectID primitive.ObjectID, req models.UpdateOrderRequest) {
 	collection := database.DB.Collection("orders")
 	ctx := context.Background()
 
 	var order models.Order
-	err = collection.FindOne(ctx, bson.M{"_id": orderObjectID}).Decode(&order)
+	err := collection.FindOne(ctx, bson.M{"_id": orderObjectID}).Decode(&order)
 	if err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found"})
 		return
 	}
+	before := order
+	before.Items = append([]models.OrderItem(nil), order.Items...)
+
+	if req.Discount != nil {
+		if !req.Discount.IsValid() {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid discount"})
+			return
+		}
+		if !currentUserRole(c).HasPermission(models.PermissionOrdersDiscount) {
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "Insufficient permissions to apply discounts"})
+			return
+		}
+		if order.Status.IsFinal() {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("The discount of a %s order cannot be changed", order.Status)})
+			return
+		}
+	}
 
 	// Update fields
 	if req.CustomerName != "" {
@@ -246,11 +653,62 @@ func UpdateOrder(c *gin.Context) {
 	if req.CustomerEmail != "" {
 		order.CustomerEmail = req.CustomerEmail
 	}
-	if req.Status != "" {
-		order.Status = req.Status
+	if req.CustomerPhone != "" || req.CustomerEmail != "" {
+		if customerID := linkCustomer(ctx, order.CustomerName, order.CustomerPhone, order.CustomerEmail); !customerID.IsZero() {
+			order.CustomerID = customerID
+		}
+	}
+	channelChanged := req.TableID != "" || req.PickupTime != nil || req.DeliveryAddress != nil
+	if channelChanged {
+		if order.Status.IsFinal() {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("The %s details of a %s order cannot be changed", order.OrderType, order.Status)})
+			return
+		}
+		if req.DeliveryAddress != nil && order.Status == models.OrderStatusOutForDelivery {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "The address of an order out for delivery cannot be changed"})
+			return
+		}
+		if status, err := setOrderChannel(ctx, &order, req.TableID, req.PickupTime, req.DeliveryAddress); err != nil {
+			if status == http.StatusInternalServerError {
+				c.JSON(status, ErrorResponse{Error: "Failed to update order"})
+				return
+			}
+			c.JSON(status, ErrorResponse{Error: err.Error()})
+			return
+		}
 	}
-	if req.PaymentStatus != "" {
-		order.PaymentStatus = req.PaymentStatus
+	// A new address may be in another delivery zone, with another fee
+	if req.DeliveryAddress != nil {
+		if err := applyDeliveryZone(ctx, &order); err != nil {
+			status := orderItemErrorStatus(err)
+			if status == http.StatusInternalServerError {
+				c.JSON(status, ErrorResponse{Error: "Failed to update order"})
+				return
+			}
+			c.JSON(status, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	previousStatus := order.Status
+	var statusChange *models.OrderStatusChange
+	if req.Status != "" && req.Status != order.Status {
+		if !order.CanTransitionTo(req.Status) {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("Cannot change the status of a %s order from %s to %s", order.OrderType, order.Status, req.Status)})
+			return
+		}
+		if req.Status == models.OrderStatusOutForDelivery && order.RiderID.IsZero() {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "Assign a rider before the order goes out for delivery"})
+			return
+		}
+		statusChange = &models.OrderStatusChange{
+			From:      order.Status,
+			To:        req.Status,
+			ChangedBy: currentUserID(c),
+			ChangedAt: time.Now(),
+			Reason:    req.StatusReason,
+		}
+		order.Status = req.Status
+		order.StatusHistory = append(order.StatusHistory, *statusChange)
 	}
 	if req.SpecialRequest != "" {
 		order.SpecialRequest = req.SpecialRequest
@@ -258,28 +716,115 @@ func UpdateOrder(c *gin.Context) {
 
 	order.UpdatedAt = time.Now()
 
-	update := bson.M{"$set": bson.M{
+	set := bson.M{
 		"customer_name":   order.CustomerName,
 		"customer_phone":  order.CustomerPhone,
 		"customer_email":  order.CustomerEmail,
 		"status":          order.Status,
-		"payment_status":  order.PaymentStatus,
 		"special_request": order.SpecialRequest,
 		"updated_at":      order.UpdatedAt,
-	}}
+	}
+	if !order.CustomerID.IsZero() {
+		set["customer_id"] = order.CustomerID
+	}
+	if channelChanged {
+		switch order.OrderType {
+		case models.OrderTypeDineIn:
+			set["table_id"] = order.TableID
+			set["table_number"] = order.TableNumber
+		case models.OrderTypeTakeaway:
+			set["pickup_time"] = order.PickupTime
+		case models.OrderTypeDelivery:
+			set["delivery_address"] = order.DeliveryAddress
+			set["delivery_fee"] = order.DeliveryFee
+			set["delivery_zone"] = order.DeliveryZone
+		}
+	}
+	// A discount with a zero value removes the order discount
+	if req.Discount != nil {
+		order.Discount = req.Discount
+		if req.Discount.Value == 0 {
+			order.Discount = nil
+		}
+		set["discount"] = order.Discount
+	}
+	repriced := req.Discount != nil || req.DeliveryAddress != nil
+	if repriced {
+		priceOrder(&order)
+		if err := checkDeliveryMinimum(&order); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		order.RefreshPaymentStatus()
+		set["items"] = order.Items
+		set["currency"] = order.Currency
+		set["pricing"] = order.Pricing
+		set["total_amount"] = order.TotalAmount
+		set["payment_status"] = order.PaymentStatus
+	}
+	update := bson.M{"$set": set}
+
+	// Matching on the previous status and update time guards against concurrent
+	// changes: stock, ingredients and loyalty points below are worked out from
+	// the items and amounts as they were read, and repricing rewrites the items
+	filter := bson.M{"_id": orderObjectID, "status": previousStatus, "updated_at": before.UpdatedAt}
+	if statusChange != nil {
+		update["$push"] = bson.M{"status_history": statusChange}
+	}
 
-	_, err = collection.UpdateOne(ctx, bson.M{"_id": orderObjectID}, update)
+	session, err := database.Client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update order"})
+		return
+	}
+	defer session.EndSession(ctx)
+
+	// Confirming takes the ingredients, and cancelling returns the items to stock
+	// and gives back the promo code redemption, together with the status change
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		result, err := collection.UpdateOne(sessCtx, filter, update)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 0 {
+			return nil, errOrderModified
+		}
+		if statusChange != nil && statusChange.To == models.OrderStatusCancelled {
+			if err := restoreOrderStock(sessCtx, order.Items); err != nil {
+				return nil, err
+			}
+			if err := releasePromotion(sessCtx, &order); err != nil {
+				return nil, err
+			}
+		}
+		if err := inventory.Sync(sessCtx, &order); err != nil {
+			return nil, err
+		}
+		// Cancelling, repricing or linking the order to another customer changes its loyalty points
+		return result, loyalty.Sync(sessCtx, &order)
+	})
+	if errors.Is(err, errOrderModified) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Order was modified by another request, please reload and try again"})
+		return
+	}
+	if errors.Is(err, inventory.ErrInsufficientIngredients) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update order"})
 		return
 	}
 
+	recordAudit(c, models.AuditActionUpdate, "order", order.ID, before, order)
+	publishOrderEvent(orderUpdateEventType(&before, &order), &order)
+
 	c.JSON(http.StatusOK, order.ToResponse())
 }
 
 // DeleteOrder godoc
 // @Summary Delete order
-// @Description Delete an order
+// @Description Delete a cancelled order without payments. Other orders must be cancelled first, so that their stock, ingredients, promo code and loyalty points are given back.
 // @Tags orders
 // @Accept json
 // @Produce json
@@ -288,6 +833,7 @@ func UpdateOrder(c *gin.Context) {
 // @Success 204 {object} nil
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /orders/{id} [delete]
 func DeleteOrder(c *gin.Context) {
@@ -307,12 +853,53 @@ func DeleteOrder(c *gin.Context) {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found"})
 		return
 	}
+	// Cancelling gives back what the order took, so only cancelled orders can go
+	if order.Status != models.OrderStatusCancelled {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Only cancelled orders can be deleted"})
+		return
+	}
+
+	session, err := database.Client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete order"})
+		return
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		// Payments and refunds are kept for the books, so their order is kept too
+		count, err := database.DB.Collection("payments").CountDocuments(sessCtx, bson.M{"order_id": orderObjectID})
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, errOrderHasPayments
+		}
 
-	_, err = collection.DeleteOne(ctx, bson.M{"_id": orderObjectID})
+		result, err := collection.DeleteOne(sessCtx, bson.M{"_id": orderObjectID, "status": models.OrderStatusCancelled})
+		if err != nil {
+			return nil, err
+		}
+		if result.DeletedCount == 0 {
+			return nil, errOrderModified
+		}
+		return nil, nil
+	})
+	if errors.Is(err, errOrderHasPayments) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Orders with payments cannot be deleted"})
+		return
+	}
+	if errors.Is(err, errOrderModified) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Order was modified by another request, please reload and try again"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete order"})
 		return
 	}
 
+	recordAudit(c, models.AuditActionDelete, "order", order.ID, order, nil)
+	publishOrderEvent(models.KitchenEventOrderDeleted, &order)
+
 	c.JSON(http.StatusNoContent, nil)
 }
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/loyalty"
+	"vibanda-village-admin-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	errOrderNotFound        = errors.New("order not found")
+	errOrderNotPayable      = errors.New("order cannot be paid")
+	errPaymentNotFound      = errors.New("payment not found")
+	errPaymentExceedsDue    = errors.New("payment exceeds the balance due")
+	errRefundExceedsPayment = errors.New("refund exceeds the amount left on the payment")
+)
+
+// orderPayments lists the payments of an order together with its balance
+func orderPayments(ctx context.Context, order *models.Order) (models.OrderPaymentsResponse, error) {
+	response := models.OrderPaymentsResponse{
+		OrderID:        order.ID.Hex(),
+		Currency:       order.Currency,
+		TotalAmount:    order.TotalAmount,
+		AmountPaid:     order.AmountPaid,
+		AmountRefunded: order.AmountRefunded,
+		BalanceDue:     order.BalanceDue(),
+		PaymentStatus:  order.PaymentStatus,
+		Payments:       []models.PaymentResponse{},
+	}
+
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := database.DB.Collection("payments").Find(ctx, bson.M{"order_id": order.ID}, opts)
+	if err != nil {
+		return response, err
+	}
+	defer cursor.Close(ctx)
+
+	var payments []models.Payment
+	if err := cursor.All(ctx, &payments); err != nil {
+		return response, err
+	}
+	for _, payment := range payments {
+		response.Payments = append(response.Payments, payment.ToResponse())
+	}
+	return response, nil
+}
+
+// pendingPaymentsTotal returns the amount of mobile money payments on an order
+// still awaiting the customer's approval. Payments that have waited too long are
+// failed first.
+func pendingPaymentsTotal(ctx context.Context, orderID primitive.ObjectID) (models.Money, error) {
+	if err := expirePendingPayments(ctx, bson.M{"order_id": orderID}); err != nil {
+		return 0, err
+	}
+
+	filter := bson.M{"order_id": orderID, "type": models.PaymentTypePayment, "status": models.PaymentRecordPending}
+	cursor, err := database.DB.Collection("payments").Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	var pending []models.Payment
+	if err := cursor.All(ctx, &pending); err != nil {
+		return 0, err
+	}
+
+	var total models.Money
+	for _, payment := range pending {
+		total += payment.Amount
+	}
+	return total, nil
+}
+
+// recordOrderPayment loads the order from the path and, inside a transaction,
+// builds a payment or refund with build, stores it and updates the order's paid
+// amounts and payment status. Every payment rewrites the order, so concurrent
+// payments on the same order conflict and are retried one after the other.
+func recordOrderPayment(c *gin.Context, build func(ctx mongo.SessionContext, order *models.Order) (models.Payment, error)) {
+	id := c.Param("id")
+	orderObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID"})
+		return
+	}
+
+	collection := database.DB.Collection("orders")
+	ctx := context.Background()
+
+	session, err := database.Client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to record payment"})
+		return
+	}
+	defer session.EndSession(ctx)
+
+	var order models.Order
+	var payment models.Payment
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		order = models.Order{}
+		err := collection.FindOne(sessCtx, bson.M{"_id": orderObjectID}).Decode(&order)
+		if err == mongo.ErrNoDocuments {
+			return nil, errOrderNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		payment, err = build(sessCtx, &order)
+		if err != nil {
+			return nil, err
+		}
+		payment.ID = primitive.NewObjectID()
+		payment.OrderID = order.ID
+		payment.Currency = order.Currency
+		payment.CashierID = currentUserID(c)
+		payment.CreatedAt = time.Now()
+
+		if _, err := database.DB.Collection("payments").InsertOne(sessCtx, payment); err != nil {
+			return nil, err
+		}
+
+		order.RefreshPaymentStatus()
+		order.UpdatedAt = time.Now()
+		update := bson.M{"$set": bson.M{
+			"amount_paid":     order.AmountPaid,
+			"amount_refunded": order.AmountRefunded,
+			"payment_status":  order.PaymentStatus,
+			"updated_at":      order.UpdatedAt,
+		}}
+		if _, err := collection.UpdateOne(sessCtx, bson.M{"_id": orderObjectID}, update); err != nil {
+			return nil, err
+		}
+		// Paying in full earns loyalty points, refunding takes them back
+		return nil, loyalty.Sync(sessCtx, &order)
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, errOrderNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found"})
+		return
+	case errors.Is(err, errPaymentNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Payment not found"})
+		return
+	case errors.Is(err, errOrderNotPayable):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		return
+	case errors.Is(err, errPaymentExceedsDue), errors.Is(err, errRefundExceedsPayment):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to record payment"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionCreate, "payment", payment.ID, nil, payment)
+
+	response, err := orderPayments(ctx, &order)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch payments"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetOrderPayments godoc
+// @Summary List the payments of an order
+// @Description Payments and refunds recorded for an order, with the amount paid and the balance due
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Success 200 {object} models.OrderPaymentsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders/{id}/payments [get]
+func GetOrderPayments(c *gin.Context) {
+	id := c.Param("id")
+	orderObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID"})
+		return
+	}
+
+	ctx := context.Background()
+
+	var order models.Order
+	err = database.DB.Collection("orders").FindOne(ctx, bson.M{"_id": orderObjectID}).Decode(&order)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found"})
+		return
+	}
+
+	response, err := orderPayments(ctx, &order)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch payments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateOrderPayment godoc
+// @Summary Take a payment for an order
+// @Description Record a cash, card or mobile money payment. Several payments can be taken to split the bill or pay in part, up to the balance due. The payment status is updated from the amounts paid.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Param request body models.CreatePaymentRequest true "Payment data"
+// @Success 201 {object} models.OrderPaymentsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders/{id}/payments [post]
+func CreateOrderPayment(c *gin.Context) {
+	var req models.CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !req.Method.IsValid() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Method must be one of: cash, card, mobile_money"})
+		return
+	}
+	if req.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Amount must be greater than zero"})
+		return
+	}
+
+	recordOrderPayment(c, func(ctx mongo.SessionContext, order *models.Order) (models.Payment, error) {
+		if order.Status == models.OrderStatusCancelled {
+			return models.Payment{}, fmt.Errorf("%w: order is cancelled", errOrderNotPayable)
+		}
+		pending, err := pendingPaymentsTotal(ctx, order.ID)
+		if err != nil {
+			return models.Payment{}, err
+		}
+		if due := order.BalanceDue() - pending; req.Amount > due {
+			return models.Payment{}, fmt.Errorf("%w of %s", errPaymentExceedsDue, due)
+		}
+
+		order.AmountPaid += req.Amount
+		return models.Payment{
+			Type:      models.PaymentTypePayment,
+			Method:    req.Method,
+			Amount:    req.Amount,
+			Reference: strings.TrimSpace(req.Reference),
+			Status:    models.PaymentRecordCompleted,
+		}, nil
+	})
+}
+
+// RefundOrderPayment godoc
+// @Summary Refund a payment
+// @Description Give back all or part of a payment, using the same method. The payment status is updated from the amounts paid and refunded.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Param paymentId path string true "Payment ID"
+// @Param request body models.RefundPaymentRequest true "Refund data"
+// @Success 201 {object} models.OrderPaymentsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders/{id}/payments/{paymentId}/refund [post]
+func RefundOrderPayment(c *gin.Context) {
+	paymentObjectID, err := primitive.ObjectIDFromHex(c.Param("paymentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid payment ID"})
+		return
+	}
+
+	var req models.RefundPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Amount must be greater than zero"})
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "A reason is required for a refund"})
+		return
+	}
+
+	recordOrderPayment(c, func(ctx mongo.SessionContext, order *models.Order) (models.Payment, error) {
+		payments := database.DB.Collection("payments")
+
+		var original models.Payment
+		filter := bson.M{
+			"_id":      paymentObjectID,
+			"order_id": order.ID,
+			"type":     models.PaymentTypePayment,
+			"status":   bson.M{"$nin": []models.PaymentRecordStatus{models.PaymentRecordPending, models.PaymentRecordFailed}},
+		}
+		if err := payments.FindOne(ctx, filter).Decode(&original); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return models.Payment{}, errPaymentNotFound
+			}
+			return models.Payment{}, err
+		}
+
+		cursor, err := payments.Find(ctx, bson.M{"refund_of": original.ID})
+		if err != nil {
+			return models.Payment{}, err
+		}
+		var refunds []models.Payment
+		if err := cursor.All(ctx, &refunds); err != nil {
+			return models.Payment{}, err
+		}
+		left := original.Amount
+		for _, refund := range refunds {
+			left -= refund.Amount
+		}
+		if req.Amount > left {
+			return models.Payment{}, fmt.Errorf("%w (%s)", errRefundExceedsPayment, left)
+		}
+
+		order.AmountRefunded += req.Amount
+		return models.Payment{
+			Type:     models.PaymentTypeRefund,
+			Method:   original.Method,
+			Amount:   req.Amount,
+			Status:   models.PaymentRecordCompleted,
+			RefundOf: original.ID,
+			Reason:   reason,
+		}, nil
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/inventory"
 	"vibanda-village-admin-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
@@ -13,6 +14,30 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// productResponses converts products to responses. Products with a recipe get
+// their stock from the ingredients on hand, and the cost of their ingredients.
+func productResponses(ctx context.Context, products []models.Product) ([]models.ProductResponse, error) {
+	var recipes [][]models.RecipeItem
+	for _, product := range products {
+		recipes = append(recipes, product.Recipe)
+	}
+	ingredients, err := inventory.Load(ctx, inventory.IngredientIDs(recipes...))
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []models.ProductResponse{}
+	for _, product := range products {
+		response := product.ToResponse()
+		if len(product.Recipe) > 0 {
+			response.Stock = inventory.Portions(product.Recipe, ingredients)
+			response.RecipeCost, response.Recipe = inventory.Cost(product.Recipe, ingredients)
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
 // GetProducts godoc
 // @Summary Get all products
 // @Description Retrieve a list of all products with pagination
@@ -81,13 +106,14 @@ func GetProducts(c *gin.Context) {
 	}
 
 	// Convert to response format
-	var productResponses []models.ProductResponse
-	for _, product := range products {
-		productResponses = append(productResponses, product.ToResponse())
+	responses, err := productResponses(ctx, products)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to work out product stock"})
+		return
 	}
 
 	response := PaginatedResponse{
-		Data:       productResponses,
+		Data:       responses,
 		Total:      total,
 		Page:       page,
 		Limit:      limit,
@@ -128,7 +154,13 @@ func GetProduct(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, product.ToResponse())
+	responses, err := productResponses(ctx, []models.Product{product})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to work out product stock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, responses[0])
 }
 
 // CreateProduct godoc
@@ -176,6 +208,8 @@ func CreateProduct(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditActionCreate, "product", product.ID, nil, product)
+
 	c.JSON(http.StatusCreated, product.ToResponse())
 }
 
@@ -216,6 +250,7 @@ func UpdateProduct(c *gin.Context) {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Product not found"})
 		return
 	}
+	before := product
 
 	// Update fields
 	if req.Name != "" {
@@ -271,7 +306,15 @@ func UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, product.ToResponse())
+	recordAudit(c, models.AuditActionUpdate, "product", product.ID, before, product)
+
+	responses, err := productResponses(ctx, []models.Product{product})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to work out product stock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, responses[0])
 }
 
 // DeleteProduct godoc
@@ -311,5 +354,7 @@ func DeleteProduct(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditActionDelete, "product", product.ID, product, nil)
+
 	c.JSON(http.StatusNoContent, nil)
 }
@@ -0,0 +1,527 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"vibanda-village-admin-backend/internal/customers"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	errPromotionNotFound      = errors.New("promo code not found")
+	errPromotionNotApplicable = errors.New("promo code cannot be applied")
+	errPromotionLimitReached  = errors.New("promo code usage limit reached")
+)
+
+// normalizePromoCode makes codes case-insensitive and ignores surrounding spaces
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// promotionTargetFromRequest validates the targeting of a promotion request
+func promotionTargetFromRequest(req models.PromotionTargetRequest) (models.PromotionTarget, error) {
+	target := models.PromotionTarget{Categories: req.Categories, Subcategories: req.Subcategories}
+	for _, productID := range req.ProductIDs {
+		productObjectID, err := primitive.ObjectIDFromHex(productID)
+		if err != nil {
+			return target, fmt.Errorf("invalid product ID %q", productID)
+		}
+		target.ProductIDs = append(target.ProductIDs, productObjectID)
+	}
+	for _, category := range req.Categories {
+		if category != models.CategoryFood && category != models.CategoryDrink {
+			return target, fmt.Errorf("unknown category %q", category)
+		}
+	}
+	return target, nil
+}
+
+// validatePromotion checks the terms of a promotion before it is saved
+func validatePromotion(promotion *models.Promotion) error {
+	discount := models.Discount{Type: promotion.Type, Value: promotion.Value}
+	if !discount.IsValid() || promotion.Value < 1 {
+		return errors.New("type must be percentage or fixed, with a value above zero and at most 10000 basis points for percentages")
+	}
+	if promotion.MinimumSpend < 0 || promotion.UsageLimit < 0 || promotion.PerCustomerLimit < 0 {
+		return errors.New("minimum spend and usage limits cannot be negative")
+	}
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+// redeemPromotion applies a promo code to an order and records the redemption. It
+// must run in the order's transaction. Every redemption increments the
+// promotion's usage count, so concurrent redemptions of the same code conflict
+// and are retried one after the other; the limits below are therefore checked
+// against all redemptions committed before.
+func redeemPromotion(ctx context.Context, order *models.Order, code string) error {
+	collection := database.DB.Collection("promotions")
+
+	var promotion models.Promotion
+	err := collection.FindOne(ctx, bson.M{"code": normalizePromoCode(code)}).Decode(&promotion)
+	if err == mongo.ErrNoDocuments {
+		return fmt.Errorf("%w: %s", errPromotionNotFound, code)
+	}
+	if err != nil {
+		return err
+	}
+	if !promotion.IsRunningAt(time.Now()) {
+		return fmt.Errorf("%w: %s is not currently running", errPromotionNotApplicable, promotion.Code)
+	}
+
+	filter := bson.M{
+		"_id":    promotion.ID,
+		"active": true,
+		"$or": []bson.M{
+			{"usage_limit": 0},
+			{"$expr": bson.M{"$lt": bson.A{"$usage_count", "$usage_limit"}}},
+		},
+	}
+	update := bson.M{
+		"$inc": bson.M{"usage_count": 1},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", errPromotionLimitReached, promotion.Code)
+	}
+
+	// Customers are told apart by their phone number in one format, so that
+	// writing it differently does not get around the per customer limit
+	redemptions := database.DB.Collection("promotion_redemptions")
+	customerPhone := customers.NormalizePhone(order.CustomerPhone)
+	if promotion.PerCustomerLimit > 0 {
+		if customerPhone == "" {
+			return fmt.Errorf("%w: %s needs the customer's phone number", errPromotionNotApplicable, promotion.Code)
+		}
+		// Redemptions recorded before phone numbers were normalized hold them as entered
+		phones := bson.A{customerPhone, strings.TrimSpace(order.CustomerPhone)}
+		used, err := redemptions.CountDocuments(ctx, bson.M{"promotion_id": promotion.ID, "customer_phone": bson.M{"$in": phones}})
+		if err != nil {
+			return err
+		}
+		if used >= int64(promotion.PerCustomerLimit) {
+			return fmt.Errorf("%w: %s has already been used by this customer", errPromotionLimitReached, promotion.Code)
+		}
+	}
+
+	_, err = redemptions.InsertOne(ctx, models.PromotionRedemption{
+		ID:            primitive.NewObjectID(),
+		PromotionID:   promotion.ID,
+		Code:          promotion.Code,
+		OrderID:       order.ID,
+		CustomerPhone: customerPhone,
+		CreatedAt:     time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	order.Promotion = promotion.Applied()
+	return nil
+}
+
+// releasePromotion gives back the redemption of a cancelled order, so that it no
+// longer counts towards the promotion's limits
+func releasePromotion(ctx context.Context, order *models.Order) error {
+	if order.Promotion == nil {
+		return nil
+	}
+
+	result, err := database.DB.Collection("promotion_redemptions").DeleteOne(ctx, bson.M{"order_id": order.ID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return nil
+	}
+
+	update := bson.M{
+		"$inc": bson.M{"usage_count": -1},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	_, err = database.DB.Collection("promotions").UpdateOne(ctx, bson.M{"_id": order.Promotion.PromotionID}, update)
+	return err
+}
+
+// checkPromotionApplies fails when a priced order gets nothing off from its promotion
+func checkPromotionApplies(order *models.Order) error {
+	if order.Promotion == nil || order.Pricing.PromotionDiscount > 0 {
+		return nil
+	}
+	net := order.Pricing.Subtotal - order.Pricing.LineDiscounts
+	if net < order.Promotion.MinimumSpend {
+		return fmt.Errorf("%w: %s requires a minimum spend of %s", errPromotionNotApplicable, order.Promotion.Code, order.Promotion.MinimumSpend)
+	}
+	return fmt.Errorf("%w: no items on the order qualify for %s", errPromotionNotApplicable, order.Promotion.Code)
+}
+
+// GetPromotions godoc
+// @Summary Get all promotions
+// @Description Retrieve a list of promotions with pagination
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param search query string false "Search by code or name"
+// @Param status query string false "Filter by status (active/inactive)"
+// @Success 200 {object} PaginatedResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /promotions [get]
+func GetPromotions(c *gin.Context) {
+	page := parseIntParam(c.Query("page"), 1)
+	limit := parseIntParam(c.Query("limit"), 10)
+	search := c.Query("search")
+	statusFilter := c.Query("status")
+
+	collection := database.DB.Collection("promotions")
+	ctx := context.Background()
+
+	// Build filter
+	filter := bson.M{}
+	if search != "" {
+		filter["$or"] = []bson.M{
+			{"code": bson.M{"$regex": search, "$options": "i"}},
+			{"name": bson.M{"$regex": search, "$options": "i"}},
+		}
+	}
+	if statusFilter != "" {
+		filter["active"] = statusFilter == "active"
+	}
+
+	// Get total count
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to count promotions"})
+		return
+	}
+
+	// Get paginated results
+	opts := options.Find()
+	opts.SetSkip(int64((page - 1) * limit))
+	opts.SetLimit(int64(limit))
+	opts.SetSort(bson.M{"created_at": -1})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch promotions"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var promotions []models.Promotion
+	if err = cursor.All(ctx, &promotions); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to decode promotions"})
+		return
+	}
+
+	// Convert to response format
+	promotionResponses := []models.PromotionResponse{}
+	for _, promotion := range promotions {
+		promotionResponses = append(promotionResponses, promotion.ToResponse())
+	}
+
+	response := PaginatedResponse{
+		Data:       promotionResponses,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetPromotion godoc
+// @Summary Get promotion by ID
+// @Description Retrieve a specific promotion by ID
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Promotion ID"
+// @Success 200 {object} models.PromotionResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /promotions/{id} [get]
+func GetPromotion(c *gin.Context) {
+	id := c.Param("id")
+	promotionObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid promotion ID"})
+		return
+	}
+
+	collection := database.DB.Collection("promotions")
+	ctx := context.Background()
+
+	var promotion models.Promotion
+	err = collection.FindOne(ctx, bson.M{"_id": promotionObjectID}).Decode(&promotion)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Promotion not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion.ToResponse())
+}
+
+// CreatePromotion godoc
+// @Summary Create a new promotion
+// @Description Create a promo code. Percentage values are in basis points (10% = 1000), fixed values and minimum spend in minor units. A usage limit of 0 means unlimited.
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreatePromotionRequest true "Promotion data"
+// @Success 201 {object} models.PromotionResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /promotions [post]
+func CreatePromotion(c *gin.Context) {
+	var req models.CreatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	code := normalizePromoCode(req.Code)
+	if len(code) < 3 || len(code) > 32 || strings.ContainsAny(code, " \t") {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Code must be 3 to 32 characters without spaces"})
+		return
+	}
+	target, err := promotionTargetFromRequest(req.Target)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	now := time.Now()
+	promotion := models.Promotion{
+		ID:               primitive.NewObjectID(),
+		Code:             code,
+		Name:             req.Name,
+		Description:      req.Description,
+		Type:             req.Type,
+		Value:            req.Value,
+		MinimumSpend:     req.MinimumSpend,
+		Target:           target,
+		StartsAt:         req.StartsAt,
+		EndsAt:           req.EndsAt,
+		UsageLimit:       req.UsageLimit,
+		PerCustomerLimit: req.PerCustomerLimit,
+		Active:           active,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if strings.TrimSpace(promotion.Name) == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Name is required"})
+		return
+	}
+	if err := validatePromotion(&promotion); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	collection := database.DB.Collection("promotions")
+	ctx := context.Background()
+
+	_, err = collection.InsertOne(ctx, promotion)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "A promotion with this code already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create promotion"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionCreate, "promotion", promotion.ID, nil, promotion)
+
+	c.JSON(http.StatusCreated, promotion.ToResponse())
+}
+
+// UpdatePromotion godoc
+// @Summary Update promotion
+// @Description Update the terms of a promotion. The code cannot be changed, and orders already placed keep the terms they were redeemed with.
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Promotion ID"
+// @Param request body models.UpdatePromotionRequest true "Promotion update data"
+// @Success 200 {object} models.PromotionResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /promotions/{id} [put]
+func UpdatePromotion(c *gin.Context) {
+	id := c.Param("id")
+	promotionObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid promotion ID"})
+		return
+	}
+
+	var req models.UpdatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	collection := database.DB.Collection("promotions")
+	ctx := context.Background()
+
+	var promotion models.Promotion
+	err = collection.FindOne(ctx, bson.M{"_id": promotionObjectID}).Decode(&promotion)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Promotion not found"})
+		return
+	}
+	before := promotion
+
+	// Update fields
+	if req.Name != "" {
+		promotion.Name = req.Name
+	}
+	if req.Description != "" {
+		promotion.Description = req.Description
+	}
+	if req.Type != "" {
+		promotion.Type = req.Type
+	}
+	if req.Value != 0 {
+		promotion.Value = req.Value
+	}
+	if req.MinimumSpend != nil {
+		promotion.MinimumSpend = *req.MinimumSpend
+	}
+	if req.Target != nil {
+		target, err := promotionTargetFromRequest(*req.Target)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		promotion.Target = target
+	}
+	if req.StartsAt != nil {
+		promotion.StartsAt = req.StartsAt
+	}
+	if req.EndsAt != nil {
+		promotion.EndsAt = req.EndsAt
+	}
+	if req.UsageLimit != nil {
+		promotion.UsageLimit = *req.UsageLimit
+	}
+	if req.PerCustomerLimit != nil {
+		promotion.PerCustomerLimit = *req.PerCustomerLimit
+	}
+	if req.Active != nil {
+		promotion.Active = *req.Active
+	}
+	if err := validatePromotion(&promotion); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	promotion.UpdatedAt = time.Now()
+
+	// usage_count is left out so that redemptions made meanwhile are not lost
+	update := bson.M{"$set": bson.M{
+		"name":               promotion.Name,
+		"description":        promotion.Description,
+		"type":               promotion.Type,
+		"value":              promotion.Value,
+		"minimum_spend":      promotion.MinimumSpend,
+		"target":             promotion.Target,
+		"starts_at":          promotion.StartsAt,
+		"ends_at":            promotion.EndsAt,
+		"usage_limit":        promotion.UsageLimit,
+		"per_customer_limit": promotion.PerCustomerLimit,
+		"active":             promotion.Active,
+		"updated_at":         promotion.UpdatedAt,
+	}}
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": promotionObjectID}, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update promotion"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionUpdate, "promotion", promotion.ID, before, promotion)
+
+	c.JSON(http.StatusOK, promotion.ToResponse())
+}
+
+// DeletePromotion godoc
+// @Summary Delete promotion
+// @Description Delete a promotion that has never been redeemed. Redeemed promotions must be deactivated instead.
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Promotion ID"
+// @Success 204 {object} nil
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /promotions/{id} [delete]
+func DeletePromotion(c *gin.Context) {
+	id := c.Param("id")
+	promotionObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid promotion ID"})
+		return
+	}
+
+	collection := database.DB.Collection("promotions")
+	ctx := context.Background()
+
+	var promotion models.Promotion
+	err = collection.FindOne(ctx, bson.M{"_id": promotionObjectID}).Decode(&promotion)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Promotion not found"})
+		return
+	}
+
+	// Redemptions refer to the promotion, so only unused ones can be removed
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": promotionObjectID, "usage_count": 0})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete promotion"})
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Promotion has been redeemed; deactivate it instead"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionDelete, "promotion", promotion.ID, promotion, nil)
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+	"vibanda-village-admin-backend/internal/config"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// bookableSlots returns the seating start times on a date, from opening time up to the
+// last start that still ends by closing time
+func bookableSlots(date string) ([]time.Time, error) {
+	cfg := config.Load()
+
+	opening, err := parseReservationSlot(date, cfg.OpeningTime)
+	if err != nil {
+		return nil, err
+	}
+	closing, err := parseReservationSlot(date, cfg.ClosingTime)
+	if err != nil {
+		return nil, err
+	}
+
+	step := time.Duration(cfg.ReservationSlotMinutes) * time.Minute
+	if step <= 0 {
+		step = 30 * time.Minute
+	}
+	lastStart := closing.Add(-reservationSeatingDuration())
+
+	var slots []time.Time
+	for slot := opening; !slot.After(lastStart); slot = slot.Add(step) {
+		slots = append(slots, slot)
+	}
+	return slots, nil
+}
+
+// isBookableSlot reports whether start is one of the bookable slots on its date and not in the past
+func isBookableSlot(date string, start time.Time) bool {
+	if start.Before(time.Now()) {
+		return false
+	}
+	slots, err := bookableSlots(date)
+	if err != nil {
+		return false
+	}
+	for _, slot := range slots {
+		if slot.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAvailability godoc
+// @Summary Get reservation availability
+// @Description List bookable time slots for a party size on a date, based on opening hours, existing reservations and table capacity
+// @Tags public
+// @Accept json
+// @Produce json
+// @Param date query string true "Date (YYYY-MM-DD)"
+// @Param guests query int true "Party size"
+// @Success 200 {object} models.AvailabilityResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /public/availability [get]
+func GetAvailability(c *gin.Context) {
+	date := c.Query("date")
+	guests := parseIntParam(c.Query("guests"), 0)
+
+	day, err := time.ParseInLocation(reservationDateLayout, date, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Date must be YYYY-MM-DD"})
+		return
+	}
+	if guests < 1 || guests > 20 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Guests must be between 1 and 20"})
+		return
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if day.Before(today) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Date must not be in the past"})
+		return
+	}
+
+	ctx := context.Background()
+
+	slots, err := bookableSlots(date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Opening hours are misconfigured"})
+		return
+	}
+
+	reservations, err := activeReservationsOn(ctx, date, primitive.NilObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch reservations"})
+		return
+	}
+
+	tables, err := activeTables(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch tables"})
+		return
+	}
+
+	duration := reservationSeatingDuration()
+	response := models.AvailabilityResponse{
+		Date:   date,
+		Guests: guests,
+		Slots:  []models.AvailabilitySlot{},
+	}
+	for _, slot := range slots {
+		if slot.Before(now) {
+			continue
+		}
+		free := freeTables(tables, occupiedTableIDs(reservations, slot, duration))
+		response.Slots = append(response.Slots, models.AvailabilitySlot{
+			Time:      slot.Format(reservationTimeLayout),
+			Available: len(tables) == 0 || len(chooseTables(free, guests)) > 0,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreatePublicReservation godoc
+// @Summary Request a reservation
+// @Description Submit a reservation from the customer website. The booking is created as pending with a table assigned, and must be confirmed by staff.
+// @Tags public
+// @Accept json
+// @Produce json
+// @Param request body models.PublicReservationRequest true "Reservation data"
+// @Success 201 {object} models.ReservationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /public/reservations [post]
+func CreatePublicReservation(c *gin.Context) {
+	var req models.PublicReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format. Please check your input data and try again."})
+		return
+	}
+	if len(req.CustomerName) < 2 || req.CustomerPhone == "" || req.CustomerEmail == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Name, phone and email are required"})
+		return
+	}
+	if req.Guests < 1 || req.Guests > 20 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Guests must be between 1 and 20"})
+		return
+	}
+
+	start, err := parseReservationSlot(req.Date, req.Time)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Date must be YYYY-MM-DD and time must be HH:MM"})
+		return
+	}
+	if !isBookableSlot(req.Date, start) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "The requested time is not an available booking slot"})
+		return
+	}
+
+	collection := database.DB.Collection("reservations")
+	ctx := context.Background()
+
+	now := time.Now()
+	reservation := models.Reservation{
+		ID:              primitive.NewObjectID(),
+		CustomerName:    req.CustomerName,
+		CustomerEmail:   req.CustomerEmail,
+		CustomerPhone:   req.CustomerPhone,
+		Date:            req.Date,
+		Time:            req.Time,
+		Guests:          req.Guests,
+		Status:          models.ReservationStatusPending,
+		SpecialRequests: req.SpecialRequests,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	reservation.CustomerID = linkCustomer(ctx, reservation.CustomerName, reservation.CustomerPhone, reservation.CustomerEmail)
+
+	err = bookReservation(ctx, &reservation, false, func(sessCtx mongo.SessionContext) error {
+		_, err := collection.InsertOne(sessCtx, reservation)
+		return err
+	})
+	if errors.Is(err, errNoTableAvailable) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Sorry, this time is no longer available. Please choose another time."})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "An error occurred while creating your reservation. Please try again later."})
+		return
+	}
+
+	c.JSON(http.StatusCreated, reservation.ToResponse())
+}
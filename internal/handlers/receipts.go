@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	netmail "net/mail"
+	"strings"
+	"time"
+	"vibanda-village-admin-backend/internal/config"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/mail"
+	"vibanda-village-admin-backend/internal/models"
+	"vibanda-village-admin-backend/internal/receipt"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// loadReceipt builds the receipt of the order in the path, writing an error
+// response and returning nil if it cannot
+func loadReceipt(c *gin.Context) *receipt.Receipt {
+	id := c.Param("id")
+	orderObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID"})
+		return nil
+	}
+
+	cfg := config.Load()
+	ctx := context.Background()
+
+	var order models.Order
+	err = database.DB.Collection("orders").FindOne(ctx, bson.M{"_id": orderObjectID}).Decode(&order)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found"})
+		return nil
+	}
+
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := database.DB.Collection("payments").Find(ctx, bson.M{"order_id": order.ID}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch payments"})
+		return nil
+	}
+	var payments []models.Payment
+	if err := cursor.All(ctx, &payments); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch payments"})
+		return nil
+	}
+
+	location, err := time.LoadLocation(cfg.ReportTimeZone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	return receipt.New(receipt.BusinessFromConfig(cfg), &order, payments, location)
+}
+
+// GetOrderReceipt godoc
+// @Summary Print an order receipt
+// @Description Render the receipt of an order as a PDF, as plain text or as ESC/POS commands for a thermal printer. Text and ESC/POS receipts are laid out for 58mm or 80mm paper.
+// @Tags orders
+// @Produce application/pdf
+// @Produce plain
+// @Produce octet-stream
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Param format query string false "Output format: pdf, text or escpos" default(pdf)
+// @Param width query int false "Paper width in millimetres for text and escpos: 58 or 80" default(80)
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders/{id}/receipt [get]
+func GetOrderReceipt(c *gin.Context) {
+	format := c.DefaultQuery("format", "pdf")
+	if format != "pdf" && format != "text" && format != "escpos" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Format must be one of: pdf, text, escpos"})
+		return
+	}
+
+	var width int
+	switch c.DefaultQuery("width", "80") {
+	case "58":
+		width = receipt.Width58mm
+	case "80":
+		width = receipt.Width80mm
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Width must be 58 or 80"})
+		return
+	}
+
+	r := loadReceipt(c)
+	if r == nil {
+		return
+	}
+
+	filename := "receipt-" + r.Order.OrderNumber
+	switch format {
+	case "pdf":
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename+".pdf"))
+		c.Data(http.StatusOK, "application/pdf", r.PDF())
+	case "text":
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename+".txt"))
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(r.Text(width)))
+	case "escpos":
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".bin"))
+		c.Data(http.StatusOK, "application/octet-stream", r.ESCPOS(width))
+	}
+}
+
+// EmailOrderReceipt godoc
+// @Summary Email an order receipt
+// @Description Email the receipt of an order, with the PDF attached, to the given address or else to the customer's email on the order
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Param request body models.EmailReceiptRequest false "Recipient, defaults to the customer's email"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /orders/{id}/receipt/email [post]
+func EmailOrderReceipt(c *gin.Context) {
+	cfg := config.Load()
+	if !mail.Configured(cfg) {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Email is not configured"})
+		return
+	}
+
+	var req models.EmailReceiptRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	r := loadReceipt(c)
+	if r == nil {
+		return
+	}
+
+	to := strings.TrimSpace(req.Email)
+	if to == "" {
+		to = strings.TrimSpace(r.Order.CustomerEmail)
+	}
+	if to == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "The order has no customer email, provide an email address"})
+		return
+	}
+	address, err := netmail.ParseAddress(to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid email address"})
+		return
+	}
+
+	subject := fmt.Sprintf("Your receipt from %s (%s)", cfg.RestaurantName, r.Order.OrderNumber)
+	attachment := mail.Attachment{
+		Filename:    "receipt-" + r.Order.OrderNumber + ".pdf",
+		ContentType: "application/pdf",
+		Data:        r.PDF(),
+	}
+	if err := mail.Send(cfg, address.Address, subject, r.Text(receipt.Width80mm), attachment); err != nil {
+		log.Printf("Failed to email receipt for order %s: %v", r.Order.OrderNumber, err)
+		c.JSON(http.StatusBadGateway, ErrorResponse{Error: "Failed to send the receipt email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Receipt sent",
+		"email":   address.Address,
+	})
+}
@@ -0,0 +1,540 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+	"vibanda-village-admin-backend/internal/config"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// defaultReportDays is the length of the period reported on when no from date is given
+const defaultReportDays = 30
+
+// revenueIntervalFormats maps each revenue interval to the $dateToString format used to group it.
+// Weeks are ISO weeks, e.g. 2024-W07.
+var revenueIntervalFormats = map[string]string{
+	"day":   "%Y-%m-%d",
+	"week":  "%G-W%V",
+	"month": "%Y-%m",
+}
+
+// reportPeriod is the date range a report covers, resolved in the requested time zone
+type reportPeriod struct {
+	from     string
+	to       string
+	timeZone string
+	start    time.Time
+	end      time.Time
+}
+
+// parseReportPeriod reads the from, to and tz query parameters. Both dates are
+// inclusive; the period defaults to the last 30 days in the configured time zone.
+func parseReportPeriod(c *gin.Context) (reportPeriod, error) {
+	cfg := config.Load()
+
+	timeZone := c.DefaultQuery("tz", cfg.ReportTimeZone)
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return reportPeriod{}, errors.New("Invalid time zone, expected an IANA name such as Africa/Nairobi")
+	}
+
+	now := time.Now().In(location)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	if to := c.Query("to"); to != "" {
+		end, err = time.ParseInLocation(reservationDateLayout, to, location)
+		if err != nil {
+			return reportPeriod{}, errors.New("Invalid to date, expected YYYY-MM-DD")
+		}
+	}
+	start := end.AddDate(0, 0, -(defaultReportDays - 1))
+	if from := c.Query("from"); from != "" {
+		start, err = time.ParseInLocation(reservationDateLayout, from, location)
+		if err != nil {
+			return reportPeriod{}, errors.New("Invalid from date, expected YYYY-MM-DD")
+		}
+	}
+	if start.After(end) {
+		return reportPeriod{}, errors.New("From date must not be after to date")
+	}
+
+	return reportPeriod{
+		from:     start.Format(reservationDateLayout),
+		to:       end.Format(reservationDateLayout),
+		timeZone: timeZone,
+		start:    start,
+		end:      end.AddDate(0, 0, 1),
+	}, nil
+}
+
+// createdWithin matches documents created during the period
+func (p reportPeriod) createdWithin() bson.M {
+	return bson.M{"created_at": bson.M{"$gte": p.start, "$lt": p.end}}
+}
+
+// datedWithin matches documents whose YYYY-MM-DD date field falls in the period
+func (p reportPeriod) datedWithin() bson.M {
+	return bson.M{"date": bson.M{"$gte": p.from, "$lte": p.to}}
+}
+
+func (p reportPeriod) toRange() models.ReportRange {
+	return models.ReportRange{From: p.from, To: p.to, TimeZone: p.timeZone}
+}
+
+// aggregate runs pipeline against collection and decodes every result into results
+func aggregate(ctx context.Context, collection string, pipeline mongo.Pipeline, results interface{}) error {
+	cursor, err := database.DB.Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return cursor.All(ctx, results)
+}
+
+// cancellationRate counts the documents matching filter and how many of them have a cancelled status
+func cancellationRate(ctx context.Context, collection string, filter bson.M, cancelledStatuses []string) (models.CancellationRate, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: nil},
+			{Key: "total", Value: bson.M{"$sum": 1}},
+			{Key: "cancelled", Value: bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$in": bson.A{"$status", cancelledStatuses}}, 1, 0}}}},
+		}}},
+	}
+
+	var results []models.CancellationRate
+	if err := aggregate(ctx, collection, pipeline, &results); err != nil {
+		return models.CancellationRate{}, err
+	}
+	if len(results) == 0 {
+		return models.CancellationRate{}, nil
+	}
+
+	rate := results[0]
+	if rate.Total > 0 {
+		rate.Rate = float64(rate.Cancelled) / float64(rate.Total)
+	}
+	return rate, nil
+}
+
+// GetRevenueReport godoc
+// @Summary Revenue report
+// @Description Amounts paid less refunds on paid orders, grouped by day, week or month
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start date (YYYY-MM-DD), defaults to 30 days before to"
+// @Param to query string false "End date (YYYY-MM-DD), defaults to today"
+// @Param tz query string false "IANA time zone used for dates and grouping"
+// @Param interval query string false "Grouping interval (day, week, month)" default(day)
+// @Success 200 {object} models.RevenueReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /reports/revenue [get]
+func GetRevenueReport(c *gin.Context) {
+	period, err := parseReportPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	interval := c.DefaultQuery("interval", "day")
+	format, ok := revenueIntervalFormats[interval]
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Interval must be one of: day, week, month"})
+		return
+	}
+
+	// Revenue is what was actually taken for paid orders, net of refunds, which
+	// can differ from the order total, e.g. when the customer overpaid
+	match := period.createdWithin()
+	match["payment_status"] = models.PaymentStatusPaid
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.M{"$dateToString": bson.M{"format": format, "date": "$created_at", "timezone": period.timeZone}}},
+			{Key: "revenue", Value: bson.M{"$sum": bson.M{"$subtract": bson.A{
+				bson.M{"$ifNull": bson.A{"$amount_paid", 0}},
+				bson.M{"$ifNull": bson.A{"$amount_refunded", 0}},
+			}}}},
+			{Key: "orders", Value: bson.M{"$sum": 1}},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	ctx := context.Background()
+	points := []models.RevenuePoint{}
+	if err := aggregate(ctx, "orders", pipeline, &points); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to build revenue report"})
+		return
+	}
+
+	report := models.RevenueReport{Range: period.toRange(), Interval: interval, Currency: config.Load().Currency, Points: points}
+	for _, point := range points {
+		report.Revenue += point.Revenue
+		report.Orders += point.Orders
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetOrderStatusReport godoc
+// @Summary Orders by status
+// @Description Number of orders created in the period grouped by status
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start date (YYYY-MM-DD), defaults to 30 days before to"
+// @Param to query string false "End date (YYYY-MM-DD), defaults to today"
+// @Param tz query string false "IANA time zone used for dates"
+// @Success 200 {object} models.OrderStatusReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /reports/orders-by-status [get]
+func GetOrderStatusReport(c *gin.Context) {
+	period, err := parseReportPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: period.createdWithin()}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$status"},
+			{Key: "count", Value: bson.M{"$sum": 1}},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	ctx := context.Background()
+	statuses := []models.StatusCount{}
+	if err := aggregate(ctx, "orders", pipeline, &statuses); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to build order status report"})
+		return
+	}
+
+	report := models.OrderStatusReport{Range: period.toRange(), Statuses: statuses}
+	for _, status := range statuses {
+		report.Total += status.Count
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetTopItemsReport godoc
+// @Summary Top-selling items
+// @Description Items sold in the period ranked by quantity, excluding cancelled orders and voided items
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start date (YYYY-MM-DD), defaults to 30 days before to"
+// @Param to query string false "End date (YYYY-MM-DD), defaults to today"
+// @Param tz query string false "IANA time zone used for dates"
+// @Param limit query int false "Number of items" default(10)
+// @Success 200 {object} models.TopItemsReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /reports/top-items [get]
+func GetTopItemsReport(c *gin.Context) {
+	period, err := parseReportPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	limit := parseIntParam(c.Query("limit"), 10)
+	if limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Limit must be between 1 and 100"})
+		return
+	}
+
+	match := period.createdWithin()
+	match["status"] = bson.M{"$ne": models.OrderStatusCancelled}
+
+	// Items from orders placed before products were linked have no product_id, so fall back to the name
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$match", Value: bson.M{"items.voided": bson.M{"$ne": true}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.M{"$ifNull": bson.A{"$items.product_id", "$items.name"}}},
+			{Key: "name", Value: bson.M{"$last": "$items.name"}},
+			{Key: "quantity", Value: bson.M{"$sum": "$items.quantity"}},
+			{Key: "revenue", Value: bson.M{"$sum": "$items.total"}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "quantity", Value: -1}, {Key: "revenue", Value: -1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	var results []struct {
+		ID       interface{}  `bson:"_id"`
+		Name     string       `bson:"name"`
+		Quantity int64        `bson:"quantity"`
+		Revenue  models.Money `bson:"revenue"`
+	}
+	ctx := context.Background()
+	if err := aggregate(ctx, "orders", pipeline, &results); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to build top items report"})
+		return
+	}
+
+	items := make([]models.TopItem, 0, len(results))
+	for _, result := range results {
+		item := models.TopItem{Name: result.Name, Quantity: result.Quantity, Revenue: result.Revenue}
+		if productID, ok := result.ID.(primitive.ObjectID); ok {
+			item.ProductID = productID.Hex()
+		}
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, models.TopItemsReport{Range: period.toRange(), Currency: config.Load().Currency, Items: items})
+}
+
+// GetAverageTicketReport godoc
+// @Summary Average ticket size
+// @Description Number, total value and average value of paid orders in the period
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start date (YYYY-MM-DD), defaults to 30 days before to"
+// @Param to query string false "End date (YYYY-MM-DD), defaults to today"
+// @Param tz query string false "IANA time zone used for dates"
+// @Success 200 {object} models.AverageTicketReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /reports/average-ticket [get]
+func GetAverageTicketReport(c *gin.Context) {
+	period, err := parseReportPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Revenue is what was actually taken for paid orders, net of refunds, which
+	// can differ from the order total, e.g. when the customer overpaid
+	match := period.createdWithin()
+	match["payment_status"] = models.PaymentStatusPaid
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: nil},
+			{Key: "orders", Value: bson.M{"$sum": 1}},
+			{Key: "revenue", Value: bson.M{"$sum": bson.M{"$subtract": bson.A{
+				bson.M{"$ifNull": bson.A{"$amount_paid", 0}},
+				bson.M{"$ifNull": bson.A{"$amount_refunded", 0}},
+			}}}},
+		}}},
+	}
+
+	ctx := context.Background()
+	var results []models.AverageTicketReport
+	if err := aggregate(ctx, "orders", pipeline, &results); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to build average ticket report"})
+		return
+	}
+
+	report := models.AverageTicketReport{}
+	if len(results) > 0 {
+		report = results[0]
+	}
+	if report.Orders > 0 {
+		report.AverageTicket = report.Revenue.MulDiv(1, report.Orders)
+	}
+	report.Range = period.toRange()
+	report.Currency = config.Load().Currency
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetCoversReport godoc
+// @Summary Reservation covers per day
+// @Description Number of reservations and guests booked for each day, excluding cancelled and waitlisted bookings
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start date (YYYY-MM-DD), defaults to 30 days before to"
+// @Param to query string false "End date (YYYY-MM-DD), defaults to today"
+// @Param tz query string false "IANA time zone used for dates"
+// @Success 200 {object} models.CoversReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /reports/covers [get]
+func GetCoversReport(c *gin.Context) {
+	period, err := parseReportPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	match := period.datedWithin()
+	match["status"] = bson.M{"$in": activeReservationStatuses}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$date"},
+			{Key: "reservations", Value: bson.M{"$sum": 1}},
+			{Key: "covers", Value: bson.M{"$sum": "$guests"}},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	ctx := context.Background()
+	days := []models.CoversPoint{}
+	if err := aggregate(ctx, "reservations", pipeline, &days); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to build covers report"})
+		return
+	}
+
+	report := models.CoversReport{Range: period.toRange(), Days: days}
+	for _, day := range days {
+		report.Reservations += day.Reservations
+		report.Covers += day.Covers
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetCancellationReport godoc
+// @Summary Cancellation rates
+// @Description Share of orders, reservations and event tickets in the period that were cancelled
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start date (YYYY-MM-DD), defaults to 30 days before to"
+// @Param to query string false "End date (YYYY-MM-DD), defaults to today"
+// @Param tz query string false "IANA time zone used for dates"
+// @Success 200 {object} models.CancellationReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /reports/cancellations [get]
+func GetCancellationReport(c *gin.Context) {
+	period, err := parseReportPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	report := models.CancellationReport{Range: period.toRange()}
+
+	report.Orders, err = cancellationRate(ctx, "orders", period.createdWithin(),
+		[]string{string(models.OrderStatusCancelled)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to build cancellation report"})
+		return
+	}
+
+	report.Reservations, err = cancellationRate(ctx, "reservations", period.datedWithin(),
+		[]string{string(models.ReservationStatusCancelled)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to build cancellation report"})
+		return
+	}
+
+	report.Tickets, err = cancellationRate(ctx, "tickets", period.createdWithin(),
+		[]string{string(models.TicketStatusCancelled), string(models.TicketStatusRefunded)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to build cancellation report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetEventSalesReport godoc
+// @Summary Event ticket sales
+// @Description Tickets sold, sell-through and ticket revenue for events taking place in the period
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start date (YYYY-MM-DD), defaults to 30 days before to"
+// @Param to query string false "End date (YYYY-MM-DD), defaults to today"
+// @Param tz query string false "IANA time zone used for dates"
+// @Success 200 {object} models.EventSalesReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /reports/events [get]
+func GetEventSalesReport(c *gin.Context) {
+	period, err := parseReportPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Revenue counts the price paid for every ticket that was not cancelled or refunded
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: period.datedWithin()}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "tickets",
+			"localField":   "_id",
+			"foreignField": "event_id",
+			"as":           "tickets",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"title":        1,
+			"date":         1,
+			"capacity":     1,
+			"tickets_sold": 1,
+			"revenue": bson.M{"$sum": bson.M{"$map": bson.M{
+				"input": bson.M{"$filter": bson.M{
+					"input": "$tickets",
+					"as":    "ticket",
+					"cond":  bson.M{"$in": bson.A{"$$ticket.status", bson.A{models.TicketStatusValid, models.TicketStatusUsed}}},
+				}},
+				"as": "ticket",
+				"in": "$$ticket.price",
+			}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "date", Value: 1}, {Key: "title", Value: 1}}}},
+	}
+
+	var results []struct {
+		ID          primitive.ObjectID `bson:"_id"`
+		Title       string             `bson:"title"`
+		Date        string             `bson:"date"`
+		Capacity    int                `bson:"capacity"`
+		TicketsSold int                `bson:"tickets_sold"`
+		Revenue     models.Money       `bson:"revenue"`
+	}
+	ctx := context.Background()
+	if err := aggregate(ctx, "events", pipeline, &results); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to build event sales report"})
+		return
+	}
+
+	events := make([]models.EventSales, 0, len(results))
+	for _, result := range results {
+		sales := models.EventSales{
+			EventID:     result.ID.Hex(),
+			Title:       result.Title,
+			Date:        result.Date,
+			Capacity:    result.Capacity,
+			TicketsSold: result.TicketsSold,
+			Revenue:     result.Revenue,
+		}
+		if result.Capacity > 0 {
+			sales.SellThrough = float64(result.TicketsSold) / float64(result.Capacity)
+		}
+		events = append(events, sales)
+	}
+
+	c.JSON(http.StatusOK, models.EventSalesReport{Range: period.toRange(), Events: events})
+}
@@ -2,17 +2,34 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"sort"
 	"time"
+	"vibanda-village-admin-backend/internal/config"
 	"vibanda-village-admin-backend/internal/database"
 	"vibanda-village-admin-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const (
+	reservationDateLayout = "2006-01-02"
+	reservationTimeLayout = "15:04"
+)
+
+// activeReservationStatuses are the statuses that hold a table
+var activeReservationStatuses = []models.ReservationStatus{
+	models.ReservationStatusPending,
+	models.ReservationStatusConfirmed,
+}
+
+var errNoTableAvailable = errors.New("no table available for the requested time")
+
 // GetReservations godoc
 // @Summary Get all reservations
 // @Description Retrieve a list of all reservations with pagination
@@ -128,7 +145,7 @@ func GetReservation(c *gin.Context) {
 
 // CreateReservation godoc
 // @Summary Create a new reservation
-// @Description Create a new reservation
+// @Description Create a new reservation and assign it a free table, or a combination of tables, for the seating duration
 // @Tags reservations
 // @Accept json
 // @Produce json
@@ -136,6 +153,7 @@ func GetReservation(c *gin.Context) {
 // @Param request body models.CreateReservationRequest true "Reservation data"
 // @Success 201 {object} models.ReservationResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /reservations [post]
 func CreateReservation(c *gin.Context) {
@@ -144,6 +162,14 @@ func CreateReservation(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
+	if _, err := parseReservationSlot(req.Date, req.Time); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Date must be YYYY-MM-DD and time must be HH:MM"})
+		return
+	}
+	if req.Guests < 1 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Guests must be at least 1"})
+		return
+	}
 
 	collection := database.DB.Collection("reservations")
 	ctx := context.Background()
@@ -162,13 +188,23 @@ func CreateReservation(c *gin.Context) {
 		CreatedAt:       now,
 		UpdatedAt:       now,
 	}
-
-	_, err := collection.InsertOne(ctx, reservation)
+	reservation.CustomerID = linkCustomer(ctx, reservation.CustomerName, reservation.CustomerPhone, reservation.CustomerEmail)
+
+	err := bookReservation(ctx, &reservation, req.Waitlist, func(sessCtx mongo.SessionContext) error {
+		_, err := collection.InsertOne(sessCtx, reservation)
+		return err
+	})
+	if errors.Is(err, errNoTableAvailable) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "No table is available for the requested time. Try another time or join the waitlist."})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create reservation"})
 		return
 	}
 
+	recordAudit(c, models.AuditActionCreate, "reservation", reservation.ID, nil, reservation)
+
 	c.JSON(http.StatusCreated, reservation.ToResponse())
 }
 
@@ -184,6 +220,7 @@ func CreateReservation(c *gin.Context) {
 // @Success 200 {object} models.ReservationResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /reservations/{id} [put]
 func UpdateReservation(c *gin.Context) {
@@ -210,6 +247,8 @@ func UpdateReservation(c *gin.Context) {
 		return
 	}
 
+	original := reservation
+
 	// Update fields
 	if req.CustomerName != "" {
 		reservation.CustomerName = req.CustomerName
@@ -236,26 +275,67 @@ func UpdateReservation(c *gin.Context) {
 		reservation.SpecialRequests = req.SpecialRequests
 	}
 
+	if reservation.CustomerPhone != original.CustomerPhone || reservation.CustomerEmail != original.CustomerEmail {
+		if customerID := linkCustomer(ctx, reservation.CustomerName, reservation.CustomerPhone, reservation.CustomerEmail); !customerID.IsZero() {
+			reservation.CustomerID = customerID
+		}
+	}
+
+	slotChanged := reservation.Date != original.Date || reservation.Time != original.Time || reservation.Guests != original.Guests
+	if slotChanged {
+		if _, err := parseReservationSlot(reservation.Date, reservation.Time); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Date must be YYYY-MM-DD and time must be HH:MM"})
+			return
+		}
+	}
+
+	// Re-seat the booking when its slot changes or when it is reinstated from the waitlist or a cancellation
+	reinstated := !isActiveReservationStatus(original.Status) && isActiveReservationStatus(reservation.Status)
+	needsTables := isActiveReservationStatus(reservation.Status) && (slotChanged || reinstated)
+	if reservation.Status == models.ReservationStatusWaitlisted {
+		reservation.TableIDs = nil
+	}
+
 	reservation.UpdatedAt = time.Now()
 
-	update := bson.M{"$set": bson.M{
-		"customer_name":    reservation.CustomerName,
-		"customer_email":   reservation.CustomerEmail,
-		"customer_phone":   reservation.CustomerPhone,
-		"date":             reservation.Date,
-		"time":             reservation.Time,
-		"guests":           reservation.Guests,
-		"status":           reservation.Status,
-		"special_requests": reservation.SpecialRequests,
-		"updated_at":       reservation.UpdatedAt,
-	}}
-
-	_, err = collection.UpdateOne(ctx, bson.M{"_id": reservationObjectID}, update)
+	save := func(ctx context.Context) error {
+		set := bson.M{
+			"customer_name":    reservation.CustomerName,
+			"customer_email":   reservation.CustomerEmail,
+			"customer_phone":   reservation.CustomerPhone,
+			"date":             reservation.Date,
+			"time":             reservation.Time,
+			"guests":           reservation.Guests,
+			"table_ids":        reservation.TableIDs,
+			"status":           reservation.Status,
+			"special_requests": reservation.SpecialRequests,
+			"updated_at":       reservation.UpdatedAt,
+		}
+		if !reservation.CustomerID.IsZero() {
+			set["customer_id"] = reservation.CustomerID
+		}
+		_, err := collection.UpdateOne(ctx, bson.M{"_id": reservationObjectID}, bson.M{"$set": set})
+		return err
+	}
+
+	if needsTables {
+		err = bookReservation(ctx, &reservation, false, func(sessCtx mongo.SessionContext) error {
+			return save(sessCtx)
+		})
+	} else {
+		err = save(ctx)
+	}
+	if errors.Is(err, errNoTableAvailable) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "No table is available for the requested time"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update reservation"})
 		return
 	}
 
+	recordAudit(c, models.AuditActionUpdate, "reservation", reservation.ID, original, reservation)
+
 	c.JSON(http.StatusOK, reservation.ToResponse())
 }
 
@@ -296,5 +376,236 @@ func DeleteReservation(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditActionDelete, "reservation", reservation.ID, reservation, nil)
+
 	c.JSON(http.StatusNoContent, nil)
 }
+
+// parseReservationSlot parses a reservation date (YYYY-MM-DD) and time (HH:MM) in local time
+func parseReservationSlot(date, timeOfDay string) (time.Time, error) {
+	return time.ParseInLocation(reservationDateLayout+" "+reservationTimeLayout, date+" "+timeOfDay, time.Local)
+}
+
+// isActiveReservationStatus reports whether a reservation in this status holds a table
+func isActiveReservationStatus(status models.ReservationStatus) bool {
+	for _, active := range activeReservationStatuses {
+		if status == active {
+			return true
+		}
+	}
+	return false
+}
+
+// reservationSeatingDuration returns how long a table is held for each reservation
+func reservationSeatingDuration() time.Duration {
+	return time.Duration(config.Load().ReservationDurationMinutes) * time.Minute
+}
+
+// bookReservation assigns tables to the reservation and persists it with save, in one
+// transaction. When no table is free the reservation is waitlisted if allowed, otherwise
+// errNoTableAvailable is returned.
+func bookReservation(ctx context.Context, reservation *models.Reservation, allowWaitlist bool, save func(mongo.SessionContext) error) error {
+	session, err := database.Client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	requestedStatus := reservation.Status
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		// Reset state in case the transaction is retried
+		reservation.Status = requestedStatus
+		reservation.TableIDs = nil
+
+		// Seatings can run past midnight, so bookings on the days either side are
+		// locked out as well
+		for _, date := range adjacentReservationDates(reservation.Date) {
+			if err := lockReservationDate(sessCtx, date); err != nil {
+				return nil, err
+			}
+		}
+
+		tables, err := allocateTables(sessCtx, reservation.Date, reservation.Time, reservation.Guests, reservation.ID)
+		if errors.Is(err, errNoTableAvailable) && allowWaitlist {
+			reservation.Status = models.ReservationStatusWaitlisted
+		} else if err != nil {
+			return nil, err
+		}
+		for _, table := range tables {
+			reservation.TableIDs = append(reservation.TableIDs, table.ID)
+		}
+
+		return nil, save(sessCtx)
+	})
+	return err
+}
+
+// lockReservationDate writes to a per-date document so that concurrent bookings for the
+// same date hit a write conflict and are retried, instead of both taking the same table
+func lockReservationDate(ctx context.Context, date string) error {
+	_, err := database.DB.Collection("reservation_locks").UpdateOne(ctx,
+		bson.M{"_id": date},
+		bson.M{"$inc": bson.M{"version": 1}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// adjacentReservationDates returns the date with the days before and after it,
+// whose seatings may overlap its own across midnight
+func adjacentReservationDates(date string) []string {
+	day, err := time.ParseInLocation(reservationDateLayout, date, time.Local)
+	if err != nil {
+		return []string{date}
+	}
+	return []string{
+		day.AddDate(0, 0, -1).Format(reservationDateLayout),
+		date,
+		day.AddDate(0, 0, 1).Format(reservationDateLayout),
+	}
+}
+
+// activeReservationsOn loads the reservations holding tables on a date, including
+// those on the days either side, whose late or early seatings may overlap it. The
+// reservation excludeID is left out so it can be re-seated.
+func activeReservationsOn(ctx context.Context, date string, excludeID primitive.ObjectID) ([]models.Reservation, error) {
+	filter := bson.M{
+		"date":   bson.M{"$in": adjacentReservationDates(date)},
+		"status": bson.M{"$in": activeReservationStatuses},
+	}
+	if !excludeID.IsZero() {
+		filter["_id"] = bson.M{"$ne": excludeID}
+	}
+
+	cursor, err := database.DB.Collection("reservations").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reservations []models.Reservation
+	if err = cursor.All(ctx, &reservations); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// occupiedTableIDs returns the tables held by reservations whose seating overlaps one starting at start
+func occupiedTableIDs(reservations []models.Reservation, start time.Time, duration time.Duration) map[primitive.ObjectID]bool {
+	occupied := map[primitive.ObjectID]bool{}
+	for _, reservation := range reservations {
+		seatedAt, err := parseReservationSlot(reservation.Date, reservation.Time)
+		if err != nil {
+			continue
+		}
+		if start.Before(seatedAt.Add(duration)) && seatedAt.Before(start.Add(duration)) {
+			for _, tableID := range reservation.TableIDs {
+				occupied[tableID] = true
+			}
+		}
+	}
+	return occupied
+}
+
+// freeTables returns the tables not present in occupied
+func freeTables(tables []models.Table, occupied map[primitive.ObjectID]bool) []models.Table {
+	var free []models.Table
+	for _, table := range tables {
+		if !occupied[table.ID] {
+			free = append(free, table)
+		}
+	}
+	return free
+}
+
+// activeTables returns all tables currently in service
+func activeTables(ctx context.Context) ([]models.Table, error) {
+	cursor, err := database.DB.Collection("tables").Find(ctx, bson.M{"active": true})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tables []models.Table
+	if err = cursor.All(ctx, &tables); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// allocateTables finds free tables seating guests at the given date and time.
+// Until any tables are set up, bookings are taken without one.
+func allocateTables(ctx context.Context, date, timeOfDay string, guests int, excludeID primitive.ObjectID) ([]models.Table, error) {
+	start, err := parseReservationSlot(date, timeOfDay)
+	if err != nil {
+		return nil, err
+	}
+
+	reservations, err := activeReservationsOn(ctx, date, excludeID)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := activeTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, nil
+	}
+
+	occupied := occupiedTableIDs(reservations, start, reservationSeatingDuration())
+	free := freeTables(tables, occupied)
+
+	chosen := chooseTables(free, guests)
+	if len(chosen) == 0 {
+		return nil, errNoTableAvailable
+	}
+	return chosen, nil
+}
+
+// chooseTables picks the smallest single table that seats the party. Failing that it
+// combines combinable tables within one area, preferring the fewest spare seats.
+func chooseTables(free []models.Table, guests int) []models.Table {
+	var best *models.Table
+	for i := range free {
+		table := &free[i]
+		if table.Seats >= guests && (best == nil || table.Seats < best.Seats) {
+			best = table
+		}
+	}
+	if best != nil {
+		return []models.Table{*best}
+	}
+
+	byArea := map[models.TableArea][]models.Table{}
+	for _, table := range free {
+		if table.Combinable {
+			byArea[table.Area] = append(byArea[table.Area], table)
+		}
+	}
+
+	var bestCombination []models.Table
+	bestSeats := 0
+	for _, tables := range byArea {
+		sort.Slice(tables, func(i, j int) bool { return tables[i].Seats > tables[j].Seats })
+
+		var combination []models.Table
+		seats := 0
+		for _, table := range tables {
+			if seats >= guests {
+				break
+			}
+			combination = append(combination, table)
+			seats += table.Seats
+		}
+		if seats < guests {
+			continue
+		}
+		if bestCombination == nil || seats < bestSeats || (seats == bestSeats && len(combination) < len(bestCombination)) {
+			bestCombination = combination
+			bestSeats = seats
+		}
+	}
+	return bestCombination
+}
@@ -0,0 +1,521 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/inventory"
+	"vibanda-village-admin-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// stationCodePattern restricts station codes to short lowercase slugs used in URLs
+var stationCodePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{1,29}$`)
+
+// openTicketStatuses are the ticket statuses a station still has to work on
+var openTicketStatuses = []models.StationTicketStatus{models.StationTicketQueued, models.StationTicketPreparing}
+
+// loadStations returns every prep station, ordered by code
+func loadStations(ctx context.Context) ([]models.Station, error) {
+	opts := options.Find().SetSort(bson.M{"code": 1})
+	cursor, err := database.DB.Collection("stations").Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var stations []models.Station
+	if err := cursor.All(ctx, &stations); err != nil {
+		return nil, err
+	}
+	return stations, nil
+}
+
+// routeOrderItems sets the prep station of each item from its product's category
+// and subcategory
+func routeOrderItems(ctx context.Context, items []models.OrderItem) error {
+	stations, err := loadStations(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].Station = models.StationFor(stations, items[i].Category, items[i].Subcategory)
+	}
+	return nil
+}
+
+// validateStationRouting checks the categories of a station and that no other
+// active station already takes the same categories or subcategories
+func validateStationRouting(ctx context.Context, station *models.Station) error {
+	for _, category := range station.Categories {
+		if category != models.CategoryFood && category != models.CategoryDrink {
+			return errors.New("Category must be one of: food, drink")
+		}
+	}
+	if !station.Active {
+		return nil
+	}
+
+	stations, err := loadStations(ctx)
+	if err != nil {
+		return err
+	}
+	for _, other := range stations {
+		if other.ID == station.ID || !other.Active {
+			continue
+		}
+		for _, category := range station.Categories {
+			for _, taken := range other.Categories {
+				if category == taken {
+					return fmt.Errorf("Category %s is already routed to station %s", category, other.Code)
+				}
+			}
+		}
+		for _, subcategory := range station.Subcategories {
+			for _, taken := range other.Subcategories {
+				if subcategory == taken {
+					return fmt.Errorf("Subcategory %s is already routed to station %s", subcategory, other.Code)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// clearOtherDefaultStations makes station the only default station
+func clearOtherDefaultStations(ctx context.Context, station *models.Station) error {
+	filter := bson.M{"_id": bson.M{"$ne": station.ID}, "is_default": true}
+	update := bson.M{"$set": bson.M{"is_default": false, "updated_at": time.Now()}}
+	_, err := database.DB.Collection("stations").UpdateMany(ctx, filter, update)
+	return err
+}
+
+// GetStations godoc
+// @Summary Get prep stations
+// @Description Retrieve the prep stations and the product categories and subcategories routed to each
+// @Tags stations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.StationResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /stations [get]
+func GetStations(c *gin.Context) {
+	stations, err := loadStations(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch stations"})
+		return
+	}
+
+	stationResponses := []models.StationResponse{}
+	for _, station := range stations {
+		stationResponses = append(stationResponses, station.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, stationResponses)
+}
+
+// CreateStation godoc
+// @Summary Create a prep station
+// @Description Add a prep station. Items are routed to the station that lists their product's subcategory, then to the one that lists its category, and otherwise to the default station.
+// @Tags stations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateStationRequest true "Station data"
+// @Success 201 {object} models.StationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /stations [post]
+func CreateStation(c *gin.Context) {
+	var req models.CreateStationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	code := strings.ToLower(strings.TrimSpace(req.Code))
+	if !stationCodePattern.MatchString(code) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Code must be 2 to 30 lowercase letters, digits, dashes or underscores"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Name is required"})
+		return
+	}
+
+	collection := database.DB.Collection("stations")
+	ctx := context.Background()
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	now := time.Now()
+	station := models.Station{
+		ID:            primitive.NewObjectID(),
+		Code:          code,
+		Name:          name,
+		Categories:    req.Categories,
+		Subcategories: req.Subcategories,
+		IsDefault:     req.IsDefault,
+		Active:        active,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if station.Categories == nil {
+		station.Categories = []models.ProductCategory{}
+	}
+	if station.Subcategories == nil {
+		station.Subcategories = []models.ProductSubcategory{}
+	}
+
+	if err := validateStationRouting(ctx, &station); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	_, err := collection.InsertOne(ctx, station)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "A station with this code already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create station"})
+		return
+	}
+	if station.IsDefault {
+		if err := clearOtherDefaultStations(ctx, &station); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update the default station"})
+			return
+		}
+	}
+
+	recordAudit(c, models.AuditActionCreate, "station", station.ID, nil, station)
+
+	c.JSON(http.StatusCreated, station.ToResponse())
+}
+
+// UpdateStation godoc
+// @Summary Update a prep station
+// @Description Change a station's name, routing or status. Routing changes apply to items ordered afterwards.
+// @Tags stations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code path string true "Station code"
+// @Param request body models.UpdateStationRequest true "Station update data"
+// @Success 200 {object} models.StationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /stations/{code} [put]
+func UpdateStation(c *gin.Context) {
+	code := c.Param("code")
+
+	var req models.UpdateStationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	collection := database.DB.Collection("stations")
+	ctx := context.Background()
+
+	var station models.Station
+	err := collection.FindOne(ctx, bson.M{"code": code}).Decode(&station)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Station not found"})
+		return
+	}
+	before := station
+
+	if name := strings.TrimSpace(req.Name); name != "" {
+		station.Name = name
+	}
+	if req.Categories != nil {
+		station.Categories = *req.Categories
+	}
+	if req.Subcategories != nil {
+		station.Subcategories = *req.Subcategories
+	}
+	if req.IsDefault != nil {
+		station.IsDefault = *req.IsDefault
+	}
+	if req.Active != nil {
+		station.Active = *req.Active
+	}
+
+	if err := validateStationRouting(ctx, &station); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	station.UpdatedAt = time.Now()
+
+	update := bson.M{"$set": bson.M{
+		"name":          station.Name,
+		"categories":    station.Categories,
+		"subcategories": station.Subcategories,
+		"is_default":    station.IsDefault,
+		"active":        station.Active,
+		"updated_at":    station.UpdatedAt,
+	}}
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": station.ID}, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update station"})
+		return
+	}
+	if station.IsDefault && !before.IsDefault {
+		if err := clearOtherDefaultStations(ctx, &station); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update the default station"})
+			return
+		}
+	}
+
+	recordAudit(c, models.AuditActionUpdate, "station", station.ID, before, station)
+
+	c.JSON(http.StatusOK, station.ToResponse())
+}
+
+// DeleteStation godoc
+// @Summary Delete a prep station
+// @Description Delete a station that has no open tickets. Deactivate it instead to stop routing new items to it.
+// @Tags stations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code path string true "Station code"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /stations/{code} [delete]
+func DeleteStation(c *gin.Context) {
+	code := c.Param("code")
+
+	collection := database.DB.Collection("stations")
+	ctx := context.Background()
+
+	var station models.Station
+	err := collection.FindOne(ctx, bson.M{"code": code}).Decode(&station)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Station not found"})
+		return
+	}
+
+	open, err := database.DB.Collection("orders").CountDocuments(ctx, openTicketsFilter(station.Code))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete station"})
+		return
+	}
+	if open > 0 {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Station has open tickets; deactivate it instead"})
+		return
+	}
+
+	_, err = collection.DeleteOne(ctx, bson.M{"_id": station.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete station"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionDelete, "station", station.ID, station, nil)
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// openTicketsFilter matches open orders with tickets the station still has to work on
+func openTicketsFilter(code string) bson.M {
+	return bson.M{
+		"status": bson.M{"$in": []models.OrderStatus{models.OrderStatusPending, models.OrderStatusConfirmed, models.OrderStatusReady}},
+		"tickets": bson.M{"$elemMatch": bson.M{
+			"station": code,
+			"status":  bson.M{"$in": openTicketStatuses},
+		}},
+	}
+}
+
+// GetStationTickets godoc
+// @Summary List a station's open tickets
+// @Description Tickets a station still has to prepare, oldest first, with the items on each
+// @Tags stations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code path string true "Station code"
+// @Success 200 {array} models.StationTicketResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /stations/{code}/tickets [get]
+func GetStationTickets(c *gin.Context) {
+	code := c.Param("code")
+	ctx := context.Background()
+
+	count, err := database.DB.Collection("stations").CountDocuments(ctx, bson.M{"code": code})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch tickets"})
+		return
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Station not found"})
+		return
+	}
+
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := database.DB.Collection("orders").Find(ctx, openTicketsFilter(code), opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch tickets"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var orders []models.Order
+	if err = cursor.All(ctx, &orders); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to decode tickets"})
+		return
+	}
+
+	ticketResponses := []models.StationTicketResponse{}
+	for i := range orders {
+		for j := range orders[i].Tickets {
+			ticket := &orders[i].Tickets[j]
+			if ticket.Station != code || ticket.Status == models.StationTicketReady {
+				continue
+			}
+			ticketResponses = append(ticketResponses, orders[i].TicketResponse(ticket))
+		}
+	}
+
+	c.JSON(http.StatusOK, ticketResponses)
+}
+
+// UpdateStationTicket godoc
+// @Summary Update a ticket's status
+// @Description Move a station ticket from queued to preparing to ready. When every ticket of an order is ready, the order becomes ready.
+// @Tags stations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code path string true "Station code"
+// @Param ticketId path string true "Ticket ID"
+// @Param request body models.UpdateStationTicketRequest true "New status"
+// @Success 200 {object} models.StationTicketResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /stations/{code}/tickets/{ticketId} [put]
+func UpdateStationTicket(c *gin.Context) {
+	code := c.Param("code")
+	ticketObjectID, err := primitive.ObjectIDFromHex(c.Param("ticketId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ticket ID"})
+		return
+	}
+
+	var req models.UpdateStationTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.Status != models.StationTicketPreparing && req.Status != models.StationTicketReady {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Status must be one of: preparing, ready"})
+		return
+	}
+
+	collection := database.DB.Collection("orders")
+	ctx := context.Background()
+
+	var order models.Order
+	filter := bson.M{"tickets": bson.M{"$elemMatch": bson.M{"_id": ticketObjectID, "station": code}}}
+	err = collection.FindOne(ctx, filter).Decode(&order)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Ticket not found"})
+		return
+	}
+	if order.Status.IsFinal() {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("Tickets of a %s order cannot be changed", order.Status)})
+		return
+	}
+
+	before := order
+	before.Tickets = append([]models.StationTicket(nil), order.Tickets...)
+
+	ticket := order.Ticket(ticketObjectID)
+	if !ticket.Status.CanTransitionTo(req.Status) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("Cannot change ticket status from %s to %s", ticket.Status, req.Status)})
+		return
+	}
+
+	now := time.Now()
+	ticket.Status = req.Status
+	if ticket.StartedAt == nil {
+		ticket.StartedAt = &now
+	}
+	if req.Status == models.StationTicketReady {
+		ticket.ReadyAt = &now
+	}
+	statusChange := order.SyncStatusWithTickets(currentUserID(c), now)
+	order.UpdatedAt = now
+
+	update := bson.M{"$set": bson.M{
+		"tickets":    order.Tickets,
+		"status":     order.Status,
+		"updated_at": order.UpdatedAt,
+	}}
+	if statusChange != nil {
+		update["$push"] = bson.M{"status_history": statusChange}
+	}
+
+	session, err := database.Client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update ticket"})
+		return
+	}
+	defer session.EndSession(ctx)
+
+	// A pending order that becomes ready takes its ingredients with the ticket change
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		// Matching on the previous update guards against concurrent changes to the order
+		result, err := collection.UpdateOne(sessCtx, bson.M{"_id": order.ID, "updated_at": before.UpdatedAt}, update)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 0 {
+			return nil, errOrderModified
+		}
+		if statusChange == nil {
+			return nil, nil
+		}
+		return nil, inventory.Sync(sessCtx, &order)
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, errOrderModified):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Order was modified by another request, please reload and try again"})
+		return
+	case errors.Is(err, inventory.ErrInsufficientIngredients):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update ticket"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionUpdate, "order", order.ID, before, order)
+	publishOrderEvent(orderUpdateEventType(&before, &order), &order)
+
+	c.JSON(http.StatusOK, order.TicketResponse(order.Ticket(ticketObjectID)))
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// isValidTableArea checks if the area is one of the known floor plan areas
+func isValidTableArea(area models.TableArea) bool {
+	return area == models.AreaIndoor || area == models.AreaTerrace
+}
+
+// GetTables godoc
+// @Summary Get all tables
+// @Description Retrieve the floor plan tables ordered by table number
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param area query string false "Filter by area"
+// @Param status query string false "Filter by status (active/inactive)"
+// @Success 200 {array} models.TableResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tables [get]
+func GetTables(c *gin.Context) {
+	areaFilter := c.Query("area")
+	statusFilter := c.Query("status")
+
+	collection := database.DB.Collection("tables")
+	ctx := context.Background()
+
+	// Build filter
+	filter := bson.M{}
+	if areaFilter != "" {
+		filter["area"] = areaFilter
+	}
+	if statusFilter != "" {
+		filter["active"] = statusFilter == "active"
+	}
+
+	opts := options.Find().SetSort(bson.M{"number": 1})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch tables"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var tables []models.Table
+	if err = cursor.All(ctx, &tables); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to decode tables"})
+		return
+	}
+
+	// Convert to response format
+	tableResponses := []models.TableResponse{}
+	for _, table := range tables {
+		tableResponses = append(tableResponses, table.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, tableResponses)
+}
+
+// GetTable godoc
+// @Summary Get table by ID
+// @Description Retrieve a specific table by ID
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Table ID"
+// @Success 200 {object} models.TableResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tables/{id} [get]
+func GetTable(c *gin.Context) {
+	id := c.Param("id")
+	tableObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid table ID"})
+		return
+	}
+
+	collection := database.DB.Collection("tables")
+	ctx := context.Background()
+
+	var table models.Table
+	err = collection.FindOne(ctx, bson.M{"_id": tableObjectID}).Decode(&table)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Table not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, table.ToResponse())
+}
+
+// CreateTable godoc
+// @Summary Create a new table
+// @Description Add a table to the floor plan
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateTableRequest true "Table data"
+// @Success 201 {object} models.TableResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tables [post]
+func CreateTable(c *gin.Context) {
+	var req models.CreateTableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.Number < 1 || req.Seats < 1 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Table number and seats must be at least 1"})
+		return
+	}
+	if !isValidTableArea(req.Area) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Area must be one of: indoor, terrace"})
+		return
+	}
+
+	collection := database.DB.Collection("tables")
+	ctx := context.Background()
+
+	// Check if table number is already taken
+	var existingTable models.Table
+	err := collection.FindOne(ctx, bson.M{"number": req.Number}).Decode(&existingTable)
+	if err == nil {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "A table with this number already exists"})
+		return
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	now := time.Now()
+	table := models.Table{
+		ID:         primitive.NewObjectID(),
+		Number:     req.Number,
+		Seats:      req.Seats,
+		Area:       req.Area,
+		Combinable: req.Combinable,
+		Active:     active,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	_, err = collection.InsertOne(ctx, table)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create table"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, table.ToResponse())
+}
+
+// UpdateTable godoc
+// @Summary Update table
+// @Description Update an existing table
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Table ID"
+// @Param request body models.UpdateTableRequest true "Table update data"
+// @Success 200 {object} models.TableResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tables/{id} [put]
+func UpdateTable(c *gin.Context) {
+	id := c.Param("id")
+	tableObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid table ID"})
+		return
+	}
+
+	var req models.UpdateTableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.Area != "" && !isValidTableArea(req.Area) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Area must be one of: indoor, terrace"})
+		return
+	}
+
+	collection := database.DB.Collection("tables")
+	ctx := context.Background()
+
+	var table models.Table
+	err = collection.FindOne(ctx, bson.M{"_id": tableObjectID}).Decode(&table)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Table not found"})
+		return
+	}
+
+	// Check for number conflicts if it's being updated
+	if req.Number > 0 && req.Number != table.Number {
+		var existingTable models.Table
+		err := collection.FindOne(ctx, bson.M{"number": req.Number, "_id": bson.M{"$ne": tableObjectID}}).Decode(&existingTable)
+		if err == nil {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "A table with this number already exists"})
+			return
+		}
+		table.Number = req.Number
+	}
+
+	// Update other fields
+	if req.Seats > 0 {
+		table.Seats = req.Seats
+	}
+	if req.Area != "" {
+		table.Area = req.Area
+	}
+	if req.Combinable != nil {
+		table.Combinable = *req.Combinable
+	}
+	if req.Active != nil {
+		table.Active = *req.Active
+	}
+
+	table.UpdatedAt = time.Now()
+
+	update := bson.M{"$set": bson.M{
+		"number":     table.Number,
+		"seats":      table.Seats,
+		"area":       table.Area,
+		"combinable": table.Combinable,
+		"active":     table.Active,
+		"updated_at": table.UpdatedAt,
+	}}
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": tableObjectID}, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update table"})
+		return
+	}
+
+	c.JSON(http.StatusOK, table.ToResponse())
+}
+
+// DeleteTable godoc
+// @Summary Delete table
+// @Description Remove a table from the floor plan
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Table ID"
+// @Success 204 {object} nil
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tables/{id} [delete]
+func DeleteTable(c *gin.Context) {
+	id := c.Param("id")
+	tableObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid table ID"})
+		return
+	}
+
+	collection := database.DB.Collection("tables")
+	ctx := context.Background()
+
+	var table models.Table
+	err = collection.FindOne(ctx, bson.M{"_id": tableObjectID}).Decode(&table)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Table not found"})
+		return
+	}
+
+	// Refuse to delete tables that still hold upcoming bookings
+	upcoming, err := database.DB.Collection("reservations").CountDocuments(ctx, bson.M{
+		"table_ids": tableObjectID,
+		"status":    bson.M{"$in": activeReservationStatuses},
+		"date":      bson.M{"$gte": time.Now().Format(reservationDateLayout)},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to check table reservations"})
+		return
+	}
+	if upcoming > 0 {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Table has upcoming reservations; deactivate it instead"})
+		return
+	}
+
+	_, err = collection.DeleteOne(ctx, bson.M{"_id": tableObjectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete table"})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
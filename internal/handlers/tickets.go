@@ -0,0 +1,448 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/models"
+	"vibanda-village-admin-backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	errEventNotFound    = errors.New("event not found")
+	errTicketsNotOnSale = errors.New("tickets are not on sale for this event")
+	errEventSoldOut     = errors.New("not enough tickets left")
+	errTicketNotValid   = errors.New("ticket is not valid")
+)
+
+// generateTicketCode generates a unique-enough code printed on the ticket and scanned at the door
+func generateTicketCode() (string, error) {
+	code, err := utils.GenerateCode(10)
+	if err != nil {
+		return "", err
+	}
+	return "VV-" + code, nil
+}
+
+// sellEventTickets atomically adds quantity to the event's sold count, refusing sales past
+// capacity and closing ticket sales once the event is sold out
+func sellEventTickets(ctx context.Context, eventID primitive.ObjectID, quantity int) (*models.Event, error) {
+	collection := database.DB.Collection("events")
+
+	filter := bson.M{
+		"_id":               eventID,
+		"tickets_available": true,
+		"$expr": bson.M{"$lte": bson.A{
+			bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$tickets_sold", 0}}, quantity}},
+			"$capacity",
+		}},
+	}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"tickets_sold": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$tickets_sold", 0}}, quantity}},
+			"updated_at":   time.Now(),
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"tickets_available": bson.M{"$lt": bson.A{"$tickets_sold", "$capacity"}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var event models.Event
+	err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&event)
+	if err == nil {
+		return &event, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	// Work out why the sale was refused
+	err = collection.FindOne(ctx, bson.M{"_id": eventID}).Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		return nil, errEventNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !event.TicketsAvailable {
+		return nil, errTicketsNotOnSale
+	}
+	return nil, fmt.Errorf("%w: %d remaining", errEventSoldOut, event.Capacity-event.TicketsSold)
+}
+
+// releaseEventTicket gives a cancelled ticket's place back, reopening sales if the event had sold out
+func releaseEventTicket(ctx context.Context, eventID primitive.ObjectID) error {
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"tickets_available": bson.M{"$cond": bson.A{
+				bson.M{"$gte": bson.A{"$tickets_sold", "$capacity"}}, true, "$tickets_available",
+			}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"tickets_sold": bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{"$tickets_sold", 1}}}},
+			"updated_at":   time.Now(),
+		}}},
+	}
+	_, err := database.DB.Collection("events").UpdateOne(ctx, bson.M{"_id": eventID}, update)
+	return err
+}
+
+// GetEventTickets godoc
+// @Summary Get tickets for an event
+// @Description Retrieve the tickets issued for an event with pagination
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Event ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param search query string false "Search by code or holder"
+// @Param status query string false "Filter by status"
+// @Success 200 {object} PaginatedResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /events/{id}/tickets [get]
+func GetEventTickets(c *gin.Context) {
+	eventObjectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid event ID"})
+		return
+	}
+
+	page := parseIntParam(c.Query("page"), 1)
+	limit := parseIntParam(c.Query("limit"), 10)
+	search := c.Query("search")
+	statusFilter := c.Query("status")
+
+	collection := database.DB.Collection("tickets")
+	ctx := context.Background()
+
+	// Build filter
+	filter := bson.M{"event_id": eventObjectID}
+	if search != "" {
+		filter["$or"] = []bson.M{
+			{"code": bson.M{"$regex": search, "$options": "i"}},
+			{"holder_name": bson.M{"$regex": search, "$options": "i"}},
+			{"holder_email": bson.M{"$regex": search, "$options": "i"}},
+		}
+	}
+	if statusFilter != "" {
+		filter["status"] = statusFilter
+	}
+
+	// Get total count
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to count tickets"})
+		return
+	}
+
+	// Get paginated results
+	opts := options.Find()
+	opts.SetSkip(int64((page - 1) * limit))
+	opts.SetLimit(int64(limit))
+	opts.SetSort(bson.M{"created_at": -1})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch tickets"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var tickets []models.Ticket
+	if err = cursor.All(ctx, &tickets); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to decode tickets"})
+		return
+	}
+
+	// Convert to response format
+	var ticketResponses []models.TicketResponse
+	for _, ticket := range tickets {
+		ticketResponses = append(ticketResponses, ticket.ToResponse())
+	}
+
+	response := PaginatedResponse{
+		Data:       ticketResponses,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// IssueTickets godoc
+// @Summary Sell tickets for an event
+// @Description Issue one or more tickets, each with a unique code. Sales past the event capacity are refused and ticket sales close once the event sells out.
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Event ID"
+// @Param request body models.IssueTicketsRequest true "Ticket sale data"
+// @Success 201 {array} models.TicketResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /events/{id}/tickets [post]
+func IssueTickets(c *gin.Context) {
+	eventObjectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid event ID"})
+		return
+	}
+
+	var req models.IssueTicketsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if len(req.HolderName) < 2 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Holder name is required"})
+		return
+	}
+	if req.Quantity < 1 || req.Quantity > 20 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Quantity must be between 1 and 20"})
+		return
+	}
+
+	collection := database.DB.Collection("tickets")
+	ctx := context.Background()
+
+	session, err := database.Client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to issue tickets"})
+		return
+	}
+	defer session.EndSession(ctx)
+
+	var tickets []models.Ticket
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		event, err := sellEventTickets(sessCtx, eventObjectID, req.Quantity)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		tickets = nil
+		var documents []interface{}
+		for i := 0; i < req.Quantity; i++ {
+			code, err := generateTicketCode()
+			if err != nil {
+				return nil, err
+			}
+			ticket := models.Ticket{
+				ID:          primitive.NewObjectID(),
+				EventID:     event.ID,
+				Code:        code,
+				HolderName:  req.HolderName,
+				HolderEmail: req.HolderEmail,
+				HolderPhone: req.HolderPhone,
+				Price:       event.Price,
+				Status:      models.TicketStatusValid,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			}
+			tickets = append(tickets, ticket)
+			documents = append(documents, ticket)
+		}
+
+		return collection.InsertMany(sessCtx, documents)
+	})
+	switch {
+	case errors.Is(err, errEventNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Event not found"})
+		return
+	case errors.Is(err, errTicketsNotOnSale), errors.Is(err, errEventSoldOut):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to issue tickets"})
+		return
+	}
+
+	var ticketResponses []models.TicketResponse
+	for _, ticket := range tickets {
+		ticketResponses = append(ticketResponses, ticket.ToResponse())
+	}
+
+	c.JSON(http.StatusCreated, ticketResponses)
+}
+
+// CancelTicket godoc
+// @Summary Cancel a ticket
+// @Description Cancel or refund a valid ticket, returning its place to the event
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Event ID"
+// @Param ticketId path string true "Ticket ID"
+// @Param request body models.CancelTicketRequest true "Cancellation data"
+// @Success 200 {object} models.TicketResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /events/{id}/tickets/{ticketId}/cancel [post]
+func CancelTicket(c *gin.Context) {
+	eventObjectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid event ID"})
+		return
+	}
+	ticketObjectID, err := primitive.ObjectIDFromHex(c.Param("ticketId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ticket ID"})
+		return
+	}
+
+	var req models.CancelTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	collection := database.DB.Collection("tickets")
+	ctx := context.Background()
+
+	var ticket models.Ticket
+	err = collection.FindOne(ctx, bson.M{"_id": ticketObjectID, "event_id": eventObjectID}).Decode(&ticket)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Ticket not found"})
+		return
+	}
+	if ticket.Status != models.TicketStatusValid {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("Ticket is already %s", ticket.Status)})
+		return
+	}
+
+	now := time.Now()
+	ticket.Status = models.TicketStatusCancelled
+	if req.Refund {
+		ticket.Status = models.TicketStatusRefunded
+	}
+	ticket.CancelledAt = &now
+	ticket.CancelReason = req.Reason
+	ticket.UpdatedAt = now
+
+	session, err := database.Client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to cancel ticket"})
+		return
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		update := bson.M{"$set": bson.M{
+			"status":        ticket.Status,
+			"cancelled_at":  ticket.CancelledAt,
+			"cancel_reason": ticket.CancelReason,
+			"updated_at":    ticket.UpdatedAt,
+		}}
+		// Only a still-valid ticket may be cancelled, so its place is released exactly once
+		result, err := collection.UpdateOne(sessCtx, bson.M{"_id": ticketObjectID, "status": models.TicketStatusValid}, update)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 0 {
+			return nil, errTicketNotValid
+		}
+		return nil, releaseEventTicket(sessCtx, eventObjectID)
+	})
+	if errors.Is(err, errTicketNotValid) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Ticket is no longer valid"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to cancel ticket"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket.ToResponse())
+}
+
+// CheckInTicket godoc
+// @Summary Check in a ticket
+// @Description Mark a ticket as used at the door. A ticket can only be checked in once.
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Event ID"
+// @Param request body models.CheckInTicketRequest true "Ticket code"
+// @Success 200 {object} models.TicketResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /events/{id}/tickets/check-in [post]
+func CheckInTicket(c *gin.Context) {
+	eventObjectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid event ID"})
+		return
+	}
+
+	var req models.CheckInTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if code == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Ticket code is required"})
+		return
+	}
+
+	collection := database.DB.Collection("tickets")
+	ctx := context.Background()
+
+	now := time.Now()
+	filter := bson.M{"event_id": eventObjectID, "code": code, "status": models.TicketStatusValid}
+	update := bson.M{"$set": bson.M{
+		"status":        models.TicketStatusUsed,
+		"checked_in_at": now,
+		"checked_in_by": currentUserID(c),
+		"updated_at":    now,
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var ticket models.Ticket
+	err = collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&ticket)
+	if err == nil {
+		c.JSON(http.StatusOK, ticket.ToResponse())
+		return
+	}
+	if err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to check in ticket"})
+		return
+	}
+
+	// Explain why the ticket was refused
+	err = collection.FindOne(ctx, bson.M{"event_id": eventObjectID, "code": code}).Decode(&ticket)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "No ticket with this code for this event"})
+		return
+	}
+	if ticket.Status == models.TicketStatusUsed && ticket.CheckedInAt != nil {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("Ticket was already checked in at %s", ticket.CheckedInAt.Format("15:04"))})
+		return
+	}
+	c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("Ticket is %s", ticket.Status)})
+}
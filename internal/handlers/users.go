@@ -155,7 +155,7 @@ func CreateUser(c *gin.Context) {
 	}
 
 	// Get current user from context (set by auth middleware)
-	currentUserID, exists := c.Get("userID")
+	currentUserID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Unauthorized"})
 		return
@@ -240,6 +240,8 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditActionCreate, "user", user.ID, nil, user)
+
 	c.JSON(http.StatusCreated, user.ToResponse())
 }
 
@@ -273,7 +275,7 @@ func UpdateUser(c *gin.Context) {
 	}
 
 	// Get current user from context (set by auth middleware)
-	currentUserID, exists := c.Get("userID")
+	currentUserID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Unauthorized"})
 		return
@@ -301,6 +303,7 @@ func UpdateUser(c *gin.Context) {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
 		return
 	}
+	before := user
 
 	// Permission checks
 	if currentUser.Role == models.RoleAdmin {
@@ -395,12 +398,22 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
+	// A deactivated user must not be able to refresh their session
+	if user.Status == models.StatusInactive {
+		if err := revokeRefreshTokens(ctx, bson.M{"user_id": userObjectID}); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to revoke user sessions"})
+			return
+		}
+	}
+
+	recordAudit(c, models.AuditActionUpdate, "user", user.ID, before, user)
+
 	c.JSON(http.StatusOK, user.ToResponse())
 }
 
 // DeleteUser godoc
 // @Summary Delete user
-// @Description Delete a user account (Admin cannot delete other admins or managers)
+// @Description Delete a user account (Admin cannot delete other admins or managers, Manager can delete staff only)
 // @Tags users
 // @Accept json
 // @Produce json
@@ -431,7 +444,7 @@ func DeleteUser(c *gin.Context) {
 	}
 
 	// Get current user from context (set by auth middleware)
-	currentUserID, exists := c.Get("userID")
+	currentUserID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Unauthorized"})
 		return
@@ -456,9 +469,9 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
-	// Manager cannot delete admins
-	if currentUser.Role == models.RoleManager && user.Role == models.RoleAdmin {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "Manager cannot delete admin"})
+	// Manager can only delete staff members
+	if currentUser.Role == models.RoleManager && user.Role != models.RoleStaff {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "Manager can only delete staff accounts"})
 		return
 	}
 
@@ -468,5 +481,12 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditActionDelete, "user", user.ID, user, nil)
+
+	if err := revokeRefreshTokens(ctx, bson.M{"user_id": userObjectID}); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to revoke user sessions"})
+		return
+	}
+
 	c.JSON(http.StatusNoContent, nil)
 }
@@ -0,0 +1,206 @@
+// Package inventory keeps ingredient stock in step with orders. Products with a
+// recipe are made from ingredients; how many portions can be made and what a
+// portion costs are worked out from the ingredients on hand.
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrInsufficientIngredients is returned when there is not enough of an
+// ingredient on hand for an order
+var ErrInsufficientIngredients = errors.New("insufficient ingredients")
+
+// epsilon absorbs floating point noise when comparing quantities
+const epsilon = 1e-9
+
+// Load returns the ingredients with the given IDs by ID. Unknown IDs are left out.
+func Load(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]models.Ingredient, error) {
+	ingredients := make(map[primitive.ObjectID]models.Ingredient)
+	if len(ids) == 0 {
+		return ingredients, nil
+	}
+
+	cursor, err := database.DB.Collection("ingredients").Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	var found []models.Ingredient
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	for _, ingredient := range found {
+		ingredients[ingredient.ID] = ingredient
+	}
+	return ingredients, nil
+}
+
+// IngredientIDs returns the ingredients used by the recipes, without duplicates
+func IngredientIDs(recipes ...[]models.RecipeItem) []primitive.ObjectID {
+	seen := make(map[primitive.ObjectID]bool)
+	var ids []primitive.ObjectID
+	for _, recipe := range recipes {
+		for _, item := range recipe {
+			if !seen[item.IngredientID] {
+				seen[item.IngredientID] = true
+				ids = append(ids, item.IngredientID)
+			}
+		}
+	}
+	return ids
+}
+
+// Portions returns how many portions of a recipe the ingredients on hand allow.
+// A recipe with a missing ingredient cannot be made.
+func Portions(recipe []models.RecipeItem, ingredients map[primitive.ObjectID]models.Ingredient) int {
+	portions := -1
+	for _, item := range recipe {
+		ingredient, ok := ingredients[item.IngredientID]
+		if !ok || item.Quantity <= 0 {
+			return 0
+		}
+		n := int(math.Floor(ingredient.OnHand/item.Quantity + epsilon))
+		if portions < 0 || n < portions {
+			portions = n
+		}
+	}
+	if portions < 0 {
+		return 0
+	}
+	return portions
+}
+
+// Cost returns what the ingredients of one portion of a recipe cost, with the
+// cost of each ingredient
+func Cost(recipe []models.RecipeItem, ingredients map[primitive.ObjectID]models.Ingredient) (models.Money, []models.RecipeLine) {
+	var total models.Money
+	lines := make([]models.RecipeLine, 0, len(recipe))
+	for _, item := range recipe {
+		line := models.RecipeLine{IngredientID: item.IngredientID.Hex(), Quantity: item.Quantity}
+		if ingredient, ok := ingredients[item.IngredientID]; ok {
+			line.Name = ingredient.Name
+			line.Unit = ingredient.Unit
+			line.Cost = models.Money(math.Round(item.Quantity * float64(ingredient.CostPerUnit)))
+		}
+		total += line.Cost
+		lines = append(lines, line)
+	}
+	return total, lines
+}
+
+// Usage returns the ingredients an order uses: the recipes of its items that are
+// not voided times their quantities, once the order is confirmed. Pending and
+// cancelled orders use none.
+func Usage(order *models.Order) map[primitive.ObjectID]float64 {
+	usage := make(map[primitive.ObjectID]float64)
+	if order.Status == models.OrderStatusPending || order.Status == models.OrderStatusCancelled {
+		return usage
+	}
+	for _, item := range order.Items {
+		if item.Voided {
+			continue
+		}
+		for _, recipeItem := range item.Recipe {
+			usage[recipeItem.IngredientID] += recipeItem.Quantity * float64(item.Quantity)
+		}
+	}
+	return usage
+}
+
+// Sync deducts the ingredients an order uses from stock, or returns them, for
+// the difference between what the order uses now and what was already deducted
+// for it. It fails with ErrInsufficientIngredients if an ingredient would drop
+// below zero. It must run in the transaction that changes the order, after the
+// order is saved, and is safe to run again.
+func Sync(ctx context.Context, order *models.Order) error {
+	orders := database.DB.Collection("orders")
+
+	// What was deducted is read back rather than taken from the order, which may
+	// have been changed by an earlier attempt of the transaction
+	var stored struct {
+		IngredientsUsed []models.RecipeItem `bson:"ingredients_used"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"ingredients_used": 1})
+	if err := orders.FindOne(ctx, bson.M{"_id": order.ID}, opts).Decode(&stored); err != nil {
+		return err
+	}
+	have := make(map[primitive.ObjectID]float64)
+	for _, used := range stored.IngredientsUsed {
+		have[used.IngredientID] += used.Quantity
+	}
+	want := Usage(order)
+
+	ids := make(map[primitive.ObjectID]bool)
+	for id := range want {
+		ids[id] = true
+	}
+	for id := range have {
+		ids[id] = true
+	}
+
+	ingredients := database.DB.Collection("ingredients")
+	now := time.Now()
+	changed := false
+	for id := range ids {
+		diff := want[id] - have[id]
+		if math.Abs(diff) < epsilon {
+			continue
+		}
+		changed = true
+
+		filter := bson.M{"_id": id}
+		if diff > 0 {
+			filter["on_hand"] = bson.M{"$gte": diff - epsilon}
+		}
+		update := bson.M{
+			"$inc": bson.M{"on_hand": -diff},
+			"$set": bson.M{"updated_at": now},
+		}
+		result, err := ingredients.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return err
+		}
+		// Ingredients deleted since are not given back
+		if result.MatchedCount == 0 && diff > 0 {
+			return shortage(ctx, id, diff)
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	used := make([]models.RecipeItem, 0, len(want))
+	for id, quantity := range want {
+		used = append(used, models.RecipeItem{IngredientID: id, Quantity: quantity})
+	}
+	sort.Slice(used, func(i, j int) bool { return used[i].IngredientID.Hex() < used[j].IngredientID.Hex() })
+	order.IngredientsUsed = used
+
+	_, err := orders.UpdateOne(ctx, bson.M{"_id": order.ID}, bson.M{"$set": bson.M{"ingredients_used": used}})
+	return err
+}
+
+// shortage explains why an ingredient could not be deducted
+func shortage(ctx context.Context, id primitive.ObjectID, needed float64) error {
+	var ingredient models.Ingredient
+	err := database.DB.Collection("ingredients").FindOne(ctx, bson.M{"_id": id}).Decode(&ingredient)
+	if err == mongo.ErrNoDocuments {
+		return fmt.Errorf("%w: ingredient %s no longer exists", ErrInsufficientIngredients, id.Hex())
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %g %s of %s needed, %g left", ErrInsufficientIngredients, needed, ingredient.Unit, ingredient.Name, ingredient.OnHand)
+}
@@ -0,0 +1,169 @@
+// Package kitchen keeps the feed of order events shown on kitchen displays.
+// Events are stored for replay and pushed to the displays connected to this
+// server as they happen.
+package kitchen
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// EventRetention is how long events are kept for displays to catch up on
+	EventRetention = 24 * time.Hour
+
+	// ReplayLimit is the most events replayed to a reconnecting display
+	ReplayLimit = 500
+
+	// subscriberBuffer is how many events a display may fall behind before it is
+	// disconnected; it then reconnects and replays what it missed
+	subscriberBuffer = 64
+
+	// publishQueue is how many events may wait to be stored before Publish
+	// starts dropping them
+	publishQueue = 256
+
+	// storeTimeout bounds the database work of storing one event
+	storeTimeout = 10 * time.Second
+)
+
+// Subscription receives the events published after it was created. Events is
+// closed when the subscriber falls too far behind.
+type Subscription struct {
+	Events <-chan models.KitchenEvent
+	events chan models.KitchenEvent
+}
+
+// pendingEvent is an event waiting to be numbered and stored
+type pendingEvent struct {
+	eventType models.KitchenEventType
+	order     models.Order
+	createdAt time.Time
+}
+
+// ErrBacklogged is returned by Publish when too many events are waiting to be
+// stored, e.g. while the database is unreachable
+var ErrBacklogged = errors.New("kitchen feed is backlogged")
+
+var (
+	mu          sync.Mutex
+	subscribers = make(map[*Subscription]bool)
+
+	queue     = make(chan pendingEvent, publishQueue)
+	startOnce sync.Once
+)
+
+// Publish queues an event for an order to be numbered, stored and pushed to
+// every subscriber. It does not wait for the database: events are stored one
+// at a time in the background, so subscribers receive them in sequence order.
+func Publish(eventType models.KitchenEventType, order *models.Order) error {
+	startOnce.Do(func() { go publishQueued() })
+
+	select {
+	case queue <- pendingEvent{eventType: eventType, order: *order, createdAt: time.Now()}:
+		return nil
+	default:
+		return ErrBacklogged
+	}
+}
+
+// publishQueued stores and fans out queued events until the process exits
+func publishQueued() {
+	for pending := range queue {
+		event, err := store(pending)
+		if err != nil {
+			log.Printf("Failed to store %s for order %s: %v", pending.eventType, pending.order.ID.Hex(), err)
+			continue
+		}
+		fanOut(event)
+	}
+}
+
+// store numbers an event and saves it for replay
+func store(pending pendingEvent) (models.KitchenEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	seq, err := database.NextPermanentSequence(ctx, "kitchen_events")
+	if err != nil {
+		return models.KitchenEvent{}, err
+	}
+
+	event := models.KitchenEvent{
+		Seq:       seq,
+		Type:      pending.eventType,
+		OrderID:   pending.order.ID,
+		Stations:  models.OrderStations(&pending.order),
+		Order:     pending.order,
+		CreatedAt: pending.createdAt,
+		ExpiresAt: pending.createdAt.Add(EventRetention),
+	}
+	if _, err := database.DB.Collection("kitchen_events").InsertOne(ctx, event); err != nil {
+		return models.KitchenEvent{}, err
+	}
+	return event, nil
+}
+
+// fanOut pushes an event to every subscriber, dropping those too far behind
+func fanOut(event models.KitchenEvent) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for sub := range subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			delete(subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// Subscribe starts receiving published events
+func Subscribe() *Subscription {
+	events := make(chan models.KitchenEvent, subscriberBuffer)
+	sub := &Subscription{Events: events, events: events}
+
+	mu.Lock()
+	subscribers[sub] = true
+	mu.Unlock()
+	return sub
+}
+
+// Unsubscribe stops a subscription
+func Unsubscribe(sub *Subscription) {
+	mu.Lock()
+	defer mu.Unlock()
+	if subscribers[sub] {
+		delete(subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// EventsAfter returns the stored events after the given sequence number, oldest
+// first, limited to station when it is not empty
+func EventsAfter(ctx context.Context, seq int64, station string) ([]models.KitchenEvent, error) {
+	filter := bson.M{"seq": bson.M{"$gt": seq}}
+	if station != "" {
+		filter["stations"] = station
+	}
+	opts := options.Find().SetSort(bson.M{"seq": 1}).SetLimit(ReplayLimit)
+
+	cursor, err := database.DB.Collection("kitchen_events").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var events []models.KitchenEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,234 @@
+// Package loyalty keeps the points customers earn on paid orders and spend on
+// rewards. Every change to a balance is an entry in the loyalty ledger, and the
+// balance on the customer is kept in step with it.
+package loyalty
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"vibanda-village-admin-backend/internal/config"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PointsFor returns the points earned on an amount at an earn rate in basis
+// points per currency unit (10000 = 1 point per unit). Part points are dropped.
+func PointsFor(amount models.Money, rate int64) int64 {
+	if amount <= 0 || rate <= 0 {
+		return 0
+	}
+	return int64(amount) * rate / (100 * 10000)
+}
+
+// Tiers returns the configured tiers from the lowest to the highest
+func Tiers(cfg *config.Config) []models.LoyaltyTier {
+	tiers := []models.LoyaltyTier{}
+	for _, tier := range cfg.LoyaltyTiers {
+		tiers = append(tiers, models.LoyaltyTier{Name: tier.Name, MinimumSpend: models.Money(tier.MinimumSpend)})
+	}
+	return tiers
+}
+
+// TierFor returns the highest tier reached with the spend, and the tier after it.
+// Either is nil when there is none.
+func TierFor(spend models.Money, tiers []models.LoyaltyTier) (current, next *models.LoyaltyTier) {
+	for i := range tiers {
+		if spend >= tiers[i].MinimumSpend {
+			current = &tiers[i]
+			continue
+		}
+		return current, &tiers[i]
+	}
+	return current, nil
+}
+
+// TierRank returns the position of a tier from the lowest, or -1 for an unknown tier
+func TierRank(name string, tiers []models.LoyaltyTier) int {
+	for i, tier := range tiers {
+		if tier.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// RollingSpend returns what the customer paid, less refunds, on orders placed
+// since the time that were not cancelled
+func RollingSpend(ctx context.Context, customerID primitive.ObjectID, since time.Time) (models.Money, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"customer_id": customerID,
+			"created_at":  bson.M{"$gte": since},
+			"status":      bson.M{"$ne": models.OrderStatusCancelled},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": nil,
+			"spend": bson.M{"$sum": bson.M{"$subtract": bson.A{
+				bson.M{"$ifNull": bson.A{"$amount_paid", 0}},
+				bson.M{"$ifNull": bson.A{"$amount_refunded", 0}},
+			}}},
+		}}},
+	}
+	cursor, err := database.DB.Collection("orders").Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	var rows []struct {
+		Spend models.Money `bson:"spend"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	return rows[0].Spend, nil
+}
+
+// orderPoints is what an order has earned and spent for a customer
+type orderPoints struct {
+	earned   int64
+	redeemed int64
+	rewardID primitive.ObjectID
+}
+
+// Sync brings the ledger in line with an order, adding entries for the
+// difference between what the order should have earned and spent and what the
+// ledger already holds for it. It must run in the transaction that changes the
+// order, and is safe to run again.
+//
+// An order earns points on what was paid for it once it is paid in full, and
+// keeps in step with what was paid after that, so refunds take points back. A
+// cancelled order, or one no longer linked to a customer, earns nothing and
+// gives back the points of its reward.
+func Sync(ctx context.Context, order *models.Order) error {
+	want := make(map[primitive.ObjectID]*orderPoints)
+	have, err := ledgerFor(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	if !order.CustomerID.IsZero() && order.Status != models.OrderStatusCancelled {
+		points := &orderPoints{}
+		earnedBefore := have[order.CustomerID] != nil && have[order.CustomerID].earned > 0
+		if order.PaymentStatus == models.PaymentStatusPaid || earnedBefore {
+			paid := order.NetPaid()
+			if paid > order.TotalAmount {
+				paid = order.TotalAmount
+			}
+			points.earned = PointsFor(paid, config.Load().LoyaltyEarnRate)
+		}
+		if order.LoyaltyReward != nil {
+			points.redeemed = order.LoyaltyReward.Points
+			points.rewardID = order.LoyaltyReward.RewardID
+		}
+		want[order.CustomerID] = points
+	}
+
+	now := time.Now()
+	for customerID := range union(want, have) {
+		target, current := want[customerID], have[customerID]
+		if target == nil {
+			target = &orderPoints{}
+		}
+		if current == nil {
+			current = &orderPoints{}
+		}
+
+		var entries []*models.LoyaltyEntry
+		if diff := target.earned - current.earned; diff > 0 {
+			entries = append(entries, entry(order, customerID, models.LoyaltyEntryEarn, diff, fmt.Sprintf("Earned on order %s", order.OrderNumber), now))
+		} else if diff < 0 {
+			entries = append(entries, entry(order, customerID, models.LoyaltyEntryReverse, diff, fmt.Sprintf("Taken back from order %s", order.OrderNumber), now))
+		}
+		if diff := target.redeemed - current.redeemed; diff > 0 {
+			redeem := entry(order, customerID, models.LoyaltyEntryRedeem, -diff, fmt.Sprintf("Redeemed %s on order %s", order.LoyaltyReward.Name, order.OrderNumber), now)
+			redeem.RewardID = target.rewardID
+			entries = append(entries, redeem)
+		} else if diff < 0 {
+			returned := entry(order, customerID, models.LoyaltyEntryReturn, -diff, fmt.Sprintf("Reward returned from order %s", order.OrderNumber), now)
+			returned.RewardID = current.rewardID
+			entries = append(entries, returned)
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		var change int64
+		docs := make([]interface{}, 0, len(entries))
+		for _, e := range entries {
+			change += e.Points
+			docs = append(docs, e)
+		}
+		if _, err := database.DB.Collection("loyalty_entries").InsertMany(ctx, docs); err != nil {
+			return err
+		}
+		update := bson.M{"$inc": bson.M{"loyalty_points": change}, "$set": bson.M{"updated_at": now}}
+		if _, err := database.DB.Collection("customers").UpdateOne(ctx, bson.M{"_id": customerID}, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ledgerFor sums the ledger entries of an order per customer
+func ledgerFor(ctx context.Context, orderID primitive.ObjectID) (map[primitive.ObjectID]*orderPoints, error) {
+	cursor, err := database.DB.Collection("loyalty_entries").Find(ctx, bson.M{"order_id": orderID})
+	if err != nil {
+		return nil, err
+	}
+	var entries []models.LoyaltyEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	have := make(map[primitive.ObjectID]*orderPoints)
+	for _, e := range entries {
+		points := have[e.CustomerID]
+		if points == nil {
+			points = &orderPoints{}
+			have[e.CustomerID] = points
+		}
+		switch e.Type {
+		case models.LoyaltyEntryEarn, models.LoyaltyEntryReverse:
+			points.earned += e.Points
+		case models.LoyaltyEntryRedeem, models.LoyaltyEntryReturn:
+			points.redeemed -= e.Points
+			if !e.RewardID.IsZero() {
+				points.rewardID = e.RewardID
+			}
+		}
+	}
+	return have, nil
+}
+
+// entry returns a ledger entry for an order
+func entry(order *models.Order, customerID primitive.ObjectID, entryType models.LoyaltyEntryType, points int64, description string, now time.Time) *models.LoyaltyEntry {
+	return &models.LoyaltyEntry{
+		ID:          primitive.NewObjectID(),
+		CustomerID:  customerID,
+		OrderID:     order.ID,
+		OrderNumber: order.OrderNumber,
+		Type:        entryType,
+		Points:      points,
+		Description: description,
+		CreatedAt:   now,
+	}
+}
+
+// union returns the customers in either map
+func union(a, b map[primitive.ObjectID]*orderPoints) map[primitive.ObjectID]bool {
+	ids := make(map[primitive.ObjectID]bool)
+	for id := range a {
+		ids[id] = true
+	}
+	for id := range b {
+		ids[id] = true
+	}
+	return ids
+}
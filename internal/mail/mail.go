@@ -0,0 +1,94 @@
+// Package mail sends email through the SMTP server in the SMTP_* settings.
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+	"vibanda-village-admin-backend/internal/config"
+	"vibanda-village-admin-backend/pkg/utils"
+)
+
+// ErrNotConfigured is returned when no SMTP server has been set up
+var ErrNotConfigured = errors.New("email is not configured")
+
+// Attachment is a file attached to an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Configured reports whether email can be sent
+func Configured(cfg *config.Config) bool {
+	return cfg.SMTPHost != "" && cfg.SMTPFrom != ""
+}
+
+// Send emails a plain text message with optional attachments to a single recipient
+func Send(cfg *config.Config, to, subject, body string, attachments ...Attachment) error {
+	if !Configured(cfg) {
+		return ErrNotConfigured
+	}
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid email address %q", to)
+	}
+
+	message, err := buildMessage(cfg.SMTPFrom, to, subject, body, attachments)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+	addr := cfg.SMTPHost + ":" + strconv.Itoa(cfg.SMTPPort)
+	return smtp.SendMail(addr, auth, cfg.SMTPFrom, []string{to}, message)
+}
+
+// buildMessage encodes a multipart/mixed MIME message
+func buildMessage(from, to, subject, body string, attachments []Attachment) ([]byte, error) {
+	boundary, err := utils.GenerateCode(24)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", boundary)
+
+	fmt.Fprintf(&b, "--%s\r\n", boundary)
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	writeBase64(&b, []byte(body))
+
+	for _, attachment := range attachments {
+		fmt.Fprintf(&b, "--%s\r\n", boundary)
+		fmt.Fprintf(&b, "Content-Type: %s\r\n", attachment.ContentType)
+		b.WriteString("Content-Transfer-Encoding: base64\r\n")
+		fmt.Fprintf(&b, "Content-Disposition: attachment; filename=%q\r\n\r\n", attachment.Filename)
+		writeBase64(&b, attachment.Data)
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+	return b.Bytes(), nil
+}
+
+// writeBase64 writes data base64 encoded in lines of 76 characters
+func writeBase64(b *bytes.Buffer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+}
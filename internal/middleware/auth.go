@@ -1,15 +1,61 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 	"vibanda-village-admin-backend/internal/config"
+	"vibanda-village-admin-backend/internal/database"
 	"vibanda-village-admin-backend/internal/models"
 	"vibanda-village-admin-backend/pkg/utils"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var (
+	// ErrInvalidToken is returned for malformed, expired or revoked tokens
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrInactiveAccount is returned when the token's user is inactive or deleted
+	ErrInactiveAccount = errors.New("account is inactive or no longer exists")
+)
+
+// Authenticate validates an access token and loads its user. Tokens revoked by
+// logout and tokens of users deactivated or deleted since the token was issued
+// are rejected.
+func Authenticate(ctx context.Context, tokenString string) (*utils.Claims, *models.User, error) {
+	cfg := config.Load()
+
+	claims, err := utils.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil || claims.ID == "" {
+		return nil, nil, ErrInvalidToken
+	}
+
+	// Reject tokens revoked by logout
+	revoked, err := database.DB.Collection("revoked_tokens").CountDocuments(ctx, bson.M{"_id": claims.ID})
+	if err != nil {
+		return nil, nil, err
+	}
+	if revoked > 0 {
+		return nil, nil, ErrInvalidToken
+	}
+
+	// Reject tokens of users deactivated or deleted since the token was issued
+	userObjectID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	var user models.User
+	err = database.DB.Collection("users").FindOne(ctx, bson.M{"_id": userObjectID}).Decode(&user)
+	if err != nil || user.Status != models.StatusActive {
+		return nil, nil, ErrInactiveAccount
+	}
+
+	return claims, &user, nil
+}
+
 // AuthMiddleware validates JWT tokens and sets user context
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -35,27 +81,39 @@ func AuthMiddleware() gin.HandlerFunc {
 			// Treat the entire header as the token
 			tokenString = authHeader
 		}
-		cfg := config.Load()
 
-		claims, err := utils.ValidateToken(tokenString, cfg.JWTSecret)
-		if err != nil {
+		claims, user, err := Authenticate(context.Background(), tokenString)
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrInvalidToken):
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
 			return
+		case errors.Is(err, ErrInactiveAccount):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Account is inactive or no longer exists"})
+			c.Abort()
+			return
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate token"})
+			c.Abort()
+			return
 		}
 
-		// Set user information in context
+		// Set user information in context. The role comes from the database so that
+		// role changes take effect without waiting for the token to expire.
 		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("email", claims.Email)
-		c.Set("role", claims.Role)
+		c.Set("username", user.Username)
+		c.Set("email", user.Email)
+		c.Set("role", user.Role)
+		c.Set("jti", claims.ID)
+		c.Set("token_expires_at", claims.ExpiresAt.Time)
 
 		c.Next()
 	}
 }
 
-// RoleMiddleware checks if user has required role
-func RoleMiddleware(requiredRoles ...models.UserRole) gin.HandlerFunc {
+// RequirePermission checks if the user's role grants the required permission
+func RequirePermission(permission models.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("role")
 		if !exists {
@@ -65,16 +123,7 @@ func RoleMiddleware(requiredRoles ...models.UserRole) gin.HandlerFunc {
 		}
 
 		role := userRole.(models.UserRole)
-		hasPermission := false
-
-		for _, requiredRole := range requiredRoles {
-			if role == requiredRole {
-				hasPermission = true
-				break
-			}
-		}
-
-		if !hasPermission {
+		if !role.HasPermission(permission) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 			c.Abort()
 			return
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"time"
+	"vibanda-village-admin-backend/internal/database"
+	"vibanda-village-admin-backend/internal/models"
+	"vibanda-village-admin-backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxIdempotencyKeyLength bounds the Idempotency-Key header value
+const maxIdempotencyKeyLength = 255
+
+// responseRecorder copies everything written to the response so it can be stored
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware makes a route safe to retry. When a request carries an
+// Idempotency-Key header, the first response for that key is stored for ttl and
+// replayed for any repeat of the request. Reusing a key with a different body is
+// rejected with 422. Requests without the header are passed through unchanged.
+// Keys are scoped to the authenticated user and request path, so it must run
+// after AuthMiddleware.
+func IdempotencyMiddleware(ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID := c.GetString("user_id")
+		now := time.Now()
+		record := models.IdempotencyRecord{
+			ID:          utils.HashToken(userID + " " + c.Request.Method + " " + c.Request.URL.Path + " " + key),
+			Key:         key,
+			UserID:      userID,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			RequestHash: utils.HashToken(string(body)),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}
+
+		collection := database.DB.Collection("idempotency_keys")
+		ctx := context.Background()
+
+		// Claim the key; if it already exists this is a retry
+		_, err = collection.InsertOne(ctx, record)
+		if mongo.IsDuplicateKeyError(err) {
+			var stored models.IdempotencyRecord
+			if err := collection.FindOne(ctx, bson.M{"_id": record.ID}).Decode(&stored); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check idempotency key"})
+				c.Abort()
+				return
+			}
+			if stored.RequestHash != record.RequestHash {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request body"})
+				c.Abort()
+				return
+			}
+			if stored.StatusCode == 0 {
+				c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+				c.Abort()
+				return
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(stored.StatusCode, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check idempotency key"})
+			c.Abort()
+			return
+		}
+
+		// Unless a response is stored below, the key is released so the client can
+		// retry with it, also when the handler panics
+		stored := false
+		defer func() {
+			if stored {
+				return
+			}
+			if _, err := collection.DeleteOne(ctx, bson.M{"_id": record.ID}); err != nil {
+				log.Printf("Failed to release idempotency key %s: %v", record.ID, err)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Server errors are not stored so the client can retry with the same key
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		update := bson.M{"$set": bson.M{
+			"status_code":  status,
+			"content_type": recorder.Header().Get("Content-Type"),
+			"body":         recorder.body.Bytes(),
+		}}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": record.ID}, update); err != nil {
+			log.Printf("Failed to store response for idempotency key %s: %v", record.ID, err)
+			return
+		}
+		stored = true
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateWindow tracks the requests a client made in the current window
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimitMiddleware allows each client IP at most limit requests per window
+func RateLimitMiddleware(limit int, window time.Duration) gin.HandlerFunc {
+	var mu sync.Mutex
+	clients := map[string]*rateWindow{}
+	lastSweep := time.Now()
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		ip := c.ClientIP()
+
+		mu.Lock()
+		// Drop expired windows so the map does not grow without bound
+		if now.Sub(lastSweep) > window {
+			for key, w := range clients {
+				if now.Sub(w.start) > window {
+					delete(clients, key)
+				}
+			}
+			lastSweep = now
+		}
+
+		w, exists := clients[ip]
+		if !exists || now.Sub(w.start) > window {
+			w = &rateWindow{start: now}
+			clients[ip] = w
+		}
+		w.count++
+		count, retryAfter := w.count, w.start.Add(window).Sub(now)
+		mu.Unlock()
+
+		if count > limit {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, please try again later"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditAction represents the kind of change recorded in the audit log
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "create"
+	AuditActionUpdate AuditAction = "update"
+	AuditActionDelete AuditAction = "delete"
+	AuditActionLogin  AuditAction = "login"
+)
+
+// FieldChange holds the value of a field before and after a change
+type FieldChange struct {
+	From interface{} `json:"from" bson:"from"`
+	To   interface{} `json:"to" bson:"to"`
+}
+
+// AuditLog records a single mutation made through the API and who made it
+type AuditLog struct {
+	ID         primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	UserID     primitive.ObjectID     `json:"user_id" bson:"user_id"`
+	Action     AuditAction            `json:"action" bson:"action"`
+	EntityType string                 `json:"entity_type" bson:"entity_type"`
+	EntityID   primitive.ObjectID     `json:"entity_id" bson:"entity_id"`
+	Changes    map[string]FieldChange `json:"changes,omitempty" bson:"changes,omitempty"`
+	IPAddress  string                 `json:"ip_address,omitempty" bson:"ip_address,omitempty"`
+	UserAgent  string                 `json:"user_agent,omitempty" bson:"user_agent,omitempty"`
+	CreatedAt  time.Time              `json:"created_at" bson:"created_at"`
+}
+
+// AuditLogResponse represents audit log data returned to the client
+type AuditLogResponse struct {
+	ID          string                 `json:"id"`
+	UserID      string                 `json:"user_id"`
+	Action      AuditAction            `json:"action"`
+	EntityType  string                 `json:"entity_type"`
+	EntityID    string                 `json:"entity_id"`
+	Description string                 `json:"description"`
+	Changes     map[string]FieldChange `json:"changes,omitempty"`
+	IPAddress   string                 `json:"ip_address,omitempty"`
+	UserAgent   string                 `json:"user_agent,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+}
+
+// Description returns a short human readable summary of the entry
+func (a *AuditLog) Description() string {
+	switch a.Action {
+	case AuditActionLogin:
+		return "Logged in"
+	case AuditActionCreate:
+		return "Created " + a.EntityType
+	case AuditActionUpdate:
+		return "Updated " + a.EntityType
+	case AuditActionDelete:
+		return "Deleted " + a.EntityType
+	default:
+		return string(a.Action) + " " + a.EntityType
+	}
+}
+
+// ToResponse converts AuditLog to AuditLogResponse
+func (a *AuditLog) ToResponse() AuditLogResponse {
+	return AuditLogResponse{
+		ID:          a.ID.Hex(),
+		UserID:      a.UserID.Hex(),
+		Action:      a.Action,
+		EntityType:  a.EntityType,
+		EntityID:    a.EntityID.Hex(),
+		Description: a.Description(),
+		Changes:     normalizeChanges(a.Changes),
+		IPAddress:   a.IPAddress,
+		UserAgent:   a.UserAgent,
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+// ToActivity converts AuditLog to a ProfileActivity entry
+func (a *AuditLog) ToActivity() ProfileActivity {
+	return ProfileActivity{
+		ID:          a.ID.Hex(),
+		Description: a.Description(),
+		Timestamp:   a.CreatedAt,
+	}
+}
+
+// normalizeChanges converts nested BSON documents read back from the database
+// into plain maps and slices so they encode as regular JSON
+func normalizeChanges(changes map[string]FieldChange) map[string]FieldChange {
+	if changes == nil {
+		return nil
+	}
+	normalized := make(map[string]FieldChange, len(changes))
+	for field, change := range changes {
+		normalized[field] = FieldChange{From: normalizeValue(change.From), To: normalizeValue(change.To)}
+	}
+	return normalized
+}
+
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case primitive.D:
+		m := make(map[string]interface{}, len(v))
+		for _, elem := range v {
+			m[elem.Key] = normalizeValue(elem.Value)
+		}
+		return m
+	case primitive.M:
+		m := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			m[key] = normalizeValue(elem)
+		}
+		return m
+	case primitive.A:
+		a := make([]interface{}, len(v))
+		for i, elem := range v {
+			a[i] = normalizeValue(elem)
+		}
+		return a
+	default:
+		return v
+	}
+}
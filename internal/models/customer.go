@@ -0,0 +1,132 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+)
+
+// Customer is a guest known from their orders and reservations. Phones and
+// emails are kept normalised, so that the same guest is recognised however their
+// details were typed; the first of each is the one shown. Points is the loyalty
+// balance, kept in step with the loyalty ledger.
+type Customer struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty" gorm:"type:objectid;primaryKey;autoIncrement:false"`
+	Name       string             `json:"name" bson:"name" validate:"max=100"`
+	Phones     []string           `json:"phones" bson:"phones"`
+	Emails     []string           `json:"emails" bson:"emails"`
+	Notes      string             `json:"notes,omitempty" bson:"notes,omitempty" validate:"max=2000"`
+	Tags       []string           `json:"tags" bson:"tags"`
+	Points     int64              `json:"loyalty_points" bson:"loyalty_points"`
+	LastSeenAt time.Time          `json:"last_seen_at" bson:"last_seen_at"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// BeforeCreate hook to set ID and timestamps
+func (c *Customer) BeforeCreate(tx *gorm.DB) error {
+	if c.ID.IsZero() {
+		c.ID = primitive.NewObjectID()
+	}
+	now := time.Now()
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	return nil
+}
+
+// BeforeUpdate hook to update timestamp
+func (c *Customer) BeforeUpdate(tx *gorm.DB) error {
+	c.UpdatedAt = time.Now()
+	return nil
+}
+
+// CustomerStats sums up a customer's history. Cancelled orders and reservations
+// are not visits; total spent is what the customer paid less refunds.
+type CustomerStats struct {
+	OrderCount       int        `json:"order_count"`
+	ReservationCount int        `json:"reservation_count"`
+	TotalSpent       Money      `json:"total_spent"`
+	LastVisitAt      *time.Time `json:"last_visit_at,omitempty"`
+}
+
+// CustomerResponse represents customer data returned to the client
+type CustomerResponse struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Phone  string   `json:"phone,omitempty"`
+	Email  string   `json:"email,omitempty"`
+	Phones []string `json:"phones"`
+	Emails []string `json:"emails"`
+	Notes  string   `json:"notes,omitempty"`
+	Tags   []string `json:"tags"`
+	Points int64    `json:"loyalty_points"`
+	CustomerStats
+	LastSeenAt time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ToResponse converts Customer to CustomerResponse with the customer's stats
+func (c *Customer) ToResponse(stats CustomerStats) CustomerResponse {
+	response := CustomerResponse{
+		ID:            c.ID.Hex(),
+		Name:          c.Name,
+		Phones:        c.Phones,
+		Emails:        c.Emails,
+		Notes:         c.Notes,
+		Tags:          c.Tags,
+		Points:        c.Points,
+		CustomerStats: stats,
+		LastSeenAt:    c.LastSeenAt,
+		CreatedAt:     c.CreatedAt,
+		UpdatedAt:     c.UpdatedAt,
+	}
+	if len(c.Phones) > 0 {
+		response.Phone = c.Phones[0]
+	}
+	if len(c.Emails) > 0 {
+		response.Email = c.Emails[0]
+	}
+	if response.Phones == nil {
+		response.Phones = []string{}
+	}
+	if response.Emails == nil {
+		response.Emails = []string{}
+	}
+	if response.Tags == nil {
+		response.Tags = []string{}
+	}
+	return response
+}
+
+// CreateCustomerRequest represents customer creation request payload. A phone
+// or an email is required.
+type CreateCustomerRequest struct {
+	Name  string   `json:"name" validate:"required,min=2,max=100"`
+	Phone string   `json:"phone,omitempty"`
+	Email string   `json:"email,omitempty" validate:"omitempty,email"`
+	Notes string   `json:"notes,omitempty" validate:"max=2000"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// UpdateCustomerRequest represents customer update request payload. Phones and
+// emails replace the customer's current ones, the first becoming the one shown.
+type UpdateCustomerRequest struct {
+	Name   string    `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+	Phones *[]string `json:"phones,omitempty"`
+	Emails *[]string `json:"emails,omitempty"`
+	Notes  *string   `json:"notes,omitempty" validate:"omitempty,max=2000"`
+	Tags   *[]string `json:"tags,omitempty"`
+}
+
+// MergeCustomersRequest represents a request to merge a duplicate customer into another
+type MergeCustomersRequest struct {
+	SourceID string `json:"source_id" validate:"required"`
+}
+
+// CustomerHistoryResponse lists a customer's orders and reservations, newest first
+type CustomerHistoryResponse struct {
+	Orders       []OrderResponse       `json:"orders"`
+	Reservations []ReservationResponse `json:"reservations"`
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+)
+
+// GeoPoint is a GeoJSON point. Coordinates are longitude then latitude.
+type GeoPoint struct {
+	Type        string    `json:"type" bson:"type"`
+	Coordinates []float64 `json:"coordinates" bson:"coordinates"`
+}
+
+// NewGeoPoint returns the point at a latitude and longitude
+func NewGeoPoint(latitude, longitude float64) GeoPoint {
+	return GeoPoint{Type: "Point", Coordinates: []float64{longitude, latitude}}
+}
+
+// IsValid reports whether the point is a GeoJSON point on the globe
+func (p GeoPoint) IsValid() bool {
+	return p.Type == "Point" && len(p.Coordinates) == 2 && validPosition(p.Coordinates)
+}
+
+// GeoPolygon is a GeoJSON polygon: an outer ring followed by any holes. Each
+// ring is a closed list of longitude, latitude positions.
+type GeoPolygon struct {
+	Type        string        `json:"type" bson:"type"`
+	Coordinates [][][]float64 `json:"coordinates" bson:"coordinates"`
+}
+
+// IsValid reports whether the polygon is a GeoJSON polygon whose rings have at
+// least four positions and end where they start
+func (p GeoPolygon) IsValid() bool {
+	if p.Type != "Polygon" || len(p.Coordinates) == 0 {
+		return false
+	}
+	for _, ring := range p.Coordinates {
+		if len(ring) < 4 {
+			return false
+		}
+		for _, position := range ring {
+			if len(position) != 2 || !validPosition(position) {
+				return false
+			}
+		}
+		first, last := ring[0], ring[len(ring)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// validPosition reports whether a longitude, latitude position is on the globe
+func validPosition(position []float64) bool {
+	return position[0] >= -180 && position[0] <= 180 && position[1] >= -90 && position[1] <= 90
+}
+
+// DeliveryZone is an area the restaurant delivers to, with what delivery there
+// costs. Where zones overlap, the one with the highest priority applies, so a
+// cheaper inner zone can be drawn inside a wider one.
+type DeliveryZone struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty" gorm:"type:objectid;primaryKey;autoIncrement:false"`
+	Name         string             `json:"name" bson:"name" gorm:"uniqueIndex;not null" validate:"required,min=2,max=50"`
+	Area         GeoPolygon         `json:"area" bson:"area" gorm:"not null" validate:"required"`
+	Fee          Money              `json:"fee" bson:"fee" validate:"min=0"`
+	MinimumOrder Money              `json:"minimum_order" bson:"minimum_order" validate:"min=0"`
+	EtaMinutes   int                `json:"eta_minutes" bson:"eta_minutes" validate:"min=0"`
+	Priority     int                `json:"priority" bson:"priority"`
+	Active       bool               `json:"active" bson:"active" gorm:"default:true"`
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// BeforeCreate hook to set ID and timestamps
+func (z *DeliveryZone) BeforeCreate(tx *gorm.DB) error {
+	if z.ID.IsZero() {
+		z.ID = primitive.NewObjectID()
+	}
+	now := time.Now()
+	z.CreatedAt = now
+	z.UpdatedAt = now
+	return nil
+}
+
+// BeforeUpdate hook to update timestamp
+func (z *DeliveryZone) BeforeUpdate(tx *gorm.DB) error {
+	z.UpdatedAt = time.Now()
+	return nil
+}
+
+// DeliveryZoneResponse represents delivery zone data returned to the client
+type DeliveryZoneResponse struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Area         GeoPolygon `json:"area"`
+	Fee          Money      `json:"fee"`
+	MinimumOrder Money      `json:"minimum_order"`
+	EtaMinutes   int        `json:"eta_minutes"`
+	Priority     int        `json:"priority"`
+	Active       bool       `json:"active"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// ToResponse converts DeliveryZone to DeliveryZoneResponse
+func (z *DeliveryZone) ToResponse() DeliveryZoneResponse {
+	return DeliveryZoneResponse{
+		ID:           z.ID.Hex(),
+		Name:         z.Name,
+		Area:         z.Area,
+		Fee:          z.Fee,
+		MinimumOrder: z.MinimumOrder,
+		EtaMinutes:   z.EtaMinutes,
+		Priority:     z.Priority,
+		Active:       z.Active,
+		CreatedAt:    z.CreatedAt,
+		UpdatedAt:    z.UpdatedAt,
+	}
+}
+
+// CreateDeliveryZoneRequest represents delivery zone creation request payload
+type CreateDeliveryZoneRequest struct {
+	Name         string     `json:"name" validate:"required,min=2,max=50"`
+	Area         GeoPolygon `json:"area" validate:"required"`
+	Fee          Money      `json:"fee" validate:"min=0"`
+	MinimumOrder Money      `json:"minimum_order" validate:"min=0"`
+	EtaMinutes   int        `json:"eta_minutes" validate:"min=0"`
+	Priority     int        `json:"priority"`
+	Active       *bool      `json:"active,omitempty"`
+}
+
+// UpdateDeliveryZoneRequest represents delivery zone update request payload
+type UpdateDeliveryZoneRequest struct {
+	Name         string      `json:"name,omitempty" validate:"omitempty,min=2,max=50"`
+	Area         *GeoPolygon `json:"area,omitempty"`
+	Fee          *Money      `json:"fee,omitempty" validate:"omitempty,min=0"`
+	MinimumOrder *Money      `json:"minimum_order,omitempty" validate:"omitempty,min=0"`
+	EtaMinutes   *int        `json:"eta_minutes,omitempty" validate:"omitempty,min=0"`
+	Priority     *int        `json:"priority,omitempty"`
+	Active       *bool       `json:"active,omitempty"`
+}
+
+// AppliedDeliveryZone is the delivery zone an order was placed in, with the
+// terms that applied at the time
+type AppliedDeliveryZone struct {
+	ZoneID       primitive.ObjectID `json:"zone_id" bson:"zone_id"`
+	Name         string             `json:"name" bson:"name"`
+	MinimumOrder Money              `json:"minimum_order" bson:"minimum_order"`
+	EtaMinutes   int                `json:"eta_minutes" bson:"eta_minutes"`
+}
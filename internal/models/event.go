@@ -16,10 +16,11 @@ type Event struct {
 	Time             string             `json:"time,omitempty" bson:"time,omitempty"`
 	Location         string             `json:"location" bson:"location" gorm:"not null" validate:"required,max=200"`
 	Capacity         int                `json:"capacity" bson:"capacity" gorm:"not null" validate:"required,min=1"`
-	Price            float64            `json:"price,omitempty" bson:"price,omitempty"`
+	Price            Money              `json:"price,omitempty" bson:"price,omitempty"`
 	Category         string             `json:"category,omitempty" bson:"category,omitempty"`
 	Organizer        string             `json:"organizer,omitempty" bson:"organizer,omitempty"`
 	TicketsAvailable bool               `json:"tickets_available" bson:"tickets_available" gorm:"default:true"`
+	TicketsSold      int                `json:"tickets_sold" bson:"tickets_sold" gorm:"default:0"`
 	Featured         bool               `json:"featured" bson:"featured" gorm:"default:false"`
 	Published        bool               `json:"published" bson:"published" gorm:"default:false"`
 	ImageURL         string             `json:"image_url,omitempty" bson:"image_url,omitempty"`
@@ -52,10 +53,11 @@ type EventResponse struct {
 	Time             string    `json:"time"`
 	Location         string    `json:"location"`
 	Capacity         int       `json:"capacity"`
-	Price            float64   `json:"price,omitempty"`
+	Price            Money     `json:"price,omitempty"`
 	Category         string    `json:"category,omitempty"`
 	Organizer        string    `json:"organizer,omitempty"`
 	TicketsAvailable bool      `json:"tickets_available"`
+	TicketsSold      int       `json:"tickets_sold"`
 	Featured         bool      `json:"featured"`
 	Published        bool      `json:"published"`
 	ImageURL         string    `json:"image_url,omitempty"`
@@ -77,6 +79,7 @@ func (e *Event) ToResponse() EventResponse {
 		Category:         e.Category,
 		Organizer:        e.Organizer,
 		TicketsAvailable: e.TicketsAvailable,
+		TicketsSold:      e.TicketsSold,
 		Featured:         e.Featured,
 		Published:        e.Published,
 		ImageURL:         e.ImageURL,
@@ -87,34 +90,34 @@ func (e *Event) ToResponse() EventResponse {
 
 // CreateEventRequest represents event creation request payload
 type CreateEventRequest struct {
-	Title            string  `json:"title" validate:"required,min=3,max=200"`
-	Description      string  `json:"description" validate:"required,max=1000"`
-	Date             string  `json:"date" validate:"required"`
-	Time             string  `json:"time,omitempty"`
-	Location         string  `json:"location" validate:"required,max=200"`
-	Capacity         int     `json:"capacity" validate:"required,min=1"`
-	Price            float64 `json:"price,omitempty"`
-	Category         string  `json:"category,omitempty"`
-	Organizer        string  `json:"organizer,omitempty"`
-	TicketsAvailable bool    `json:"tickets_available"`
-	Featured         bool    `json:"featured"`
-	Published        bool    `json:"published"`
-	ImageURL         string  `json:"image_url,omitempty"`
+	Title            string `json:"title" validate:"required,min=3,max=200"`
+	Description      string `json:"description" validate:"required,max=1000"`
+	Date             string `json:"date" validate:"required"`
+	Time             string `json:"time,omitempty"`
+	Location         string `json:"location" validate:"required,max=200"`
+	Capacity         int    `json:"capacity" validate:"required,min=1"`
+	Price            Money  `json:"price,omitempty"`
+	Category         string `json:"category,omitempty"`
+	Organizer        string `json:"organizer,omitempty"`
+	TicketsAvailable bool   `json:"tickets_available"`
+	Featured         bool   `json:"featured"`
+	Published        bool   `json:"published"`
+	ImageURL         string `json:"image_url,omitempty"`
 }
 
 // UpdateEventRequest represents event update request payload
 type UpdateEventRequest struct {
-	Title            string  `json:"title,omitempty" validate:"omitempty,min=3,max=200"`
-	Description      string  `json:"description,omitempty" validate:"omitempty,max=1000"`
-	Date             string  `json:"date,omitempty"`
-	Time             string  `json:"time,omitempty"`
-	Location         string  `json:"location,omitempty" validate:"omitempty,max=200"`
-	Capacity         int     `json:"capacity,omitempty" validate:"omitempty,min=1"`
-	Price            float64 `json:"price,omitempty"`
-	Category         string  `json:"category,omitempty"`
-	Organizer        string  `json:"organizer,omitempty"`
-	TicketsAvailable *bool   `json:"tickets_available,omitempty"`
-	Featured         *bool   `json:"featured,omitempty"`
-	Published        *bool   `json:"published,omitempty"`
-	ImageURL         string  `json:"image_url,omitempty"`
+	Title            string `json:"title,omitempty" validate:"omitempty,min=3,max=200"`
+	Description      string `json:"description,omitempty" validate:"omitempty,max=1000"`
+	Date             string `json:"date,omitempty"`
+	Time             string `json:"time,omitempty"`
+	Location         string `json:"location,omitempty" validate:"omitempty,max=200"`
+	Capacity         int    `json:"capacity,omitempty" validate:"omitempty,min=1"`
+	Price            Money  `json:"price,omitempty"`
+	Category         string `json:"category,omitempty"`
+	Organizer        string `json:"organizer,omitempty"`
+	TicketsAvailable *bool  `json:"tickets_available,omitempty"`
+	Featured         *bool  `json:"featured,omitempty"`
+	Published        *bool  `json:"published,omitempty"`
+	ImageURL         string `json:"image_url,omitempty"`
 }
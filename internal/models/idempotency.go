@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// IdempotencyRecord stores the outcome of a request made with an Idempotency-Key
+// header so that retries of the same request can be answered without repeating it.
+// A record without a StatusCode belongs to a request that is still in progress.
+type IdempotencyRecord struct {
+	ID          string    `json:"id" bson:"_id"`
+	Key         string    `json:"key" bson:"key"`
+	UserID      string    `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	Method      string    `json:"method" bson:"method"`
+	Path        string    `json:"path" bson:"path"`
+	RequestHash string    `json:"request_hash" bson:"request_hash"`
+	StatusCode  int       `json:"status_code,omitempty" bson:"status_code,omitempty"`
+	ContentType string    `json:"content_type,omitempty" bson:"content_type,omitempty"`
+	Body        []byte    `json:"-" bson:"body,omitempty"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at" bson:"expires_at"`
+}
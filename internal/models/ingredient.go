@@ -0,0 +1,133 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+)
+
+// UnitOfMeasure is the unit an ingredient is counted in
+type UnitOfMeasure string
+
+const (
+	UnitGram       UnitOfMeasure = "g"
+	UnitKilogram   UnitOfMeasure = "kg"
+	UnitMillilitre UnitOfMeasure = "ml"
+	UnitLitre      UnitOfMeasure = "l"
+	UnitPiece      UnitOfMeasure = "piece"
+)
+
+// IsValid reports whether the unit is one of the known units
+func (u UnitOfMeasure) IsValid() bool {
+	switch u {
+	case UnitGram, UnitKilogram, UnitMillilitre, UnitLitre, UnitPiece:
+		return true
+	}
+	return false
+}
+
+// Ingredient is a stock item dishes are made from. OnHand is counted in Unit,
+// and CostPerUnit is what one Unit costs in minor units.
+type Ingredient struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty" gorm:"type:objectid;primaryKey;autoIncrement:false"`
+	Name        string             `json:"name" bson:"name" gorm:"uniqueIndex;not null" validate:"required,min=2,max=100"`
+	Unit        UnitOfMeasure      `json:"unit" bson:"unit" gorm:"not null" validate:"required,oneof=g kg ml l piece"`
+	OnHand      float64            `json:"on_hand" bson:"on_hand" validate:"min=0"`
+	CostPerUnit Money              `json:"cost_per_unit" bson:"cost_per_unit" validate:"min=0"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// BeforeCreate hook to set ID and timestamps
+func (i *Ingredient) BeforeCreate(tx *gorm.DB) error {
+	if i.ID.IsZero() {
+		i.ID = primitive.NewObjectID()
+	}
+	now := time.Now()
+	i.CreatedAt = now
+	i.UpdatedAt = now
+	return nil
+}
+
+// BeforeUpdate hook to update timestamp
+func (i *Ingredient) BeforeUpdate(tx *gorm.DB) error {
+	i.UpdatedAt = time.Now()
+	return nil
+}
+
+// IngredientResponse represents ingredient data returned to the client
+type IngredientResponse struct {
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Unit        UnitOfMeasure `json:"unit"`
+	OnHand      float64       `json:"on_hand"`
+	CostPerUnit Money         `json:"cost_per_unit"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// ToResponse converts Ingredient to IngredientResponse
+func (i *Ingredient) ToResponse() IngredientResponse {
+	return IngredientResponse{
+		ID:          i.ID.Hex(),
+		Name:        i.Name,
+		Unit:        i.Unit,
+		OnHand:      i.OnHand,
+		CostPerUnit: i.CostPerUnit,
+		CreatedAt:   i.CreatedAt,
+		UpdatedAt:   i.UpdatedAt,
+	}
+}
+
+// CreateIngredientRequest represents ingredient creation request payload
+type CreateIngredientRequest struct {
+	Name        string        `json:"name" validate:"required,min=2,max=100"`
+	Unit        UnitOfMeasure `json:"unit" validate:"required,oneof=g kg ml l piece"`
+	OnHand      float64       `json:"on_hand" validate:"min=0"`
+	CostPerUnit Money         `json:"cost_per_unit" validate:"min=0"`
+}
+
+// UpdateIngredientRequest represents ingredient update request payload. Setting
+// on_hand replaces the count, as after a stocktake.
+type UpdateIngredientRequest struct {
+	Name        string        `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+	Unit        UnitOfMeasure `json:"unit,omitempty" validate:"omitempty,oneof=g kg ml l piece"`
+	OnHand      *float64      `json:"on_hand,omitempty" validate:"omitempty,min=0"`
+	CostPerUnit *Money        `json:"cost_per_unit,omitempty" validate:"omitempty,min=0"`
+}
+
+// AdjustIngredientRequest adds to or takes from an ingredient's count, e.g. for a
+// delivery or for waste
+type AdjustIngredientRequest struct {
+	Change float64 `json:"change" validate:"required"`
+}
+
+// RecipeItem is the quantity of an ingredient, in the ingredient's unit, that
+// goes into one portion of a product
+type RecipeItem struct {
+	IngredientID primitive.ObjectID `json:"ingredient_id" bson:"ingredient_id"`
+	Quantity     float64            `json:"quantity" bson:"quantity"`
+}
+
+// RecipeLine is a recipe item with the ingredient's name and unit and what the
+// quantity costs
+type RecipeLine struct {
+	IngredientID string        `json:"ingredient_id"`
+	Name         string        `json:"name,omitempty"`
+	Unit         UnitOfMeasure `json:"unit,omitempty"`
+	Quantity     float64       `json:"quantity"`
+	Cost         Money         `json:"cost"`
+}
+
+// RecipeItemRequest is one ingredient of a recipe in a request
+type RecipeItemRequest struct {
+	IngredientID string  `json:"ingredient_id" validate:"required"`
+	Quantity     float64 `json:"quantity" validate:"required,gt=0"`
+}
+
+// SetRecipeRequest replaces a product's recipe. An empty list removes it, and the
+// product goes back to its own stock count.
+type SetRecipeRequest struct {
+	Items []RecipeItemRequest `json:"items"`
+}
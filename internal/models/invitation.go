@@ -0,0 +1,101 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type InvitationStatus string
+
+const (
+	InvitationStatusPending InvitationStatus = "pending"
+	InvitationStatusUsed    InvitationStatus = "used"
+	InvitationStatusExpired InvitationStatus = "expired"
+	InvitationStatusRevoked InvitationStatus = "revoked"
+)
+
+// Invitation represents a single-use invite to register an account with a given role.
+// Only a hash of the invite token is stored.
+type Invitation struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Email     string             `json:"email" bson:"email" validate:"required,email"`
+	Role      UserRole           `json:"role" bson:"role" validate:"required,oneof=manager staff"`
+	TokenHash string             `json:"-" bson:"token_hash"`
+	InvitedBy primitive.ObjectID `json:"invited_by" bson:"invited_by"`
+	ExpiresAt time.Time          `json:"expires_at" bson:"expires_at"`
+	UsedAt    *time.Time         `json:"used_at,omitempty" bson:"used_at,omitempty"`
+	UsedBy    primitive.ObjectID `json:"used_by,omitempty" bson:"used_by,omitempty"`
+	RevokedAt *time.Time         `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// Status derives the invitation status from its timestamps
+func (i *Invitation) Status() InvitationStatus {
+	switch {
+	case i.UsedAt != nil:
+		return InvitationStatusUsed
+	case i.RevokedAt != nil:
+		return InvitationStatusRevoked
+	case time.Now().After(i.ExpiresAt):
+		return InvitationStatusExpired
+	default:
+		return InvitationStatusPending
+	}
+}
+
+// InvitationResponse represents invitation data returned to client.
+// Token is only set in the response to creating the invitation.
+type InvitationResponse struct {
+	ID        string           `json:"id"`
+	Email     string           `json:"email"`
+	Role      UserRole         `json:"role"`
+	Status    InvitationStatus `json:"status"`
+	InvitedBy string           `json:"invited_by"`
+	ExpiresAt time.Time        `json:"expires_at"`
+	UsedAt    *time.Time       `json:"used_at,omitempty"`
+	UsedBy    string           `json:"used_by,omitempty"`
+	Token     string           `json:"token,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// ToResponse converts Invitation to InvitationResponse
+func (i *Invitation) ToResponse() InvitationResponse {
+	response := InvitationResponse{
+		ID:        i.ID.Hex(),
+		Email:     i.Email,
+		Role:      i.Role,
+		Status:    i.Status(),
+		InvitedBy: i.InvitedBy.Hex(),
+		ExpiresAt: i.ExpiresAt,
+		UsedAt:    i.UsedAt,
+		CreatedAt: i.CreatedAt,
+		UpdatedAt: i.UpdatedAt,
+	}
+	if !i.UsedBy.IsZero() {
+		response.UsedBy = i.UsedBy.Hex()
+	}
+	return response
+}
+
+// CreateInvitationRequest represents invitation creation request payload
+type CreateInvitationRequest struct {
+	Email          string   `json:"email" validate:"required,email"`
+	Role           UserRole `json:"role" validate:"required,oneof=manager staff"`
+	ExpiresInHours int      `json:"expires_in_hours,omitempty" validate:"omitempty,min=1,max=720"`
+}
+
+// InvitedRegisterRequest represents self-registration with an invitation token.
+// The role is taken from the invitation.
+type InvitedRegisterRequest struct {
+	InviteToken string `json:"invite_token" validate:"required"`
+	Name        string `json:"name" validate:"required,min=2,max=100"`
+	Email       string `json:"email" validate:"required,email"`
+	Username    string `json:"username" validate:"required,min=3,max=50"`
+	Password    string `json:"password" validate:"required,min=6"`
+	Phone       string `json:"phone,omitempty"`
+	Department  string `json:"department,omitempty"`
+	Bio         string `json:"bio,omitempty"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// KitchenEventType represents what happened to an order on the kitchen feed
+type KitchenEventType string
+
+const (
+	KitchenEventOrderCreated       KitchenEventType = "order.created"
+	KitchenEventOrderUpdated       KitchenEventType = "order.updated"
+	KitchenEventOrderStatusChanged KitchenEventType = "order.status_changed"
+	KitchenEventOrderDeleted       KitchenEventType = "order.deleted"
+)
+
+// KitchenEvent is a change to an order pushed to kitchen displays. Events are
+// numbered in the order they happened so that a display can resume after the
+// last event it received.
+type KitchenEvent struct {
+	ID        primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	Seq       int64              `json:"seq" bson:"seq"`
+	Type      KitchenEventType   `json:"type" bson:"type"`
+	OrderID   primitive.ObjectID `json:"order_id" bson:"order_id"`
+	Stations  []string           `json:"stations" bson:"stations"`
+	Order     Order              `json:"order" bson:"order"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	ExpiresAt time.Time          `json:"-" bson:"expires_at"`
+}
+
+// KitchenEventResponse represents a kitchen event sent to a display
+type KitchenEventResponse struct {
+	Seq       int64            `json:"seq"`
+	Type      KitchenEventType `json:"type"`
+	Order     OrderResponse    `json:"order"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// OrderStations returns the stations that prepare the items of an order.
+// Voided items count, so that their station learns they were voided.
+func OrderStations(order *Order) []string {
+	stations := []string{}
+	seen := make(map[string]bool)
+	for _, item := range order.Items {
+		station := item.Station
+		if station == "" || seen[station] {
+			continue
+		}
+		seen[station] = true
+		stations = append(stations, station)
+	}
+	return stations
+}
+
+// ForStation returns the event as seen by a station, keeping only the items and
+// tickets it prepares. An empty station sees every item.
+func (e *KitchenEvent) ForStation(station string) KitchenEventResponse {
+	order := e.Order
+	if station != "" {
+		order.Items = nil
+		for _, item := range e.Order.Items {
+			if item.Station == station {
+				order.Items = append(order.Items, item)
+			}
+		}
+		order.Tickets = nil
+		for _, ticket := range e.Order.Tickets {
+			if ticket.Station == station {
+				order.Tickets = append(order.Tickets, ticket)
+			}
+		}
+	}
+
+	return KitchenEventResponse{
+		Seq:       e.Seq,
+		Type:      e.Type,
+		Order:     order.ToResponse(),
+		CreatedAt: e.CreatedAt,
+	}
+}
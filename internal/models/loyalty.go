@@ -0,0 +1,202 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+)
+
+// LoyaltyEntryType is the reason a customer's points balance changed
+type LoyaltyEntryType string
+
+const (
+	// LoyaltyEntryEarn adds the points earned on a paid order
+	LoyaltyEntryEarn LoyaltyEntryType = "earn"
+	// LoyaltyEntryReverse takes back earned points after a refund or cancellation
+	LoyaltyEntryReverse LoyaltyEntryType = "reverse"
+	// LoyaltyEntryRedeem spends points on a reward applied to an order
+	LoyaltyEntryRedeem LoyaltyEntryType = "redeem"
+	// LoyaltyEntryReturn gives back the points of a reward removed from an order
+	// or of a cancelled order
+	LoyaltyEntryReturn LoyaltyEntryType = "return"
+)
+
+// LoyaltyEntry is a change to a customer's points balance. Points are positive
+// when added and negative when taken away; the balance is their sum.
+type LoyaltyEntry struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	CustomerID  primitive.ObjectID `json:"customer_id" bson:"customer_id"`
+	OrderID     primitive.ObjectID `json:"order_id,omitempty" bson:"order_id,omitempty"`
+	OrderNumber string             `json:"order_number,omitempty" bson:"order_number,omitempty"`
+	RewardID    primitive.ObjectID `json:"reward_id,omitempty" bson:"reward_id,omitempty"`
+	Type        LoyaltyEntryType   `json:"type" bson:"type"`
+	Points      int64              `json:"points" bson:"points"`
+	Description string             `json:"description" bson:"description"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// LoyaltyEntryResponse represents loyalty ledger data returned to the client
+type LoyaltyEntryResponse struct {
+	ID          string           `json:"id"`
+	CustomerID  string           `json:"customer_id"`
+	OrderID     string           `json:"order_id,omitempty"`
+	OrderNumber string           `json:"order_number,omitempty"`
+	RewardID    string           `json:"reward_id,omitempty"`
+	Type        LoyaltyEntryType `json:"type"`
+	Points      int64            `json:"points"`
+	Description string           `json:"description"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// ToResponse converts LoyaltyEntry to LoyaltyEntryResponse
+func (e *LoyaltyEntry) ToResponse() LoyaltyEntryResponse {
+	response := LoyaltyEntryResponse{
+		ID:          e.ID.Hex(),
+		CustomerID:  e.CustomerID.Hex(),
+		OrderNumber: e.OrderNumber,
+		Type:        e.Type,
+		Points:      e.Points,
+		Description: e.Description,
+		CreatedAt:   e.CreatedAt,
+	}
+	if !e.OrderID.IsZero() {
+		response.OrderID = e.OrderID.Hex()
+	}
+	if !e.RewardID.IsZero() {
+		response.RewardID = e.RewardID.Hex()
+	}
+	return response
+}
+
+// LoyaltyReward is a reward in the catalogue, bought with points and given as
+// a discount on an order. Rewards with a minimum tier are only for customers
+// who have reached it.
+type LoyaltyReward struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty" gorm:"type:objectid;primaryKey;autoIncrement:false"`
+	Name        string             `json:"name" bson:"name" gorm:"uniqueIndex;not null" validate:"required,min=2,max=100"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty" validate:"max=500"`
+	PointsCost  int64              `json:"points_cost" bson:"points_cost" validate:"required,min=1"`
+	Discount    Discount           `json:"discount" bson:"discount" validate:"required"`
+	MinimumTier string             `json:"minimum_tier,omitempty" bson:"minimum_tier,omitempty"`
+	Active      bool               `json:"active" bson:"active" gorm:"default:true"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// BeforeCreate hook to set ID and timestamps
+func (r *LoyaltyReward) BeforeCreate(tx *gorm.DB) error {
+	if r.ID.IsZero() {
+		r.ID = primitive.NewObjectID()
+	}
+	now := time.Now()
+	r.CreatedAt = now
+	r.UpdatedAt = now
+	return nil
+}
+
+// BeforeUpdate hook to update timestamp
+func (r *LoyaltyReward) BeforeUpdate(tx *gorm.DB) error {
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+// Applied returns the copy of the reward kept on an order it was redeemed on
+func (r *LoyaltyReward) Applied() *AppliedReward {
+	discount := r.Discount
+	discount.Reason = r.Name
+	return &AppliedReward{
+		RewardID: r.ID,
+		Name:     r.Name,
+		Points:   r.PointsCost,
+		Discount: discount,
+	}
+}
+
+// LoyaltyRewardResponse represents loyalty reward data returned to the client
+type LoyaltyRewardResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	PointsCost  int64     `json:"points_cost"`
+	Discount    Discount  `json:"discount"`
+	MinimumTier string    `json:"minimum_tier,omitempty"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ToResponse converts LoyaltyReward to LoyaltyRewardResponse
+func (r *LoyaltyReward) ToResponse() LoyaltyRewardResponse {
+	return LoyaltyRewardResponse{
+		ID:          r.ID.Hex(),
+		Name:        r.Name,
+		Description: r.Description,
+		PointsCost:  r.PointsCost,
+		Discount:    r.Discount,
+		MinimumTier: r.MinimumTier,
+		Active:      r.Active,
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+	}
+}
+
+// CreateLoyaltyRewardRequest represents loyalty reward creation request payload
+type CreateLoyaltyRewardRequest struct {
+	Name        string   `json:"name" validate:"required,min=2,max=100"`
+	Description string   `json:"description,omitempty" validate:"max=500"`
+	PointsCost  int64    `json:"points_cost" validate:"required,min=1"`
+	Discount    Discount `json:"discount" validate:"required"`
+	MinimumTier string   `json:"minimum_tier,omitempty"`
+	Active      *bool    `json:"active,omitempty"`
+}
+
+// UpdateLoyaltyRewardRequest represents loyalty reward update request payload.
+// An empty minimum tier opens the reward to every customer.
+type UpdateLoyaltyRewardRequest struct {
+	Name        string    `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+	Description *string   `json:"description,omitempty" validate:"omitempty,max=500"`
+	PointsCost  *int64    `json:"points_cost,omitempty" validate:"omitempty,min=1"`
+	Discount    *Discount `json:"discount,omitempty"`
+	MinimumTier *string   `json:"minimum_tier,omitempty"`
+	Active      *bool     `json:"active,omitempty"`
+}
+
+// AppliedReward is the reward redeemed on an order, with the points it
+// cost and its discount at the time
+type AppliedReward struct {
+	RewardID primitive.ObjectID `json:"reward_id" bson:"reward_id"`
+	Name     string             `json:"name" bson:"name"`
+	Points   int64              `json:"points" bson:"points"`
+	Discount Discount           `json:"discount" bson:"discount"`
+}
+
+// RedeemLoyaltyRewardRequest represents a request to redeem a reward on an order
+type RedeemLoyaltyRewardRequest struct {
+	RewardID string `json:"reward_id" validate:"required"`
+}
+
+// LoyaltyTier is a loyalty level reached by spending at least MinimumSpend
+// within the tier window
+type LoyaltyTier struct {
+	Name         string `json:"name"`
+	MinimumSpend Money  `json:"minimum_spend"`
+}
+
+// LoyaltyProgramResponse describes how points are earned and tiers reached
+type LoyaltyProgramResponse struct {
+	EarnRate       int64         `json:"earn_rate_bps"`
+	TierWindowDays int           `json:"tier_window_days"`
+	Tiers          []LoyaltyTier `json:"tiers"`
+}
+
+// LoyaltyAccountResponse is a customer's points balance and tier. Rolling spend
+// is what the customer paid within the tier window.
+type LoyaltyAccountResponse struct {
+	CustomerID   string       `json:"customer_id"`
+	Points       int64        `json:"points"`
+	Tier         string       `json:"tier,omitempty"`
+	RollingSpend Money        `json:"rolling_spend"`
+	NextTier     *LoyaltyTier `json:"next_tier,omitempty"`
+	SpendToNext  Money        `json:"spend_to_next_tier,omitempty"`
+}
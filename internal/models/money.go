@@ -0,0 +1,113 @@
+package models
+
+import "fmt"
+
+// Money is an amount in the minor unit of the configured currency, e.g. cents.
+// Amounts are integers so that totals never suffer from floating point rounding.
+type Money int64
+
+// ApplyRate returns the given share of the amount, where rate is in basis points
+// (1% = 100), rounded half away from zero to the nearest minor unit
+func (m Money) ApplyRate(rate int64) Money {
+	return m.MulDiv(rate, 10000)
+}
+
+// MulDiv returns m * numerator / denominator, rounded half away from zero
+func (m Money) MulDiv(numerator, denominator int64) Money {
+	return divideRounded(int64(m)*numerator, denominator)
+}
+
+// String formats the amount in major units with two decimals, e.g. 1250 as "12.50"
+func (m Money) String() string {
+	sign := ""
+	value := int64(m)
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, value/100, value%100)
+}
+
+// divideRounded divides and rounds half away from zero
+func divideRounded(numerator, denominator int64) Money {
+	if (numerator < 0) != (denominator < 0) {
+		return Money(-((-numerator + denominator/2) / denominator))
+	}
+	return Money((numerator + denominator/2) / denominator)
+}
+
+type DiscountType string
+
+const (
+	DiscountTypePercentage DiscountType = "percentage"
+	DiscountTypeFixed      DiscountType = "fixed"
+)
+
+// Discount describes a reduction. For percentage discounts Value is in basis
+// points (10% = 1000); for fixed discounts it is an amount in minor units.
+type Discount struct {
+	Type   DiscountType `json:"type" bson:"type" validate:"required,oneof=percentage fixed"`
+	Value  int64        `json:"value" bson:"value" validate:"required,min=0"`
+	Reason string       `json:"reason,omitempty" bson:"reason,omitempty"`
+}
+
+// AmountOf returns how much the discount takes off the given amount. It never
+// exceeds the amount itself.
+func (d *Discount) AmountOf(amount Money) Money {
+	if d == nil || amount <= 0 {
+		return 0
+	}
+
+	var discount Money
+	switch d.Type {
+	case DiscountTypePercentage:
+		discount = amount.ApplyRate(d.Value)
+	case DiscountTypeFixed:
+		discount = Money(d.Value)
+	}
+
+	if discount > amount {
+		return amount
+	}
+	if discount < 0 {
+		return 0
+	}
+	return discount
+}
+
+// IsValid reports whether the discount has a known type and a usable value
+func (d *Discount) IsValid() bool {
+	switch d.Type {
+	case DiscountTypePercentage:
+		return d.Value >= 0 && d.Value <= 10000
+	case DiscountTypeFixed:
+		return d.Value >= 0
+	default:
+		return false
+	}
+}
+
+// TaxLine is a single tax applied to an order
+type TaxLine struct {
+	Name      string `json:"name" bson:"name"`
+	Rate      int64  `json:"rate" bson:"rate"`
+	Inclusive bool   `json:"inclusive" bson:"inclusive"`
+	Amount    Money  `json:"amount" bson:"amount"`
+}
+
+// PriceBreakdown is the full calculation behind an order's total. Inclusive
+// taxes are already contained in the prices and are shown for information;
+// exclusive taxes are added on top.
+type PriceBreakdown struct {
+	Subtotal          Money     `json:"subtotal" bson:"subtotal"`
+	LineDiscounts     Money     `json:"line_discounts" bson:"line_discounts"`
+	PromotionDiscount Money     `json:"promotion_discount" bson:"promotion_discount"`
+	RewardDiscount    Money     `json:"reward_discount,omitempty" bson:"reward_discount,omitempty"`
+	OrderDiscount     Money     `json:"order_discount" bson:"order_discount"`
+	ServiceChargeRate int64     `json:"service_charge_rate" bson:"service_charge_rate"`
+	ServiceCharge     Money     `json:"service_charge" bson:"service_charge"`
+	DeliveryFee       Money     `json:"delivery_fee,omitempty" bson:"delivery_fee,omitempty"`
+	Taxes             []TaxLine `json:"taxes" bson:"taxes"`
+	TaxTotal          Money     `json:"tax_total" bson:"tax_total"`
+	GrandTotal        Money     `json:"grand_total" bson:"grand_total"`
+}
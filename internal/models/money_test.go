@@ -0,0 +1,94 @@
+package models
+
+import "testing"
+
+func TestMoneyMulDiv(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      Money
+		numerator   int64
+		denominator int64
+		want        Money
+	}{
+		{"exact", 1000, 1600, 10000, 160},
+		{"half rounds up", 1, 1, 2, 1},
+		{"below half rounds down", 1, 1, 3, 0},
+		{"above half rounds up", 2, 1, 3, 1},
+		{"negative half rounds away from zero", -3, 1, 2, -2},
+		{"negative numerator", 5, -1, 2, -3},
+		{"inclusive tax share", 11600, 1600, 11600, 1600},
+		{"zero", 0, 1600, 10000, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.amount.MulDiv(tt.numerator, tt.denominator); got != tt.want {
+				t.Errorf("Money(%d).MulDiv(%d, %d) = %d, want %d", tt.amount, tt.numerator, tt.denominator, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyApplyRate(t *testing.T) {
+	tests := []struct {
+		amount Money
+		rate   int64
+		want   Money
+	}{
+		{10000, 1600, 1600},
+		{999, 1600, 160},
+		{1005, 1000, 101},
+		{1234, 0, 0},
+		{1234, 10000, 1234},
+	}
+	for _, tt := range tests {
+		if got := tt.amount.ApplyRate(tt.rate); got != tt.want {
+			t.Errorf("Money(%d).ApplyRate(%d) = %d, want %d", tt.amount, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		amount Money
+		want   string
+	}{
+		{1250, "12.50"},
+		{5, "0.05"},
+		{0, "0.00"},
+		{-5, "-0.05"},
+		{-123456, "-1234.56"},
+	}
+	for _, tt := range tests {
+		if got := tt.amount.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestDiscountAmountOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		discount *Discount
+		amount   Money
+		want     Money
+	}{
+		{"no discount", nil, 2500, 0},
+		{"percentage", &Discount{Type: DiscountTypePercentage, Value: 1000}, 2500, 250},
+		{"percentage rounds", &Discount{Type: DiscountTypePercentage, Value: 3333}, 100, 33},
+		{"full percentage", &Discount{Type: DiscountTypePercentage, Value: 10000}, 1234, 1234},
+		{"fixed", &Discount{Type: DiscountTypeFixed, Value: 500}, 2000, 500},
+		{"fixed capped at amount", &Discount{Type: DiscountTypeFixed, Value: 5000}, 2000, 2000},
+		{"percentage over 100% capped at amount", &Discount{Type: DiscountTypePercentage, Value: 15000}, 2000, 2000},
+		{"negative fixed", &Discount{Type: DiscountTypeFixed, Value: -100}, 2000, 0},
+		{"zero amount", &Discount{Type: DiscountTypeFixed, Value: 500}, 0, 0},
+		{"negative amount", &Discount{Type: DiscountTypeFixed, Value: 500}, -100, 0},
+		{"unknown type", &Discount{Type: "bogus", Value: 500}, 2000, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.discount.AmountOf(tt.amount); got != tt.want {
+				t.Errorf("AmountOf(%d) = %d, want %d", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
@@ -10,55 +10,173 @@ import (
 type OrderStatus string
 
 const (
-	OrderStatusPending   OrderStatus = "pending"
-	OrderStatusConfirmed OrderStatus = "confirmed"
-	OrderStatusDelivered OrderStatus = "delivered"
-	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusPending        OrderStatus = "pending"
+	OrderStatusConfirmed      OrderStatus = "confirmed"
+	OrderStatusReady          OrderStatus = "ready"
+	OrderStatusOutForDelivery OrderStatus = "out_for_delivery"
+	OrderStatusDelivered      OrderStatus = "delivered"
+	OrderStatusCancelled      OrderStatus = "cancelled"
 )
 
+// orderStatusTransitions defines which statuses an order may move to from each status.
+// Delivered and cancelled orders are final. A ready order goes back to confirmed
+// when items are added to it. Order.CanTransitionTo further restricts the
+// transitions by order type.
+var orderStatusTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:        {OrderStatusConfirmed, OrderStatusCancelled},
+	OrderStatusConfirmed:      {OrderStatusReady, OrderStatusOutForDelivery, OrderStatusDelivered, OrderStatusCancelled},
+	OrderStatusReady:          {OrderStatusConfirmed, OrderStatusOutForDelivery, OrderStatusDelivered, OrderStatusCancelled},
+	OrderStatusOutForDelivery: {OrderStatusDelivered, OrderStatusCancelled},
+}
+
+// CanTransitionTo reports whether an order in this status may move to next
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinal reports whether an order in this status can no longer change
+func (s OrderStatus) IsFinal() bool {
+	return len(orderStatusTransitions[s]) == 0
+}
+
+// CanTransitionTo reports whether the order may move to next. Only delivery
+// orders go out for delivery, and they must have gone out before they are delivered.
+func (o *Order) CanTransitionTo(next OrderStatus) bool {
+	if !o.Status.CanTransitionTo(next) {
+		return false
+	}
+	if o.OrderType == OrderTypeDelivery {
+		return next != OrderStatusDelivered || o.Status == OrderStatusOutForDelivery
+	}
+	return next != OrderStatusOutForDelivery
+}
+
+// OrderType is how an order reaches the customer
+type OrderType string
+
+const (
+	OrderTypeDineIn   OrderType = "dine_in"
+	OrderTypeTakeaway OrderType = "takeaway"
+	OrderTypeDelivery OrderType = "delivery"
+)
+
+// IsValid reports whether the order type is one of the known types
+func (t OrderType) IsValid() bool {
+	switch t {
+	case OrderTypeDineIn, OrderTypeTakeaway, OrderTypeDelivery:
+		return true
+	}
+	return false
+}
+
+// DeliveryAddress is where a delivery order is taken. The location places the
+// address in a delivery zone.
+type DeliveryAddress struct {
+	Line1        string    `json:"line1" bson:"line1" validate:"required,max=200"`
+	Line2        string    `json:"line2,omitempty" bson:"line2,omitempty" validate:"max=200"`
+	Area         string    `json:"area" bson:"area" validate:"required,max=100"`
+	City         string    `json:"city,omitempty" bson:"city,omitempty" validate:"max=100"`
+	Landmark     string    `json:"landmark,omitempty" bson:"landmark,omitempty" validate:"max=200"`
+	Instructions string    `json:"instructions,omitempty" bson:"instructions,omitempty" validate:"max=500"`
+	Location     *GeoPoint `json:"location,omitempty" bson:"location,omitempty"`
+}
+
 type PaymentStatus string
 
 const (
-	PaymentStatusPending PaymentStatus = "pending"
-	PaymentStatusPaid    PaymentStatus = "paid"
-	PaymentStatusFailed  PaymentStatus = "failed"
+	PaymentStatusPending       PaymentStatus = "pending"
+	PaymentStatusPartiallyPaid PaymentStatus = "partially_paid"
+	PaymentStatusPaid          PaymentStatus = "paid"
+	PaymentStatusRefunded      PaymentStatus = "refunded"
+	PaymentStatusFailed        PaymentStatus = "failed"
 )
 
-// OrderItem represents an item in an order
+// OrderItem represents an item in an order. Price is the unit price and Total
+// the line amount after its discount, both in minor units.
 type OrderItem struct {
-	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty" gorm:"type:objectid;primaryKey;autoIncrement:false"`
-	OrderID  primitive.ObjectID `json:"order_id" bson:"order_id" gorm:"type:objectid;index"`
-	Name     string             `json:"name" bson:"name" gorm:"not null"`
-	Quantity int                `json:"quantity" bson:"quantity" gorm:"not null" validate:"required,min=1"`
-	Price    float64            `json:"price" bson:"price" gorm:"not null" validate:"required,min=0"`
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty" gorm:"type:objectid;primaryKey;autoIncrement:false"`
+	OrderID        primitive.ObjectID `json:"order_id" bson:"order_id" gorm:"type:objectid;index"`
+	ProductID      primitive.ObjectID `json:"product_id,omitempty" bson:"product_id,omitempty" gorm:"type:objectid;index"`
+	Name           string             `json:"name" bson:"name" gorm:"not null"`
+	Category       ProductCategory    `json:"category,omitempty" bson:"category,omitempty"`
+	Subcategory    ProductSubcategory `json:"subcategory,omitempty" bson:"subcategory,omitempty"`
+	Station        string             `json:"station,omitempty" bson:"station,omitempty"`
+	TicketID       primitive.ObjectID `json:"ticket_id,omitempty" bson:"ticket_id,omitempty"`
+	Quantity       int                `json:"quantity" bson:"quantity" gorm:"not null" validate:"required,min=1"`
+	Price          Money              `json:"price" bson:"price" gorm:"not null" validate:"required,min=0"`
+	Discount       *Discount          `json:"discount,omitempty" bson:"discount,omitempty"`
+	DiscountAmount Money              `json:"discount_amount" bson:"discount_amount"`
+	Total          Money              `json:"total" bson:"total"`
+	// Items made from a recipe keep the recipe they were ordered with, per portion
+	Recipe []RecipeItem `json:"recipe,omitempty" bson:"recipe,omitempty"`
+	// Voided items stay on the order for the record but are not charged
+	Voided     bool               `json:"voided,omitempty" bson:"voided,omitempty"`
+	VoidReason string             `json:"void_reason,omitempty" bson:"void_reason,omitempty"`
+	VoidedBy   primitive.ObjectID `json:"voided_by,omitempty" bson:"voided_by,omitempty"`
+	VoidedAt   *time.Time         `json:"voided_at,omitempty" bson:"voided_at,omitempty"`
 }
 
-// Order represents an order in the system
+// OrderStatusChange records a single change of an order's status
+type OrderStatusChange struct {
+	From      OrderStatus        `json:"from,omitempty" bson:"from,omitempty"`
+	To        OrderStatus        `json:"to" bson:"to"`
+	ChangedBy primitive.ObjectID `json:"changed_by,omitempty" bson:"changed_by,omitempty"`
+	ChangedAt time.Time          `json:"changed_at" bson:"changed_at"`
+	Reason    string             `json:"reason,omitempty" bson:"reason,omitempty"`
+}
+
+// Order represents an order in the system. Dine-in orders are served at a
+// table, whose number is kept as it was when ordered; takeaway orders are
+// collected at the pickup time; delivery orders are taken to the delivery
+// address by the assigned rider.
 type Order struct {
-	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty" gorm:"type:objectid;primaryKey;autoIncrement:false"`
-	OrderNumber    string             `json:"order_number" bson:"order_number" gorm:"uniqueIndex;not null"`
-	UserID         primitive.ObjectID `json:"user_id,omitempty" bson:"user_id,omitempty" gorm:"type:objectid;index"`
-	User           *User              `json:"user,omitempty" bson:"user,omitempty" gorm:"foreignKey:UserID"`
-	CustomerName   string             `json:"customer_name" bson:"customer_name" gorm:"not null" validate:"required,min=2,max=100"`
-	CustomerPhone  string             `json:"customer_phone" bson:"customer_phone" gorm:"not null" validate:"required"`
-	CustomerEmail  string             `json:"customer_email,omitempty" bson:"customer_email,omitempty"`
-	TotalAmount    float64            `json:"total_amount" bson:"total_amount" gorm:"not null" validate:"required,min=0"`
-	Status         OrderStatus        `json:"status" bson:"status" gorm:"not null;default:pending" validate:"required,oneof=pending confirmed delivered cancelled"`
-	PaymentStatus  PaymentStatus      `json:"payment_status" bson:"payment_status" gorm:"not null;default:pending" validate:"required,oneof=pending paid failed"`
-	SpecialRequest string             `json:"special_request,omitempty" bson:"special_request,omitempty"`
-	Items          []OrderItem        `json:"items" bson:"items" gorm:"foreignKey:OrderID"`
-	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
-}
-
-// BeforeCreate hook to set ID, order number and timestamps
+	ID              primitive.ObjectID   `json:"id" bson:"_id,omitempty" gorm:"type:objectid;primaryKey;autoIncrement:false"`
+	OrderNumber     string               `json:"order_number" bson:"order_number" gorm:"uniqueIndex;not null"`
+	UserID          primitive.ObjectID   `json:"user_id,omitempty" bson:"user_id,omitempty" gorm:"type:objectid;index"`
+	User            *User                `json:"user,omitempty" bson:"user,omitempty" gorm:"foreignKey:UserID"`
+	CustomerID      primitive.ObjectID   `json:"customer_id,omitempty" bson:"customer_id,omitempty" gorm:"type:objectid;index"`
+	CustomerName    string               `json:"customer_name" bson:"customer_name" gorm:"not null" validate:"required,min=2,max=100"`
+	CustomerPhone   string               `json:"customer_phone" bson:"customer_phone" gorm:"not null" validate:"required"`
+	CustomerEmail   string               `json:"customer_email,omitempty" bson:"customer_email,omitempty"`
+	OrderType       OrderType            `json:"order_type" bson:"order_type" gorm:"not null;default:dine_in" validate:"required,oneof=dine_in takeaway delivery"`
+	TableID         primitive.ObjectID   `json:"table_id,omitempty" bson:"table_id,omitempty" gorm:"type:objectid;index"`
+	TableNumber     int                  `json:"table_number,omitempty" bson:"table_number,omitempty"`
+	PickupTime      *time.Time           `json:"pickup_time,omitempty" bson:"pickup_time,omitempty"`
+	DeliveryAddress *DeliveryAddress     `json:"delivery_address,omitempty" bson:"delivery_address,omitempty"`
+	DeliveryFee     Money                `json:"delivery_fee,omitempty" bson:"delivery_fee,omitempty"`
+	DeliveryZone    *AppliedDeliveryZone `json:"delivery_zone,omitempty" bson:"delivery_zone,omitempty"`
+	RiderID         primitive.ObjectID   `json:"rider_id,omitempty" bson:"rider_id,omitempty" gorm:"type:objectid;index"`
+	RiderName       string               `json:"rider_name,omitempty" bson:"rider_name,omitempty"`
+	Currency        string               `json:"currency" bson:"currency"`
+	Discount        *Discount            `json:"discount,omitempty" bson:"discount,omitempty"`
+	Promotion       *AppliedPromotion    `json:"promotion,omitempty" bson:"promotion,omitempty"`
+	LoyaltyReward   *AppliedReward       `json:"loyalty_reward,omitempty" bson:"loyalty_reward,omitempty"`
+	Pricing         *PriceBreakdown      `json:"pricing,omitempty" bson:"pricing,omitempty"`
+	TotalAmount     Money                `json:"total_amount" bson:"total_amount" gorm:"not null" validate:"required,min=0"`
+	AmountPaid      Money                `json:"amount_paid" bson:"amount_paid"`
+	AmountRefunded  Money                `json:"amount_refunded" bson:"amount_refunded"`
+	Status          OrderStatus          `json:"status" bson:"status" gorm:"not null;default:pending" validate:"required,oneof=pending confirmed ready out_for_delivery delivered cancelled"`
+	PaymentStatus   PaymentStatus        `json:"payment_status" bson:"payment_status" gorm:"not null;default:pending" validate:"required,oneof=pending partially_paid paid refunded failed"`
+	SpecialRequest  string               `json:"special_request,omitempty" bson:"special_request,omitempty"`
+	Items           []OrderItem          `json:"items" bson:"items" gorm:"foreignKey:OrderID"`
+	IngredientsUsed []RecipeItem         `json:"ingredients_used,omitempty" bson:"ingredients_used,omitempty"`
+	Tickets         []StationTicket      `json:"tickets,omitempty" bson:"tickets,omitempty"`
+	StatusHistory   []OrderStatusChange  `json:"status_history" bson:"status_history,omitempty"`
+	CreatedAt       time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at" bson:"updated_at"`
+}
+
+// BeforeCreate hook to set ID and timestamps. Order numbers are assigned by
+// the handler from a daily sequence.
 func (o *Order) BeforeCreate(tx *gorm.DB) error {
 	if o.ID.IsZero() {
 		o.ID = primitive.NewObjectID()
 	}
-	if o.OrderNumber == "" {
-		o.OrderNumber = "ORD-" + o.ID.Hex()[:8]
-	}
 	o.CreatedAt = time.Now()
 	o.UpdatedAt = time.Now()
 	return nil
@@ -70,22 +188,74 @@ func (o *Order) BeforeUpdate(tx *gorm.DB) error {
 	return nil
 }
 
+// NetPaid returns the amount paid for the order less refunds
+func (o *Order) NetPaid() Money {
+	return o.AmountPaid - o.AmountRefunded
+}
+
+// BalanceDue returns how much is still to be paid for the order
+func (o *Order) BalanceDue() Money {
+	if due := o.TotalAmount - o.NetPaid(); due > 0 {
+		return due
+	}
+	return 0
+}
+
+// RefreshPaymentStatus derives the payment status from the amounts paid and
+// refunded against the order total. An order with nothing to pay is paid.
+func (o *Order) RefreshPaymentStatus() {
+	net := o.NetPaid()
+	switch {
+	case net <= 0 && o.AmountRefunded > 0:
+		o.PaymentStatus = PaymentStatusRefunded
+	case o.TotalAmount <= 0:
+		o.PaymentStatus = PaymentStatusPaid
+	case net <= 0:
+		o.PaymentStatus = PaymentStatusPending
+	case net >= o.TotalAmount:
+		o.PaymentStatus = PaymentStatusPaid
+	default:
+		o.PaymentStatus = PaymentStatusPartiallyPaid
+	}
+}
+
 // OrderResponse represents order data returned to client
 type OrderResponse struct {
-	ID             string        `json:"id"`
-	OrderNumber    string        `json:"order_number"`
-	UserID         string        `json:"user_id,omitempty"`
-	User           *UserResponse `json:"user,omitempty"`
-	CustomerName   string        `json:"customer_name"`
-	CustomerPhone  string        `json:"customer_phone"`
-	CustomerEmail  string        `json:"customer_email,omitempty"`
-	TotalAmount    float64       `json:"total_amount"`
-	Status         OrderStatus   `json:"status"`
-	PaymentStatus  PaymentStatus `json:"payment_status"`
-	SpecialRequest string        `json:"special_request,omitempty"`
-	Items          []OrderItem   `json:"items"`
-	CreatedAt      time.Time     `json:"created_at"`
-	UpdatedAt      time.Time     `json:"updated_at"`
+	ID              string               `json:"id"`
+	OrderNumber     string               `json:"order_number"`
+	UserID          string               `json:"user_id,omitempty"`
+	User            *UserResponse        `json:"user,omitempty"`
+	CustomerID      string               `json:"customer_id,omitempty"`
+	CustomerName    string               `json:"customer_name"`
+	CustomerPhone   string               `json:"customer_phone"`
+	CustomerEmail   string               `json:"customer_email,omitempty"`
+	OrderType       OrderType            `json:"order_type"`
+	TableID         string               `json:"table_id,omitempty"`
+	TableNumber     int                  `json:"table_number,omitempty"`
+	PickupTime      *time.Time           `json:"pickup_time,omitempty"`
+	DeliveryAddress *DeliveryAddress     `json:"delivery_address,omitempty"`
+	DeliveryFee     Money                `json:"delivery_fee,omitempty"`
+	DeliveryZone    *AppliedDeliveryZone `json:"delivery_zone,omitempty"`
+	RiderID         string               `json:"rider_id,omitempty"`
+	RiderName       string               `json:"rider_name,omitempty"`
+	Currency        string               `json:"currency"`
+	Discount        *Discount            `json:"discount,omitempty"`
+	Promotion       *AppliedPromotion    `json:"promotion,omitempty"`
+	LoyaltyReward   *AppliedReward       `json:"loyalty_reward,omitempty"`
+	Pricing         *PriceBreakdown      `json:"pricing,omitempty"`
+	TotalAmount     Money                `json:"total_amount"`
+	AmountPaid      Money                `json:"amount_paid"`
+	AmountRefunded  Money                `json:"amount_refunded"`
+	BalanceDue      Money                `json:"balance_due"`
+	Status          OrderStatus          `json:"status"`
+	PaymentStatus   PaymentStatus        `json:"payment_status"`
+	SpecialRequest  string               `json:"special_request,omitempty"`
+	Items           []OrderItem          `json:"items"`
+	IngredientsUsed []RecipeItem         `json:"ingredients_used,omitempty"`
+	Tickets         []StationTicket      `json:"tickets,omitempty"`
+	StatusHistory   []OrderStatusChange  `json:"status_history"`
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
 }
 
 // ToResponse converts Order to OrderResponse
@@ -96,49 +266,116 @@ func (o *Order) ToResponse() OrderResponse {
 		userResponse = &userResp
 	}
 
-	return OrderResponse{
-		ID:             o.ID.Hex(),
-		OrderNumber:    o.OrderNumber,
-		UserID:         o.UserID.Hex(),
-		User:           userResponse,
-		CustomerName:   o.CustomerName,
-		CustomerPhone:  o.CustomerPhone,
-		CustomerEmail:  o.CustomerEmail,
-		TotalAmount:    o.TotalAmount,
-		Status:         o.Status,
-		PaymentStatus:  o.PaymentStatus,
-		SpecialRequest: o.SpecialRequest,
-		Items:          o.Items,
-		CreatedAt:      o.CreatedAt,
-		UpdatedAt:      o.UpdatedAt,
+	response := OrderResponse{
+		ID:              o.ID.Hex(),
+		OrderNumber:     o.OrderNumber,
+		UserID:          o.UserID.Hex(),
+		User:            userResponse,
+		CustomerName:    o.CustomerName,
+		CustomerPhone:   o.CustomerPhone,
+		CustomerEmail:   o.CustomerEmail,
+		OrderType:       o.OrderType,
+		TableNumber:     o.TableNumber,
+		PickupTime:      o.PickupTime,
+		DeliveryAddress: o.DeliveryAddress,
+		DeliveryFee:     o.DeliveryFee,
+		DeliveryZone:    o.DeliveryZone,
+		RiderName:       o.RiderName,
+		Currency:        o.Currency,
+		Discount:        o.Discount,
+		Promotion:       o.Promotion,
+		LoyaltyReward:   o.LoyaltyReward,
+		Pricing:         o.Pricing,
+		TotalAmount:     o.TotalAmount,
+		AmountPaid:      o.AmountPaid,
+		AmountRefunded:  o.AmountRefunded,
+		BalanceDue:      o.BalanceDue(),
+		Status:          o.Status,
+		PaymentStatus:   o.PaymentStatus,
+		SpecialRequest:  o.SpecialRequest,
+		Items:           o.Items,
+		IngredientsUsed: o.IngredientsUsed,
+		Tickets:         o.Tickets,
+		StatusHistory:   o.StatusHistory,
+		CreatedAt:       o.CreatedAt,
+		UpdatedAt:       o.UpdatedAt,
+	}
+	if !o.CustomerID.IsZero() {
+		response.CustomerID = o.CustomerID.Hex()
 	}
+	if !o.TableID.IsZero() {
+		response.TableID = o.TableID.Hex()
+	}
+	if !o.RiderID.IsZero() {
+		response.RiderID = o.RiderID.Hex()
+	}
+	return response
 }
 
-// CreateOrderRequest represents order creation request payload
+// CreateOrderRequest represents order creation request payload. Dine-in orders
+// need a table, takeaway orders a pickup time and delivery orders an address.
 type CreateOrderRequest struct {
-	UserID         string             `json:"user_id,omitempty"`
-	CustomerName   string             `json:"customer_name" validate:"required,min=2,max=100"`
-	CustomerPhone  string             `json:"customer_phone" validate:"required"`
-	CustomerEmail  string             `json:"customer_email,omitempty" validate:"omitempty,email"`
-	Status         OrderStatus        `json:"status,omitempty" validate:"omitempty,oneof=pending confirmed delivered cancelled"`
-	PaymentStatus  PaymentStatus      `json:"payment_status,omitempty" validate:"omitempty,oneof=pending paid failed"`
-	SpecialRequest string             `json:"special_request,omitempty"`
-	Items          []OrderItemRequest `json:"items" validate:"required,min=1,dive"`
+	UserID          string             `json:"user_id,omitempty"`
+	CustomerName    string             `json:"customer_name" validate:"required,min=2,max=100"`
+	CustomerPhone   string             `json:"customer_phone" validate:"required"`
+	CustomerEmail   string             `json:"customer_email,omitempty" validate:"omitempty,email"`
+	OrderType       OrderType          `json:"order_type" validate:"required,oneof=dine_in takeaway delivery"`
+	TableID         string             `json:"table_id,omitempty"`
+	PickupTime      *time.Time         `json:"pickup_time,omitempty"`
+	DeliveryAddress *DeliveryAddress   `json:"delivery_address,omitempty"`
+	Status          OrderStatus        `json:"status,omitempty" validate:"omitempty,oneof=pending confirmed ready out_for_delivery delivered cancelled"`
+	SpecialRequest  string             `json:"special_request,omitempty"`
+	Items           []OrderItemRequest `json:"items" validate:"required,min=1,dive"`
+	Discount        *Discount          `json:"discount,omitempty"`
+	PromoCode       string             `json:"promo_code,omitempty" validate:"omitempty,max=32"`
 }
 
-// OrderItemRequest represents order item in request
+// OrderItemRequest represents order item in request.
+// Name and price are taken from the referenced product, never from the client.
 type OrderItemRequest struct {
-	Name     string  `json:"name" validate:"required"`
-	Quantity int     `json:"quantity" validate:"required,min=1"`
-	Price    float64 `json:"price" validate:"required,min=0"`
+	ProductID string    `json:"product_id" validate:"required"`
+	Quantity  int       `json:"quantity" validate:"required,min=1"`
+	Discount  *Discount `json:"discount,omitempty"`
+}
+
+// UpdateOrderItemRequest represents an order item quantity change payload
+type UpdateOrderItemRequest struct {
+	Quantity int `json:"quantity" validate:"required,min=1"`
+}
+
+// VoidOrderItemRequest represents an order item void payload
+type VoidOrderItemRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
 }
 
-// UpdateOrderRequest represents order update request payload
+// UpdateOrderRequest represents order update request payload. The order type
+// cannot be changed, only the details that go with it.
 type UpdateOrderRequest struct {
-	CustomerName   string        `json:"customer_name,omitempty" validate:"omitempty,min=2,max=100"`
-	CustomerPhone  string        `json:"customer_phone,omitempty"`
-	CustomerEmail  string        `json:"customer_email,omitempty" validate:"omitempty,email"`
-	Status         OrderStatus   `json:"status,omitempty" validate:"omitempty,oneof=pending confirmed delivered cancelled"`
-	PaymentStatus  PaymentStatus `json:"payment_status,omitempty" validate:"omitempty,oneof=pending paid failed"`
-	SpecialRequest string        `json:"special_request,omitempty"`
+	CustomerName    string           `json:"customer_name,omitempty" validate:"omitempty,min=2,max=100"`
+	CustomerPhone   string           `json:"customer_phone,omitempty"`
+	CustomerEmail   string           `json:"customer_email,omitempty" validate:"omitempty,email"`
+	TableID         string           `json:"table_id,omitempty"`
+	PickupTime      *time.Time       `json:"pickup_time,omitempty"`
+	DeliveryAddress *DeliveryAddress `json:"delivery_address,omitempty"`
+	Status          OrderStatus      `json:"status,omitempty" validate:"omitempty,oneof=pending confirmed ready out_for_delivery delivered cancelled"`
+	SpecialRequest  string           `json:"special_request,omitempty"`
+	StatusReason    string           `json:"status_reason,omitempty" validate:"max=500"`
+	Discount        *Discount        `json:"discount,omitempty"`
+}
+
+// UpdateOrderStatusRequest represents an order status change request payload
+type UpdateOrderStatusRequest struct {
+	Status OrderStatus `json:"status" validate:"required,oneof=pending confirmed ready out_for_delivery delivered cancelled"`
+	Reason string      `json:"reason,omitempty" validate:"max=500"`
+}
+
+// AssignRiderRequest represents a delivery rider assignment payload. An empty
+// rider ID unassigns the rider.
+type AssignRiderRequest struct {
+	RiderID string `json:"rider_id"`
+}
+
+// EmailReceiptRequest represents an order receipt email request payload
+type EmailReceiptRequest struct {
+	Email string `json:"email,omitempty" validate:"omitempty,email"`
 }
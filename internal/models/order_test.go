@@ -0,0 +1,31 @@
+package models
+
+import "testing"
+
+func TestOrderRefreshPaymentStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    Money
+		paid     Money
+		refunded Money
+		want     PaymentStatus
+	}{
+		{"nothing paid", 1000, 0, 0, PaymentStatusPending},
+		{"part paid", 1000, 400, 0, PaymentStatusPartiallyPaid},
+		{"paid in full", 1000, 1000, 0, PaymentStatusPaid},
+		{"overpaid", 1000, 1200, 0, PaymentStatusPaid},
+		{"part refunded", 1000, 1000, 300, PaymentStatusPartiallyPaid},
+		{"fully refunded", 1000, 1000, 1000, PaymentStatusRefunded},
+		{"nothing to pay", 0, 0, 0, PaymentStatusPaid},
+		{"nothing to pay after a refund", 0, 500, 500, PaymentStatusRefunded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := Order{TotalAmount: tt.total, AmountPaid: tt.paid, AmountRefunded: tt.refunded}
+			order.RefreshPaymentStatus()
+			if order.PaymentStatus != tt.want {
+				t.Errorf("payment status = %s, want %s", order.PaymentStatus, tt.want)
+			}
+		})
+	}
+}
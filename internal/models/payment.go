@@ -0,0 +1,160 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PaymentMethod string
+
+const (
+	PaymentMethodCash        PaymentMethod = "cash"
+	PaymentMethodCard        PaymentMethod = "card"
+	PaymentMethodMobileMoney PaymentMethod = "mobile_money"
+)
+
+// IsValid reports whether the method is one of the accepted payment methods
+func (m PaymentMethod) IsValid() bool {
+	switch m {
+	case PaymentMethodCash, PaymentMethodCard, PaymentMethodMobileMoney:
+		return true
+	default:
+		return false
+	}
+}
+
+// PaymentRecordStatus is the state of a single payment. Cash and card payments
+// are completed when recorded; mobile money payments stay pending until the
+// provider reports whether the customer approved them.
+type PaymentRecordStatus string
+
+const (
+	PaymentRecordPending   PaymentRecordStatus = "pending"
+	PaymentRecordCompleted PaymentRecordStatus = "completed"
+	PaymentRecordFailed    PaymentRecordStatus = "failed"
+)
+
+type PaymentType string
+
+const (
+	PaymentTypePayment PaymentType = "payment"
+	PaymentTypeRefund  PaymentType = "refund"
+)
+
+// Payment records money taken for an order, or given back. An order can have
+// several payments, e.g. when the bill is split. Amounts are always positive;
+// Type tells payments and refunds apart.
+type Payment struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OrderID   primitive.ObjectID `json:"order_id" bson:"order_id"`
+	Type      PaymentType        `json:"type" bson:"type"`
+	Method    PaymentMethod      `json:"method" bson:"method" validate:"required,oneof=cash card mobile_money"`
+	Amount    Money              `json:"amount" bson:"amount" validate:"required,min=1"`
+	Currency  string             `json:"currency" bson:"currency"`
+	Reference string             `json:"reference,omitempty" bson:"reference,omitempty" validate:"max=100"`
+	// Status is empty on payments recorded before mobile money, which were all completed
+	Status PaymentRecordStatus `json:"status" bson:"status,omitempty"`
+	// Provider fields are set on mobile money payments
+	Provider      string `json:"provider,omitempty" bson:"provider,omitempty"`
+	CheckoutID    string `json:"checkout_id,omitempty" bson:"checkout_id,omitempty"`
+	Phone         string `json:"phone,omitempty" bson:"phone,omitempty"`
+	FailureReason string `json:"failure_reason,omitempty" bson:"failure_reason,omitempty"`
+	// RefundOf is the payment a refund gives money back from
+	RefundOf  primitive.ObjectID `json:"refund_of,omitempty" bson:"refund_of,omitempty"`
+	Reason    string             `json:"reason,omitempty" bson:"reason,omitempty"`
+	CashierID primitive.ObjectID `json:"cashier_id" bson:"cashier_id"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	// CompletedAt is when a pending payment was approved or failed
+	CompletedAt *time.Time `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+}
+
+// IsCompleted reports whether the money of the payment was received
+func (p *Payment) IsCompleted() bool {
+	return p.Status == PaymentRecordCompleted || p.Status == ""
+}
+
+// PaymentResponse represents payment data returned to client
+type PaymentResponse struct {
+	ID            string              `json:"id"`
+	OrderID       string              `json:"order_id"`
+	Type          PaymentType         `json:"type"`
+	Method        PaymentMethod       `json:"method"`
+	Amount        Money               `json:"amount"`
+	Currency      string              `json:"currency"`
+	Reference     string              `json:"reference,omitempty"`
+	Status        PaymentRecordStatus `json:"status"`
+	Provider      string              `json:"provider,omitempty"`
+	Phone         string              `json:"phone,omitempty"`
+	FailureReason string              `json:"failure_reason,omitempty"`
+	RefundOf      string              `json:"refund_of,omitempty"`
+	Reason        string              `json:"reason,omitempty"`
+	CashierID     string              `json:"cashier_id"`
+	CreatedAt     time.Time           `json:"created_at"`
+	CompletedAt   *time.Time          `json:"completed_at,omitempty"`
+}
+
+// ToResponse converts Payment to PaymentResponse
+func (p *Payment) ToResponse() PaymentResponse {
+	response := PaymentResponse{
+		ID:            p.ID.Hex(),
+		OrderID:       p.OrderID.Hex(),
+		Type:          p.Type,
+		Method:        p.Method,
+		Amount:        p.Amount,
+		Currency:      p.Currency,
+		Reference:     p.Reference,
+		Status:        p.Status,
+		Provider:      p.Provider,
+		Phone:         p.Phone,
+		FailureReason: p.FailureReason,
+		Reason:        p.Reason,
+		CashierID:     p.CashierID.Hex(),
+		CreatedAt:     p.CreatedAt,
+		CompletedAt:   p.CompletedAt,
+	}
+	if response.Status == "" {
+		response.Status = PaymentRecordCompleted
+	}
+	if !p.RefundOf.IsZero() {
+		response.RefundOf = p.RefundOf.Hex()
+	}
+	return response
+}
+
+// OrderPaymentsResponse lists the payments of an order with its balance
+type OrderPaymentsResponse struct {
+	OrderID        string            `json:"order_id"`
+	Currency       string            `json:"currency"`
+	TotalAmount    Money             `json:"total_amount"`
+	AmountPaid     Money             `json:"amount_paid"`
+	AmountRefunded Money             `json:"amount_refunded"`
+	BalanceDue     Money             `json:"balance_due"`
+	PaymentStatus  PaymentStatus     `json:"payment_status"`
+	Payments       []PaymentResponse `json:"payments"`
+}
+
+// CreatePaymentRequest represents a payment taken for an order
+type CreatePaymentRequest struct {
+	Method    PaymentMethod `json:"method" validate:"required,oneof=cash card mobile_money"`
+	Amount    Money         `json:"amount" validate:"required,min=1"`
+	Reference string        `json:"reference,omitempty" validate:"max=100"`
+}
+
+// MobilePaymentRequest represents a mobile money payment request sent to the customer's phone
+type MobilePaymentRequest struct {
+	Phone  string `json:"phone" validate:"required"`
+	Amount Money  `json:"amount" validate:"required,min=1"`
+}
+
+// MobilePaymentResponse represents a mobile money payment awaiting the customer's approval
+type MobilePaymentResponse struct {
+	Payment         PaymentResponse `json:"payment"`
+	CustomerMessage string          `json:"customer_message,omitempty"`
+}
+
+// RefundPaymentRequest represents a refund of all or part of a payment
+type RefundPaymentRequest struct {
+	Amount Money  `json:"amount" validate:"required,min=1"`
+	Reason string `json:"reason" validate:"required,max=500"`
+}
@@ -0,0 +1,166 @@
+package models
+
+// Permission is a named capability that can be granted to a role
+type Permission string
+
+const (
+	PermissionUsersRead       Permission = "users:read"
+	PermissionUsersWrite      Permission = "users:write"
+	PermissionUsersAssignRole Permission = "users:assign_roles"
+	PermissionSettingsManage  Permission = "settings:manage"
+	PermissionAuditRead       Permission = "audit:read"
+	PermissionReportsRead     Permission = "reports:read"
+
+	PermissionProductsRead  Permission = "products:read"
+	PermissionProductsWrite Permission = "products:write"
+	PermissionUploadsWrite  Permission = "uploads:write"
+
+	PermissionOrdersRead         Permission = "orders:read"
+	PermissionOrdersCreate       Permission = "orders:create"
+	PermissionOrdersUpdate       Permission = "orders:update"
+	PermissionOrdersUpdateStatus Permission = "orders:update_status"
+	PermissionOrdersEditItems    Permission = "orders:edit_items"
+	PermissionOrdersVoidItems    Permission = "orders:void_items"
+	PermissionOrdersDiscount     Permission = "orders:discount"
+	PermissionOrdersDelete       Permission = "orders:delete"
+
+	PermissionPaymentsCreate Permission = "payments:create"
+	PermissionPaymentsRefund Permission = "payments:refund"
+
+	PermissionPromotionsRead  Permission = "promotions:read"
+	PermissionPromotionsWrite Permission = "promotions:write"
+
+	PermissionEventsRead     Permission = "events:read"
+	PermissionEventsWrite    Permission = "events:write"
+	PermissionTicketsSell    Permission = "tickets:sell"
+	PermissionTicketsCheckIn Permission = "tickets:check_in"
+
+	PermissionReservationsRead   Permission = "reservations:read"
+	PermissionReservationsWrite  Permission = "reservations:write"
+	PermissionReservationsDelete Permission = "reservations:delete"
+	PermissionTablesRead         Permission = "tables:read"
+	PermissionTablesWrite        Permission = "tables:write"
+
+	PermissionStationsRead  Permission = "stations:read"
+	PermissionStationsWrite Permission = "stations:write"
+
+	PermissionDeliveryZonesRead  Permission = "delivery_zones:read"
+	PermissionDeliveryZonesWrite Permission = "delivery_zones:write"
+
+	PermissionCustomersRead  Permission = "customers:read"
+	PermissionCustomersWrite Permission = "customers:write"
+
+	PermissionLoyaltyRead   Permission = "loyalty:read"
+	PermissionLoyaltyWrite  Permission = "loyalty:write"
+	PermissionLoyaltyRedeem Permission = "loyalty:redeem"
+
+	PermissionInventoryRead  Permission = "inventory:read"
+	PermissionInventoryWrite Permission = "inventory:write"
+)
+
+// PermissionDescriptions gives a human readable description of each permission
+var PermissionDescriptions = map[Permission]string{
+	PermissionUsersRead:          "View user accounts",
+	PermissionUsersWrite:         "Manage user accounts",
+	PermissionUsersAssignRole:    "Assign roles and invite users",
+	PermissionSettingsManage:     "System configuration",
+	PermissionAuditRead:          "View audit log",
+	PermissionReportsRead:        "Sales and operations reports",
+	PermissionProductsRead:       "View products",
+	PermissionProductsWrite:      "Manage products and inventory",
+	PermissionUploadsWrite:       "Upload images",
+	PermissionOrdersRead:         "View orders",
+	PermissionOrdersCreate:       "Take orders",
+	PermissionOrdersUpdate:       "Edit orders",
+	PermissionOrdersUpdateStatus: "Update order status",
+	PermissionOrdersEditItems:    "Add items and change quantities on open orders",
+	PermissionOrdersVoidItems:    "Void order items",
+	PermissionOrdersDiscount:     "Apply manual discounts",
+	PermissionOrdersDelete:       "Delete orders",
+	PermissionPaymentsCreate:     "Take payments",
+	PermissionPaymentsRefund:     "Refund payments",
+	PermissionPromotionsRead:     "View promotions",
+	PermissionPromotionsWrite:    "Manage promotions and promo codes",
+	PermissionEventsRead:         "View events",
+	PermissionEventsWrite:        "Manage events",
+	PermissionTicketsSell:        "Sell and cancel event tickets",
+	PermissionTicketsCheckIn:     "Check in event guests",
+	PermissionReservationsRead:   "View reservations",
+	PermissionReservationsWrite:  "Manage reservations",
+	PermissionReservationsDelete: "Delete reservations",
+	PermissionTablesRead:         "View floor plan",
+	PermissionTablesWrite:        "Manage floor plan",
+	PermissionStationsRead:       "View prep stations",
+	PermissionStationsWrite:      "Manage prep stations and item routing",
+	PermissionDeliveryZonesRead:  "View delivery zones",
+	PermissionDeliveryZonesWrite: "Manage delivery zones and fees",
+	PermissionCustomersRead:      "View customers and their visit history",
+	PermissionCustomersWrite:     "Manage customer notes and tags and merge duplicates",
+	PermissionLoyaltyRead:        "View loyalty balances, history and rewards",
+	PermissionLoyaltyWrite:       "Manage loyalty rewards",
+	PermissionLoyaltyRedeem:      "Redeem loyalty rewards on orders",
+	PermissionInventoryRead:      "View ingredients and stock levels",
+	PermissionInventoryWrite:     "Manage ingredients, stock counts and recipes",
+}
+
+// RolePermissions maps each role to the permissions it is granted. This is the
+// single source of truth for both API access control and the profile shown in the UI.
+var RolePermissions = map[UserRole][]Permission{
+	RoleAdmin: {
+		PermissionUsersRead, PermissionUsersWrite, PermissionUsersAssignRole, PermissionSettingsManage, PermissionAuditRead, PermissionReportsRead,
+		PermissionProductsRead, PermissionProductsWrite, PermissionUploadsWrite,
+		PermissionOrdersRead, PermissionOrdersCreate, PermissionOrdersUpdate, PermissionOrdersUpdateStatus, PermissionOrdersDelete,
+		PermissionOrdersEditItems, PermissionOrdersVoidItems, PermissionOrdersDiscount,
+		PermissionPaymentsCreate, PermissionPaymentsRefund,
+		PermissionPromotionsRead, PermissionPromotionsWrite,
+		PermissionEventsRead, PermissionEventsWrite, PermissionTicketsSell, PermissionTicketsCheckIn,
+		PermissionReservationsRead, PermissionReservationsWrite, PermissionReservationsDelete,
+		PermissionTablesRead, PermissionTablesWrite,
+		PermissionStationsRead, PermissionStationsWrite,
+		PermissionDeliveryZonesRead, PermissionDeliveryZonesWrite,
+		PermissionCustomersRead, PermissionCustomersWrite,
+		PermissionLoyaltyRead, PermissionLoyaltyWrite, PermissionLoyaltyRedeem,
+		PermissionInventoryRead, PermissionInventoryWrite,
+	},
+	RoleManager: {
+		PermissionUsersRead, PermissionUsersWrite, PermissionReportsRead,
+		PermissionProductsRead, PermissionProductsWrite, PermissionUploadsWrite,
+		PermissionOrdersRead, PermissionOrdersCreate, PermissionOrdersUpdate, PermissionOrdersUpdateStatus, PermissionOrdersDelete,
+		PermissionOrdersEditItems, PermissionOrdersVoidItems, PermissionOrdersDiscount,
+		PermissionPaymentsCreate, PermissionPaymentsRefund,
+		PermissionPromotionsRead, PermissionPromotionsWrite,
+		PermissionEventsRead, PermissionEventsWrite, PermissionTicketsSell, PermissionTicketsCheckIn,
+		PermissionReservationsRead, PermissionReservationsWrite, PermissionReservationsDelete,
+		PermissionTablesRead, PermissionTablesWrite,
+		PermissionStationsRead, PermissionStationsWrite,
+		PermissionDeliveryZonesRead, PermissionDeliveryZonesWrite,
+		PermissionCustomersRead, PermissionCustomersWrite,
+		PermissionLoyaltyRead, PermissionLoyaltyWrite, PermissionLoyaltyRedeem,
+		PermissionInventoryRead, PermissionInventoryWrite,
+	},
+	RoleStaff: {
+		PermissionProductsRead,
+		PermissionOrdersRead, PermissionOrdersCreate, PermissionOrdersUpdateStatus, PermissionOrdersEditItems,
+		PermissionPaymentsCreate, PermissionPromotionsRead,
+		PermissionEventsRead, PermissionTicketsCheckIn,
+		PermissionReservationsRead, PermissionReservationsWrite,
+		PermissionTablesRead, PermissionStationsRead, PermissionDeliveryZonesRead,
+		PermissionCustomersRead, PermissionLoyaltyRead, PermissionLoyaltyRedeem,
+		PermissionInventoryRead,
+	},
+}
+
+// Permissions returns the permissions granted to the role
+func (r UserRole) Permissions() []Permission {
+	return RolePermissions[r]
+}
+
+// HasPermission reports whether the role grants the permission
+func (r UserRole) HasPermission(permission Permission) bool {
+	for _, granted := range RolePermissions[r] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
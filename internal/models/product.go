@@ -31,14 +31,17 @@ const (
 	SubcategoryOther ProductSubcategory = "other"
 )
 
-// Product represents a product in the system
+// Product represents a product in the system. Products with a recipe are made
+// from ingredients, and how many can be made comes from the ingredients on hand
+// rather than from Stock.
 type Product struct {
 	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty" gorm:"type:objectid;primaryKey;autoIncrement:false"`
 	Name        string             `json:"name" bson:"name" gorm:"not null" validate:"required,min=2,max=100"`
 	Category    ProductCategory    `json:"category" bson:"category" gorm:"not null" validate:"required,oneof=food drink"`
 	Subcategory ProductSubcategory `json:"subcategory" bson:"subcategory" gorm:"not null" validate:"required"`
-	Price       float64            `json:"price" bson:"price" gorm:"not null" validate:"required,min=0"`
+	Price       Money              `json:"price" bson:"price" gorm:"not null" validate:"required,min=0"`
 	Stock       int                `json:"stock" bson:"stock" gorm:"not null;default:0" validate:"min=0"`
+	Recipe      []RecipeItem       `json:"recipe,omitempty" bson:"recipe,omitempty"`
 	Description string             `json:"description,omitempty" bson:"description,omitempty" validate:"max=500"`
 	ImageURL    string             `json:"image_url,omitempty" bson:"image_url,omitempty"`
 	Popular     bool               `json:"popular" bson:"popular" gorm:"default:false"`
@@ -64,14 +67,18 @@ func (p *Product) BeforeUpdate(tx *gorm.DB) error {
 	return nil
 }
 
-// ProductResponse represents product data returned to client
+// ProductResponse represents product data returned to client. For products with a
+// recipe, Stock is the number of portions the ingredients on hand allow and
+// RecipeCost what the ingredients of one portion cost.
 type ProductResponse struct {
 	ID          string             `json:"id"`
 	Name        string             `json:"name"`
 	Category    ProductCategory    `json:"category"`
 	Subcategory ProductSubcategory `json:"subcategory"`
-	Price       float64            `json:"price"`
+	Price       Money              `json:"price"`
 	Stock       int                `json:"stock"`
+	Recipe      []RecipeLine       `json:"recipe,omitempty"`
+	RecipeCost  Money              `json:"recipe_cost,omitempty"`
 	Description string             `json:"description,omitempty"`
 	ImageURL    string             `json:"image_url,omitempty"`
 	Popular     bool               `json:"popular"`
@@ -105,7 +112,7 @@ type CreateProductRequest struct {
 	Name        string             `json:"name" validate:"required,min=2,max=100"`
 	Category    ProductCategory    `json:"category" validate:"required,oneof=food drink"`
 	Subcategory ProductSubcategory `json:"subcategory" validate:"required"`
-	Price       float64            `json:"price" validate:"required,min=0"`
+	Price       Money              `json:"price" validate:"required,min=0"`
 	Stock       int                `json:"stock" validate:"min=0"`
 	Description string             `json:"description,omitempty" validate:"max=500"`
 	ImageURL    string             `json:"image_url,omitempty"`
@@ -119,7 +126,7 @@ type UpdateProductRequest struct {
 	Name        string             `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
 	Category    ProductCategory    `json:"category,omitempty" validate:"omitempty,oneof=food drink"`
 	Subcategory ProductSubcategory `json:"subcategory,omitempty"`
-	Price       float64            `json:"price,omitempty" validate:"omitempty,min=0"`
+	Price       Money              `json:"price,omitempty" validate:"omitempty,min=0"`
 	Stock       int                `json:"stock,omitempty" validate:"min=0"`
 	Description string             `json:"description,omitempty" validate:"max=500"`
 	ImageURL    string             `json:"image_url,omitempty"`
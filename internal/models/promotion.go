@@ -0,0 +1,208 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+)
+
+// PromotionTarget limits a promotion to some products. An empty target applies
+// to the whole order; otherwise an item qualifies when it matches any of the
+// listed products, categories or subcategories.
+type PromotionTarget struct {
+	ProductIDs    []primitive.ObjectID `json:"product_ids,omitempty" bson:"product_ids,omitempty"`
+	Categories    []ProductCategory    `json:"categories,omitempty" bson:"categories,omitempty"`
+	Subcategories []ProductSubcategory `json:"subcategories,omitempty" bson:"subcategories,omitempty"`
+}
+
+// IsEmpty reports whether the target applies to every item
+func (t PromotionTarget) IsEmpty() bool {
+	return len(t.ProductIDs) == 0 && len(t.Categories) == 0 && len(t.Subcategories) == 0
+}
+
+// Matches reports whether an order item qualifies for the promotion
+func (t PromotionTarget) Matches(item OrderItem) bool {
+	if t.IsEmpty() {
+		return true
+	}
+	for _, productID := range t.ProductIDs {
+		if item.ProductID == productID {
+			return true
+		}
+	}
+	for _, category := range t.Categories {
+		if item.Category == category {
+			return true
+		}
+	}
+	for _, subcategory := range t.Subcategories {
+		if item.Subcategory == subcategory {
+			return true
+		}
+	}
+	return false
+}
+
+// Promotion represents a promo code customers can redeem on an order
+type Promotion struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty" gorm:"type:objectid;primaryKey;autoIncrement:false"`
+	Code        string             `json:"code" bson:"code" gorm:"uniqueIndex;not null" validate:"required,min=3,max=32"`
+	Name        string             `json:"name" bson:"name" gorm:"not null" validate:"required,min=2,max=100"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty" validate:"max=500"`
+	// Percentage values are in basis points, fixed values in minor units
+	Type             DiscountType    `json:"type" bson:"type" gorm:"not null" validate:"required,oneof=percentage fixed"`
+	Value            int64           `json:"value" bson:"value" gorm:"not null" validate:"required,min=1"`
+	MinimumSpend     Money           `json:"minimum_spend" bson:"minimum_spend" validate:"min=0"`
+	Target           PromotionTarget `json:"target" bson:"target"`
+	StartsAt         *time.Time      `json:"starts_at,omitempty" bson:"starts_at,omitempty"`
+	EndsAt           *time.Time      `json:"ends_at,omitempty" bson:"ends_at,omitempty"`
+	UsageLimit       int             `json:"usage_limit" bson:"usage_limit" validate:"min=0"`
+	PerCustomerLimit int             `json:"per_customer_limit" bson:"per_customer_limit" validate:"min=0"`
+	UsageCount       int             `json:"usage_count" bson:"usage_count"`
+	Active           bool            `json:"active" bson:"active" gorm:"default:true"`
+	CreatedAt        time.Time       `json:"created_at" bson:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at" bson:"updated_at"`
+}
+
+// BeforeCreate hook to set ID and timestamps
+func (p *Promotion) BeforeCreate(tx *gorm.DB) error {
+	if p.ID.IsZero() {
+		p.ID = primitive.NewObjectID()
+	}
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate hook to update timestamp
+func (p *Promotion) BeforeUpdate(tx *gorm.DB) error {
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+// IsRunningAt reports whether the promotion is active and within its validity window at t
+func (p *Promotion) IsRunningAt(t time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartsAt != nil && t.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !t.Before(*p.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// Applied returns the copy of the promotion kept on an order it was redeemed on
+func (p *Promotion) Applied() *AppliedPromotion {
+	return &AppliedPromotion{
+		PromotionID:  p.ID,
+		Code:         p.Code,
+		Discount:     Discount{Type: p.Type, Value: p.Value, Reason: p.Name},
+		MinimumSpend: p.MinimumSpend,
+		Target:       p.Target,
+	}
+}
+
+// PromotionResponse represents promotion data returned to client
+type PromotionResponse struct {
+	ID               string          `json:"id"`
+	Code             string          `json:"code"`
+	Name             string          `json:"name"`
+	Description      string          `json:"description,omitempty"`
+	Type             DiscountType    `json:"type"`
+	Value            int64           `json:"value"`
+	MinimumSpend     Money           `json:"minimum_spend"`
+	Target           PromotionTarget `json:"target"`
+	StartsAt         *time.Time      `json:"starts_at,omitempty"`
+	EndsAt           *time.Time      `json:"ends_at,omitempty"`
+	UsageLimit       int             `json:"usage_limit"`
+	PerCustomerLimit int             `json:"per_customer_limit"`
+	UsageCount       int             `json:"usage_count"`
+	Active           bool            `json:"active"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+// ToResponse converts Promotion to PromotionResponse
+func (p *Promotion) ToResponse() PromotionResponse {
+	return PromotionResponse{
+		ID:               p.ID.Hex(),
+		Code:             p.Code,
+		Name:             p.Name,
+		Description:      p.Description,
+		Type:             p.Type,
+		Value:            p.Value,
+		MinimumSpend:     p.MinimumSpend,
+		Target:           p.Target,
+		StartsAt:         p.StartsAt,
+		EndsAt:           p.EndsAt,
+		UsageLimit:       p.UsageLimit,
+		PerCustomerLimit: p.PerCustomerLimit,
+		UsageCount:       p.UsageCount,
+		Active:           p.Active,
+		CreatedAt:        p.CreatedAt,
+		UpdatedAt:        p.UpdatedAt,
+	}
+}
+
+// AppliedPromotion is the promotion an order was placed with. It keeps the terms
+// at the time of redemption so that later edits to the promotion do not change
+// the price of existing orders.
+type AppliedPromotion struct {
+	PromotionID  primitive.ObjectID `json:"promotion_id" bson:"promotion_id"`
+	Code         string             `json:"code" bson:"code"`
+	Discount     Discount           `json:"discount" bson:"discount"`
+	MinimumSpend Money              `json:"minimum_spend" bson:"minimum_spend"`
+	Target       PromotionTarget    `json:"target" bson:"target"`
+}
+
+// PromotionRedemption records a single use of a promotion by an order
+type PromotionRedemption struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	PromotionID   primitive.ObjectID `json:"promotion_id" bson:"promotion_id"`
+	Code          string             `json:"code" bson:"code"`
+	OrderID       primitive.ObjectID `json:"order_id" bson:"order_id"`
+	CustomerPhone string             `json:"customer_phone" bson:"customer_phone"`
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// PromotionTargetRequest represents promotion targeting in a request
+type PromotionTargetRequest struct {
+	ProductIDs    []string             `json:"product_ids,omitempty"`
+	Categories    []ProductCategory    `json:"categories,omitempty"`
+	Subcategories []ProductSubcategory `json:"subcategories,omitempty"`
+}
+
+// CreatePromotionRequest represents promotion creation request payload
+type CreatePromotionRequest struct {
+	Code             string                 `json:"code" validate:"required,min=3,max=32"`
+	Name             string                 `json:"name" validate:"required,min=2,max=100"`
+	Description      string                 `json:"description,omitempty" validate:"max=500"`
+	Type             DiscountType           `json:"type" validate:"required,oneof=percentage fixed"`
+	Value            int64                  `json:"value" validate:"required,min=1"`
+	MinimumSpend     Money                  `json:"minimum_spend" validate:"min=0"`
+	Target           PromotionTargetRequest `json:"target"`
+	StartsAt         *time.Time             `json:"starts_at,omitempty"`
+	EndsAt           *time.Time             `json:"ends_at,omitempty"`
+	UsageLimit       int                    `json:"usage_limit" validate:"min=0"`
+	PerCustomerLimit int                    `json:"per_customer_limit" validate:"min=0"`
+	Active           *bool                  `json:"active,omitempty"`
+}
+
+// UpdatePromotionRequest represents promotion update request payload
+type UpdatePromotionRequest struct {
+	Name             string                  `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+	Description      string                  `json:"description,omitempty" validate:"max=500"`
+	Type             DiscountType            `json:"type,omitempty" validate:"omitempty,oneof=percentage fixed"`
+	Value            int64                   `json:"value,omitempty" validate:"omitempty,min=1"`
+	MinimumSpend     *Money                  `json:"minimum_spend,omitempty" validate:"omitempty,min=0"`
+	Target           *PromotionTargetRequest `json:"target,omitempty"`
+	StartsAt         *time.Time              `json:"starts_at,omitempty"`
+	EndsAt           *time.Time              `json:"ends_at,omitempty"`
+	UsageLimit       *int                    `json:"usage_limit,omitempty" validate:"omitempty,min=0"`
+	PerCustomerLimit *int                    `json:"per_customer_limit,omitempty" validate:"omitempty,min=0"`
+	Active           *bool                   `json:"active,omitempty"`
+}
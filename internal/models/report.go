@@ -0,0 +1,109 @@
+package models
+
+// ReportRange describes the period a report covers
+type ReportRange struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	TimeZone string `json:"tz"`
+}
+
+// RevenuePoint is the paid revenue for a single day, week or month
+type RevenuePoint struct {
+	Period  string `json:"period" bson:"_id"`
+	Revenue Money  `json:"revenue" bson:"revenue"`
+	Orders  int64  `json:"orders" bson:"orders"`
+}
+
+// RevenueReport represents paid revenue grouped by period
+type RevenueReport struct {
+	Range    ReportRange    `json:"range"`
+	Interval string         `json:"interval"`
+	Currency string         `json:"currency"`
+	Revenue  Money          `json:"revenue"`
+	Orders   int64          `json:"orders"`
+	Points   []RevenuePoint `json:"points"`
+}
+
+// StatusCount is the number of records with a given status
+type StatusCount struct {
+	Status string `json:"status" bson:"_id"`
+	Count  int64  `json:"count" bson:"count"`
+}
+
+// OrderStatusReport represents order counts grouped by status
+type OrderStatusReport struct {
+	Range    ReportRange   `json:"range"`
+	Total    int64         `json:"total"`
+	Statuses []StatusCount `json:"statuses"`
+}
+
+// TopItem is a best-selling item and how much it sold
+type TopItem struct {
+	ProductID string `json:"product_id,omitempty" bson:"product_id,omitempty"`
+	Name      string `json:"name" bson:"name"`
+	Quantity  int64  `json:"quantity" bson:"quantity"`
+	Revenue   Money  `json:"revenue" bson:"revenue"`
+}
+
+// TopItemsReport represents the best-selling items
+type TopItemsReport struct {
+	Range    ReportRange `json:"range"`
+	Currency string      `json:"currency"`
+	Items    []TopItem   `json:"items"`
+}
+
+// AverageTicketReport represents the average value of a paid order
+type AverageTicketReport struct {
+	Range         ReportRange `json:"range"`
+	Currency      string      `json:"currency"`
+	Orders        int64       `json:"orders" bson:"orders"`
+	Revenue       Money       `json:"revenue" bson:"revenue"`
+	AverageTicket Money       `json:"average_ticket"`
+}
+
+// CoversPoint is the number of reservations and guests booked for a day
+type CoversPoint struct {
+	Date         string `json:"date" bson:"_id"`
+	Reservations int64  `json:"reservations" bson:"reservations"`
+	Covers       int64  `json:"covers" bson:"covers"`
+}
+
+// CoversReport represents reservation covers per day
+type CoversReport struct {
+	Range        ReportRange   `json:"range"`
+	Reservations int64         `json:"reservations"`
+	Covers       int64         `json:"covers"`
+	Days         []CoversPoint `json:"days"`
+}
+
+// CancellationRate is the share of records that were cancelled
+type CancellationRate struct {
+	Total     int64   `json:"total" bson:"total"`
+	Cancelled int64   `json:"cancelled" bson:"cancelled"`
+	Rate      float64 `json:"rate"`
+}
+
+// CancellationReport represents cancellation rates across orders, reservations and tickets
+type CancellationReport struct {
+	Range        ReportRange      `json:"range"`
+	Orders       CancellationRate `json:"orders"`
+	Reservations CancellationRate `json:"reservations"`
+	Tickets      CancellationRate `json:"tickets"`
+}
+
+// EventSales is the ticket sales of a single event
+type EventSales struct {
+	EventID     string  `json:"event_id"`
+	Title       string  `json:"title"`
+	Date        string  `json:"date"`
+	Capacity    int     `json:"capacity"`
+	TicketsSold int     `json:"tickets_sold"`
+	SellThrough float64 `json:"sell_through"`
+	Revenue     Money   `json:"revenue"`
+}
+
+// EventSalesReport represents ticket sales for events in the period
+type EventSalesReport struct {
+	Range  ReportRange  `json:"range"`
+	Events []EventSales `json:"events"`
+}
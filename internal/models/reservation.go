@@ -13,23 +13,27 @@ const (
 	ReservationStatusPending   ReservationStatus = "pending"
 	ReservationStatusConfirmed ReservationStatus = "confirmed"
 	ReservationStatusCancelled ReservationStatus = "cancelled"
+	// ReservationStatusWaitlisted marks a booking that could not be seated yet
+	ReservationStatusWaitlisted ReservationStatus = "waitlisted"
 )
 
 // Reservation represents a reservation in the system
 type Reservation struct {
-	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty" gorm:"type:objectid;primaryKey;autoIncrement:false"`
-	UserID          primitive.ObjectID `json:"user_id,omitempty" bson:"user_id,omitempty" gorm:"type:objectid;index"`
-	User            *User              `json:"user,omitempty" bson:"user,omitempty" gorm:"foreignKey:UserID"`
-	CustomerName    string             `json:"customer_name" bson:"customer_name" gorm:"not null" validate:"required,min=2,max=100"`
-	CustomerPhone   string             `json:"customer_phone" bson:"customer_phone" gorm:"not null" validate:"required"`
-	CustomerEmail   string             `json:"customer_email" bson:"customer_email" gorm:"not null" validate:"required,email"`
-	Date            string             `json:"date" bson:"date" gorm:"not null" validate:"required"`
-	Time            string             `json:"time" bson:"time" gorm:"not null" validate:"required"`
-	Guests          int                `json:"guests" bson:"guests" gorm:"not null" validate:"required,min=1,max=20"`
-	SpecialRequests string             `json:"special_requests,omitempty" bson:"special_requests,omitempty"`
-	Status          ReservationStatus  `json:"status" bson:"status" gorm:"not null;default:pending" validate:"required,oneof=pending confirmed cancelled"`
-	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt       time.Time          `json:"updated_at" bson:"updated_at"`
+	ID              primitive.ObjectID   `json:"id" bson:"_id,omitempty" gorm:"type:objectid;primaryKey;autoIncrement:false"`
+	UserID          primitive.ObjectID   `json:"user_id,omitempty" bson:"user_id,omitempty" gorm:"type:objectid;index"`
+	User            *User                `json:"user,omitempty" bson:"user,omitempty" gorm:"foreignKey:UserID"`
+	CustomerID      primitive.ObjectID   `json:"customer_id,omitempty" bson:"customer_id,omitempty" gorm:"type:objectid;index"`
+	CustomerName    string               `json:"customer_name" bson:"customer_name" gorm:"not null" validate:"required,min=2,max=100"`
+	CustomerPhone   string               `json:"customer_phone" bson:"customer_phone" gorm:"not null" validate:"required"`
+	CustomerEmail   string               `json:"customer_email" bson:"customer_email" gorm:"not null" validate:"required,email"`
+	Date            string               `json:"date" bson:"date" gorm:"not null" validate:"required"`
+	Time            string               `json:"time" bson:"time" gorm:"not null" validate:"required"`
+	Guests          int                  `json:"guests" bson:"guests" gorm:"not null" validate:"required,min=1,max=20"`
+	SpecialRequests string               `json:"special_requests,omitempty" bson:"special_requests,omitempty"`
+	TableIDs        []primitive.ObjectID `json:"table_ids,omitempty" bson:"table_ids,omitempty"`
+	Status          ReservationStatus    `json:"status" bson:"status" gorm:"not null;default:pending" validate:"required,oneof=pending confirmed cancelled waitlisted"`
+	CreatedAt       time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at" bson:"updated_at"`
 }
 
 // BeforeCreate hook to set ID and timestamps
@@ -53,6 +57,7 @@ type ReservationResponse struct {
 	ID              string            `json:"id"`
 	UserID          string            `json:"user_id,omitempty"`
 	User            *UserResponse     `json:"user,omitempty"`
+	CustomerID      string            `json:"customer_id,omitempty"`
 	CustomerName    string            `json:"customer_name"`
 	CustomerPhone   string            `json:"customer_phone"`
 	CustomerEmail   string            `json:"customer_email"`
@@ -60,6 +65,7 @@ type ReservationResponse struct {
 	Time            string            `json:"time"`
 	Guests          int               `json:"guests"`
 	SpecialRequests string            `json:"special_requests,omitempty"`
+	TableIDs        []string          `json:"table_ids"`
 	Status          ReservationStatus `json:"status"`
 	CreatedAt       time.Time         `json:"created_at"`
 	UpdatedAt       time.Time         `json:"updated_at"`
@@ -73,7 +79,12 @@ func (r *Reservation) ToResponse() ReservationResponse {
 		userResponse = &userResp
 	}
 
-	return ReservationResponse{
+	tableIDs := []string{}
+	for _, tableID := range r.TableIDs {
+		tableIDs = append(tableIDs, tableID.Hex())
+	}
+
+	response := ReservationResponse{
 		ID:              r.ID.Hex(),
 		UserID:          r.UserID.Hex(),
 		User:            userResponse,
@@ -84,10 +95,15 @@ func (r *Reservation) ToResponse() ReservationResponse {
 		Time:            r.Time,
 		Guests:          r.Guests,
 		SpecialRequests: r.SpecialRequests,
+		TableIDs:        tableIDs,
 		Status:          r.Status,
 		CreatedAt:       r.CreatedAt,
 		UpdatedAt:       r.UpdatedAt,
 	}
+	if !r.CustomerID.IsZero() {
+		response.CustomerID = r.CustomerID.Hex()
+	}
+	return response
 }
 
 // CreateReservationRequest represents reservation creation request payload
@@ -101,6 +117,8 @@ type CreateReservationRequest struct {
 	Guests          int               `json:"guests" validate:"required,min=1,max=20"`
 	SpecialRequests string            `json:"special_requests,omitempty"`
 	Status          ReservationStatus `json:"status,omitempty" validate:"omitempty,oneof=pending confirmed cancelled"`
+	// Waitlist puts the booking on the waitlist instead of rejecting it when no table is free
+	Waitlist bool `json:"waitlist,omitempty"`
 }
 
 // UpdateReservationRequest represents reservation update request payload
@@ -112,5 +130,29 @@ type UpdateReservationRequest struct {
 	Time            string            `json:"time,omitempty"`
 	Guests          int               `json:"guests,omitempty" validate:"omitempty,min=1,max=20"`
 	SpecialRequests string            `json:"special_requests,omitempty"`
-	Status          ReservationStatus `json:"status,omitempty" validate:"omitempty,oneof=pending confirmed cancelled"`
+	Status          ReservationStatus `json:"status,omitempty" validate:"omitempty,oneof=pending confirmed cancelled waitlisted"`
+}
+
+// PublicReservationRequest represents a booking submitted from the customer website
+type PublicReservationRequest struct {
+	CustomerName    string `json:"customer_name" validate:"required,min=2,max=100"`
+	CustomerPhone   string `json:"customer_phone" validate:"required"`
+	CustomerEmail   string `json:"customer_email" validate:"required,email"`
+	Date            string `json:"date" validate:"required"`
+	Time            string `json:"time" validate:"required"`
+	Guests          int    `json:"guests" validate:"required,min=1,max=20"`
+	SpecialRequests string `json:"special_requests,omitempty" validate:"max=500"`
+}
+
+// AvailabilitySlot represents a bookable start time
+type AvailabilitySlot struct {
+	Time      string `json:"time"`
+	Available bool   `json:"available"`
+}
+
+// AvailabilityResponse represents the bookable slots for a party on a date
+type AvailabilityResponse struct {
+	Date   string             `json:"date"`
+	Guests int                `json:"guests"`
+	Slots  []AvailabilitySlot `json:"slots"`
 }
@@ -0,0 +1,291 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+)
+
+// Station is a place where order items are prepared, such as the kitchen, the
+// grill or the bar. Items are routed to a station by their product's
+// subcategory, or failing that its category; items matching no station go to
+// the default station.
+type Station struct {
+	ID            primitive.ObjectID   `json:"id" bson:"_id,omitempty" gorm:"type:objectid;primaryKey;autoIncrement:false"`
+	Code          string               `json:"code" bson:"code" gorm:"uniqueIndex;not null" validate:"required,min=2,max=30"`
+	Name          string               `json:"name" bson:"name" gorm:"not null" validate:"required,min=2,max=50"`
+	Categories    []ProductCategory    `json:"categories" bson:"categories"`
+	Subcategories []ProductSubcategory `json:"subcategories" bson:"subcategories"`
+	IsDefault     bool                 `json:"is_default" bson:"is_default"`
+	Active        bool                 `json:"active" bson:"active" gorm:"default:true"`
+	CreatedAt     time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at" bson:"updated_at"`
+}
+
+// BeforeCreate hook to set ID and timestamps
+func (s *Station) BeforeCreate(tx *gorm.DB) error {
+	if s.ID.IsZero() {
+		s.ID = primitive.NewObjectID()
+	}
+	now := time.Now()
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	return nil
+}
+
+// BeforeUpdate hook to update timestamp
+func (s *Station) BeforeUpdate(tx *gorm.DB) error {
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// StationResponse represents station data returned to the client
+type StationResponse struct {
+	ID            string               `json:"id"`
+	Code          string               `json:"code"`
+	Name          string               `json:"name"`
+	Categories    []ProductCategory    `json:"categories"`
+	Subcategories []ProductSubcategory `json:"subcategories"`
+	IsDefault     bool                 `json:"is_default"`
+	Active        bool                 `json:"active"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+}
+
+// ToResponse converts Station to StationResponse
+func (s *Station) ToResponse() StationResponse {
+	return StationResponse{
+		ID:            s.ID.Hex(),
+		Code:          s.Code,
+		Name:          s.Name,
+		Categories:    s.Categories,
+		Subcategories: s.Subcategories,
+		IsDefault:     s.IsDefault,
+		Active:        s.Active,
+		CreatedAt:     s.CreatedAt,
+		UpdatedAt:     s.UpdatedAt,
+	}
+}
+
+// CreateStationRequest represents station creation request payload
+type CreateStationRequest struct {
+	Code          string               `json:"code" validate:"required,min=2,max=30"`
+	Name          string               `json:"name" validate:"required,min=2,max=50"`
+	Categories    []ProductCategory    `json:"categories,omitempty"`
+	Subcategories []ProductSubcategory `json:"subcategories,omitempty"`
+	IsDefault     bool                 `json:"is_default"`
+	Active        *bool                `json:"active,omitempty"`
+}
+
+// UpdateStationRequest represents station update request payload
+type UpdateStationRequest struct {
+	Name          string                `json:"name,omitempty" validate:"omitempty,min=2,max=50"`
+	Categories    *[]ProductCategory    `json:"categories,omitempty"`
+	Subcategories *[]ProductSubcategory `json:"subcategories,omitempty"`
+	IsDefault     *bool                 `json:"is_default,omitempty"`
+	Active        *bool                 `json:"active,omitempty"`
+}
+
+// StationFor returns the code of the station that prepares a product, or an
+// empty string when no active station takes it
+func StationFor(stations []Station, category ProductCategory, subcategory ProductSubcategory) string {
+	var byCategory, fallback string
+	for _, station := range stations {
+		if !station.Active {
+			continue
+		}
+		for _, s := range station.Subcategories {
+			if s == subcategory {
+				return station.Code
+			}
+		}
+		for _, c := range station.Categories {
+			if c == category && byCategory == "" {
+				byCategory = station.Code
+			}
+		}
+		if station.IsDefault && fallback == "" {
+			fallback = station.Code
+		}
+	}
+	if byCategory != "" {
+		return byCategory
+	}
+	return fallback
+}
+
+// StationTicketStatus represents how far a station has got with a ticket
+type StationTicketStatus string
+
+const (
+	StationTicketQueued    StationTicketStatus = "queued"
+	StationTicketPreparing StationTicketStatus = "preparing"
+	StationTicketReady     StationTicketStatus = "ready"
+)
+
+// stationTicketTransitions defines which statuses a ticket may move to from each status
+var stationTicketTransitions = map[StationTicketStatus][]StationTicketStatus{
+	StationTicketQueued:    {StationTicketPreparing, StationTicketReady},
+	StationTicketPreparing: {StationTicketReady},
+}
+
+// CanTransitionTo reports whether a ticket in this status may move to next
+func (s StationTicketStatus) CanTransitionTo(next StationTicketStatus) bool {
+	for _, allowed := range stationTicketTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// StationTicket is the part of an order prepared by one station. Items added
+// after a station has started on its ticket go on a new ticket.
+type StationTicket struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id"`
+	Station   string              `json:"station" bson:"station"`
+	Status    StationTicketStatus `json:"status" bson:"status"`
+	CreatedAt time.Time           `json:"created_at" bson:"created_at"`
+	StartedAt *time.Time          `json:"started_at,omitempty" bson:"started_at,omitempty"`
+	ReadyAt   *time.Time          `json:"ready_at,omitempty" bson:"ready_at,omitempty"`
+}
+
+// StationTicketResponse represents a ticket with its order details as shown at a station
+type StationTicketResponse struct {
+	ID             string              `json:"id"`
+	OrderID        string              `json:"order_id"`
+	OrderNumber    string              `json:"order_number"`
+	CustomerName   string              `json:"customer_name"`
+	OrderType      OrderType           `json:"order_type"`
+	TableNumber    int                 `json:"table_number,omitempty"`
+	PickupTime     *time.Time          `json:"pickup_time,omitempty"`
+	SpecialRequest string              `json:"special_request,omitempty"`
+	Station        string              `json:"station"`
+	Status         StationTicketStatus `json:"status"`
+	Items          []OrderItem         `json:"items"`
+	CreatedAt      time.Time           `json:"created_at"`
+	StartedAt      *time.Time          `json:"started_at,omitempty"`
+	ReadyAt        *time.Time          `json:"ready_at,omitempty"`
+}
+
+// UpdateStationTicketRequest represents a station ticket status change payload
+type UpdateStationTicketRequest struct {
+	Status StationTicketStatus `json:"status" validate:"required,oneof=preparing ready"`
+}
+
+// Ticket returns the ticket with the given ID, or nil
+func (o *Order) Ticket(ticketID primitive.ObjectID) *StationTicket {
+	for i := range o.Tickets {
+		if o.Tickets[i].ID == ticketID {
+			return &o.Tickets[i]
+		}
+	}
+	return nil
+}
+
+// TicketResponse converts a ticket of the order to a StationTicketResponse
+func (o *Order) TicketResponse(ticket *StationTicket) StationTicketResponse {
+	items := []OrderItem{}
+	for _, item := range o.Items {
+		if item.TicketID == ticket.ID {
+			items = append(items, item)
+		}
+	}
+
+	return StationTicketResponse{
+		ID:             ticket.ID.Hex(),
+		OrderID:        o.ID.Hex(),
+		OrderNumber:    o.OrderNumber,
+		CustomerName:   o.CustomerName,
+		OrderType:      o.OrderType,
+		TableNumber:    o.TableNumber,
+		PickupTime:     o.PickupTime,
+		SpecialRequest: o.SpecialRequest,
+		Station:        ticket.Station,
+		Status:         ticket.Status,
+		Items:          items,
+		CreatedAt:      ticket.CreatedAt,
+		StartedAt:      ticket.StartedAt,
+		ReadyAt:        ticket.ReadyAt,
+	}
+}
+
+// AssignTickets puts items routed to a station but not yet on a ticket on the
+// queued ticket of their station, opening one if the station has none, and
+// drops tickets left with only voided items
+func (o *Order) AssignTickets(now time.Time) {
+	for i := range o.Items {
+		item := &o.Items[i]
+		if item.Station == "" || !item.TicketID.IsZero() || item.Voided {
+			continue
+		}
+
+		var ticket *StationTicket
+		for j := range o.Tickets {
+			if o.Tickets[j].Station == item.Station && o.Tickets[j].Status == StationTicketQueued {
+				ticket = &o.Tickets[j]
+				break
+			}
+		}
+		if ticket == nil {
+			o.Tickets = append(o.Tickets, StationTicket{
+				ID:        primitive.NewObjectID(),
+				Station:   item.Station,
+				Status:    StationTicketQueued,
+				CreatedAt: now,
+			})
+			ticket = &o.Tickets[len(o.Tickets)-1]
+		}
+		item.TicketID = ticket.ID
+	}
+
+	live := make(map[primitive.ObjectID]bool)
+	for _, item := range o.Items {
+		if !item.Voided && !item.TicketID.IsZero() {
+			live[item.TicketID] = true
+		}
+	}
+	tickets := o.Tickets[:0]
+	for _, ticket := range o.Tickets {
+		if live[ticket.ID] {
+			tickets = append(tickets, ticket)
+		}
+	}
+	o.Tickets = tickets
+}
+
+// SyncStatusWithTickets moves a confirmed order to ready once every ticket is
+// ready, and back to confirmed when a ready order gets a new ticket. Pending
+// orders wait to be confirmed. It returns the status change made, if any.
+func (o *Order) SyncStatusWithTickets(userID primitive.ObjectID, now time.Time) *OrderStatusChange {
+	if len(o.Tickets) == 0 {
+		return nil
+	}
+	ready := true
+	for _, ticket := range o.Tickets {
+		if ticket.Status != StationTicketReady {
+			ready = false
+			break
+		}
+	}
+
+	var next OrderStatus
+	var reason string
+	switch {
+	case ready && o.Status == OrderStatusConfirmed:
+		next, reason = OrderStatusReady, "All station tickets are ready"
+	case !ready && o.Status == OrderStatusReady:
+		next, reason = OrderStatusConfirmed, "New items for the stations"
+	default:
+		return nil
+	}
+	if !o.CanTransitionTo(next) {
+		return nil
+	}
+
+	change := OrderStatusChange{From: o.Status, To: next, ChangedBy: userID, ChangedAt: now, Reason: reason}
+	o.Status = next
+	o.StatusHistory = append(o.StatusHistory, change)
+	return &change
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+)
+
+type TableArea string
+
+const (
+	AreaIndoor  TableArea = "indoor"
+	AreaTerrace TableArea = "terrace"
+)
+
+// Table represents a table on the restaurant floor plan
+type Table struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty" gorm:"type:objectid;primaryKey;autoIncrement:false"`
+	Number     int                `json:"number" bson:"number" gorm:"uniqueIndex;not null" validate:"required,min=1"`
+	Seats      int                `json:"seats" bson:"seats" gorm:"not null" validate:"required,min=1"`
+	Area       TableArea          `json:"area" bson:"area" gorm:"not null" validate:"required,oneof=indoor terrace"`
+	Combinable bool               `json:"combinable" bson:"combinable" gorm:"default:false"`
+	Active     bool               `json:"active" bson:"active" gorm:"default:true"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// BeforeCreate hook to set ID and timestamps
+func (t *Table) BeforeCreate(tx *gorm.DB) error {
+	if t.ID.IsZero() {
+		t.ID = primitive.NewObjectID()
+	}
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate hook to update timestamp
+func (t *Table) BeforeUpdate(tx *gorm.DB) error {
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// TableResponse represents table data returned to client
+type TableResponse struct {
+	ID         string    `json:"id"`
+	Number     int       `json:"number"`
+	Seats      int       `json:"seats"`
+	Area       TableArea `json:"area"`
+	Combinable bool      `json:"combinable"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ToResponse converts Table to TableResponse
+func (t *Table) ToResponse() TableResponse {
+	return TableResponse{
+		ID:         t.ID.Hex(),
+		Number:     t.Number,
+		Seats:      t.Seats,
+		Area:       t.Area,
+		Combinable: t.Combinable,
+		Active:     t.Active,
+		CreatedAt:  t.CreatedAt,
+		UpdatedAt:  t.UpdatedAt,
+	}
+}
+
+// CreateTableRequest represents table creation request payload
+type CreateTableRequest struct {
+	Number     int       `json:"number" validate:"required,min=1"`
+	Seats      int       `json:"seats" validate:"required,min=1"`
+	Area       TableArea `json:"area" validate:"required,oneof=indoor terrace"`
+	Combinable bool      `json:"combinable"`
+	Active     *bool     `json:"active,omitempty"`
+}
+
+// UpdateTableRequest represents table update request payload
+type UpdateTableRequest struct {
+	Number     int       `json:"number,omitempty" validate:"omitempty,min=1"`
+	Seats      int       `json:"seats,omitempty" validate:"omitempty,min=1"`
+	Area       TableArea `json:"area,omitempty" validate:"omitempty,oneof=indoor terrace"`
+	Combinable *bool     `json:"combinable,omitempty"`
+	Active     *bool     `json:"active,omitempty"`
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+)
+
+type TicketStatus string
+
+const (
+	TicketStatusValid     TicketStatus = "valid"
+	TicketStatusUsed      TicketStatus = "used"
+	TicketStatusCancelled TicketStatus = "cancelled"
+	TicketStatusRefunded  TicketStatus = "refunded"
+)
+
+// Ticket represents a single admission to an event
+type Ticket struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty" gorm:"type:objectid;primaryKey;autoIncrement:false"`
+	EventID      primitive.ObjectID `json:"event_id" bson:"event_id" gorm:"type:objectid;index"`
+	Code         string             `json:"code" bson:"code" gorm:"uniqueIndex;not null"`
+	HolderName   string             `json:"holder_name" bson:"holder_name" gorm:"not null" validate:"required,min=2,max=100"`
+	HolderEmail  string             `json:"holder_email,omitempty" bson:"holder_email,omitempty"`
+	HolderPhone  string             `json:"holder_phone,omitempty" bson:"holder_phone,omitempty"`
+	Price        Money              `json:"price" bson:"price"`
+	Status       TicketStatus       `json:"status" bson:"status" gorm:"not null;default:valid" validate:"required,oneof=valid used cancelled refunded"`
+	CheckedInAt  *time.Time         `json:"checked_in_at,omitempty" bson:"checked_in_at,omitempty"`
+	CheckedInBy  primitive.ObjectID `json:"checked_in_by,omitempty" bson:"checked_in_by,omitempty"`
+	CancelledAt  *time.Time         `json:"cancelled_at,omitempty" bson:"cancelled_at,omitempty"`
+	CancelReason string             `json:"cancel_reason,omitempty" bson:"cancel_reason,omitempty"`
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// BeforeCreate hook to set ID and timestamps
+func (t *Ticket) BeforeCreate(tx *gorm.DB) error {
+	if t.ID.IsZero() {
+		t.ID = primitive.NewObjectID()
+	}
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate hook to update timestamp
+func (t *Ticket) BeforeUpdate(tx *gorm.DB) error {
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// TicketResponse represents ticket data returned to client
+type TicketResponse struct {
+	ID           string       `json:"id"`
+	EventID      string       `json:"event_id"`
+	Code         string       `json:"code"`
+	HolderName   string       `json:"holder_name"`
+	HolderEmail  string       `json:"holder_email,omitempty"`
+	HolderPhone  string       `json:"holder_phone,omitempty"`
+	Price        Money        `json:"price"`
+	Status       TicketStatus `json:"status"`
+	CheckedInAt  *time.Time   `json:"checked_in_at,omitempty"`
+	CancelledAt  *time.Time   `json:"cancelled_at,omitempty"`
+	CancelReason string       `json:"cancel_reason,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// ToResponse converts Ticket to TicketResponse
+func (t *Ticket) ToResponse() TicketResponse {
+	return TicketResponse{
+		ID:           t.ID.Hex(),
+		EventID:      t.EventID.Hex(),
+		Code:         t.Code,
+		HolderName:   t.HolderName,
+		HolderEmail:  t.HolderEmail,
+		HolderPhone:  t.HolderPhone,
+		Price:        t.Price,
+		Status:       t.Status,
+		CheckedInAt:  t.CheckedInAt,
+		CancelledAt:  t.CancelledAt,
+		CancelReason: t.CancelReason,
+		CreatedAt:    t.CreatedAt,
+		UpdatedAt:    t.UpdatedAt,
+	}
+}
+
+// IssueTicketsRequest represents a ticket sale request payload
+type IssueTicketsRequest struct {
+	HolderName  string `json:"holder_name" validate:"required,min=2,max=100"`
+	HolderEmail string `json:"holder_email,omitempty" validate:"omitempty,email"`
+	HolderPhone string `json:"holder_phone,omitempty"`
+	Quantity    int    `json:"quantity" validate:"required,min=1,max=20"`
+}
+
+// CancelTicketRequest represents a ticket cancellation request payload
+type CancelTicketRequest struct {
+	Refund bool   `json:"refund"`
+	Reason string `json:"reason,omitempty" validate:"max=500"`
+}
+
+// CheckInTicketRequest represents a door check-in request payload
+type CheckInTicketRequest struct {
+	Code string `json:"code" validate:"required"`
+}
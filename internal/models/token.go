@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshToken represents a long-lived token used to obtain new access tokens.
+// Only a hash of the token is stored. Tokens are single-use: each refresh revokes
+// the presented token and issues a replacement in the same family.
+type RefreshToken struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID     primitive.ObjectID `json:"user_id" bson:"user_id"`
+	FamilyID   primitive.ObjectID `json:"family_id" bson:"family_id"`
+	TokenHash  string             `json:"-" bson:"token_hash"`
+	UserAgent  string             `json:"user_agent,omitempty" bson:"user_agent,omitempty"`
+	IPAddress  string             `json:"ip_address,omitempty" bson:"ip_address,omitempty"`
+	ExpiresAt  time.Time          `json:"expires_at" bson:"expires_at"`
+	RevokedAt  *time.Time         `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+	ReplacedBy primitive.ObjectID `json:"replaced_by,omitempty" bson:"replaced_by,omitempty"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// RevokedToken records an access token revoked before its expiry, keyed by its jti
+type RevokedToken struct {
+	ID        string             `json:"id" bson:"_id"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	ExpiresAt time.Time          `json:"expires_at" bson:"expires_at"`
+	RevokedAt time.Time          `json:"revoked_at" bson:"revoked_at"`
+}
+
+// RefreshTokenRequest represents token refresh request payload
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// LogoutRequest represents logout request payload
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+	AllDevices   bool   `json:"all_devices,omitempty"`
+}
@@ -140,6 +140,7 @@ type ProfilePermissions struct {
 	CanManageRoles    bool     `json:"can_manage_roles"`
 	CanManageSystem   bool     `json:"can_manage_system"`
 	AccessPermissions []string `json:"access_permissions"`
+	Granted           []string `json:"granted"`
 }
 
 // ProfileResponse represents comprehensive profile data for UserProfile.vue
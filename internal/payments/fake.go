@@ -0,0 +1,122 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"vibanda-village-admin-backend/internal/config"
+	"vibanda-village-admin-backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeCallbackDelay is how long the fake customer takes to answer the prompt
+const fakeCallbackDelay = 3 * time.Second
+
+// FakeProvider stands in for M-Pesa during development. It accepts every
+// payment request and, after a short delay, posts an M-Pesa style callback to
+// the callback URL. Payments succeed unless the phone number ends in 1, which
+// simulates the customer cancelling the prompt.
+type FakeProvider struct {
+	callbackURL   string
+	callbackToken string
+	client        *http.Client
+}
+
+// NewFakeProvider creates a fake provider that reports to PAYMENT_CALLBACK_URL.
+// Outside debug mode PAYMENT_CALLBACK_TOKEN is required; in debug mode a random
+// token is made up when it is not set.
+func NewFakeProvider(cfg *config.Config) (*FakeProvider, error) {
+	token := cfg.PaymentCallbackToken
+	if token == "" {
+		if cfg.GinMode != gin.DebugMode {
+			return nil, errors.New("PAYMENT_CALLBACK_TOKEN is required for the fake provider outside debug mode")
+		}
+		code, err := utils.GenerateCode(32)
+		if err != nil {
+			return nil, err
+		}
+		token = code
+	}
+
+	return &FakeProvider{
+		callbackURL:   cfg.PaymentCallbackURL,
+		callbackToken: token,
+		client:        &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Name identifies the provider on payment records
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+// InitiatePayment accepts the request and schedules its callback
+func (p *FakeProvider) InitiatePayment(ctx context.Context, req PaymentRequest) (*PaymentInitiation, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be greater than zero", ErrInvalidAmount)
+	}
+	code, err := utils.GenerateCode(10)
+	if err != nil {
+		return nil, err
+	}
+	checkoutID := "ws_CO_FAKE_" + code
+
+	go p.sendCallback(checkoutID, req)
+
+	return &PaymentInitiation{CheckoutID: checkoutID, CustomerMessage: "Success. Request accepted for processing"}, nil
+}
+
+// sendCallback reports the outcome of a fake payment the way M-Pesa would
+func (p *FakeProvider) sendCallback(checkoutID string, req PaymentRequest) {
+	time.Sleep(fakeCallbackDelay)
+
+	stk := map[string]interface{}{
+		"MerchantRequestID": "FAKE",
+		"CheckoutRequestID": checkoutID,
+		"ResultCode":        0,
+		"ResultDesc":        "The service request is processed successfully.",
+	}
+	if strings.HasSuffix(req.Phone, "1") {
+		stk["ResultCode"] = 1032
+		stk["ResultDesc"] = "Request cancelled by user"
+	} else {
+		receipt, _ := utils.GenerateCode(10)
+		stk["CallbackMetadata"] = map[string]interface{}{
+			"Item": []map[string]interface{}{
+				{"Name": "Amount", "Value": float64(req.Amount) / 100},
+				{"Name": "MpesaReceiptNumber", "Value": receipt},
+				{"Name": "TransactionDate", "Value": time.Now().Format("20060102150405")},
+				{"Name": "PhoneNumber", "Value": req.Phone},
+			},
+		}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"Body": map[string]interface{}{"stkCallback": stk}})
+	if err != nil {
+		log.Println("Fake payment provider failed to encode callback:", err)
+		return
+	}
+	resp, err := p.client.Post(callbackURL(p.callbackURL, p.callbackToken, req.PaymentID), "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Println("Fake payment provider failed to send callback:", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// ParseCallback verifies the callback token and reads the M-Pesa style result
+func (p *FakeProvider) ParseCallback(r *http.Request) (*PaymentResult, error) {
+	return parseCallback(r, p.callbackToken)
+}
+
+// CallbackResponse mirrors the acknowledgement M-Pesa expects
+func (p *FakeProvider) CallbackResponse(accepted bool) interface{} {
+	return mpesaCallbackResponse(accepted)
+}
@@ -0,0 +1,316 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"vibanda-village-admin-backend/internal/config"
+	"vibanda-village-admin-backend/internal/models"
+)
+
+var mpesaBaseURLs = map[string]string{
+	"sandbox":    "https://sandbox.safaricom.co.ke",
+	"production": "https://api.safaricom.co.ke",
+}
+
+// MpesaProvider requests payments with M-Pesa Express (STK push) through the
+// Safaricom Daraja API
+type MpesaProvider struct {
+	baseURL        string
+	consumerKey    string
+	consumerSecret string
+	shortCode      string
+	passkey        string
+	callbackURL    string
+	callbackToken  string
+	client         *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewMpesaProvider creates an M-Pesa provider from the MPESA_* settings
+func NewMpesaProvider(cfg *config.Config) (*MpesaProvider, error) {
+	baseURL, ok := mpesaBaseURLs[cfg.MpesaEnvironment]
+	if !ok {
+		return nil, fmt.Errorf("unknown M-Pesa environment %q", cfg.MpesaEnvironment)
+	}
+	if cfg.MpesaConsumerKey == "" || cfg.MpesaConsumerSecret == "" || cfg.MpesaShortCode == "" || cfg.MpesaPasskey == "" {
+		return nil, errors.New("MPESA_CONSUMER_KEY, MPESA_CONSUMER_SECRET, MPESA_SHORTCODE and MPESA_PASSKEY are required")
+	}
+	if cfg.PaymentCallbackToken == "" {
+		return nil, errors.New("PAYMENT_CALLBACK_TOKEN is required for M-Pesa")
+	}
+
+	return &MpesaProvider{
+		baseURL:        baseURL,
+		consumerKey:    cfg.MpesaConsumerKey,
+		consumerSecret: cfg.MpesaConsumerSecret,
+		shortCode:      cfg.MpesaShortCode,
+		passkey:        cfg.MpesaPasskey,
+		callbackURL:    cfg.PaymentCallbackURL,
+		callbackToken:  cfg.PaymentCallbackToken,
+		client:         &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Name identifies the provider on payment records
+func (p *MpesaProvider) Name() string {
+	return "mpesa"
+}
+
+// token returns an OAuth access token, reusing it until shortly before it expires
+func (p *MpesaProvider) token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.accessToken != "" && time.Now().Before(p.expiresAt) {
+		return p.accessToken, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/oauth/v1/generate?grant_type=client_credentials", nil)
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(p.consumerKey, p.consumerSecret)
+
+	var response struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   string `json:"expires_in"`
+	}
+	if err := p.do(req, &response); err != nil {
+		return "", fmt.Errorf("M-Pesa authentication failed: %w", err)
+	}
+
+	expiresIn, err := strconv.Atoi(response.ExpiresIn)
+	if err != nil || expiresIn <= 60 {
+		expiresIn = 120
+	}
+	p.accessToken = response.AccessToken
+	p.expiresAt = time.Now().Add(time.Duration(expiresIn-60) * time.Second)
+	return p.accessToken, nil
+}
+
+// do sends a request and decodes a successful JSON response
+func (p *MpesaProvider) do(req *http.Request, out interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiError struct {
+			ErrorMessage string `json:"errorMessage"`
+		}
+		if json.Unmarshal(body, &apiError) == nil && apiError.ErrorMessage != "" {
+			return fmt.Errorf("%s (HTTP %d)", apiError.ErrorMessage, resp.StatusCode)
+		}
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// mpesaPhone converts a Kenyan phone number to the 2547XXXXXXXX form M-Pesa expects
+func mpesaPhone(phone string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+	switch {
+	case len(digits) == 12 && strings.HasPrefix(digits, "254"):
+		return digits, nil
+	case len(digits) == 10 && strings.HasPrefix(digits, "0"):
+		return "254" + digits[1:], nil
+	case len(digits) == 9:
+		return "254" + digits, nil
+	default:
+		return "", fmt.Errorf("invalid M-Pesa phone number %q", phone)
+	}
+}
+
+// InitiatePayment sends an STK push prompting the customer to pay. M-Pesa only
+// charges whole shillings, so the amount must not have cents.
+func (p *MpesaProvider) InitiatePayment(ctx context.Context, req PaymentRequest) (*PaymentInitiation, error) {
+	if req.Amount <= 0 || req.Amount%100 != 0 {
+		return nil, fmt.Errorf("%w: M-Pesa only accepts whole shillings", ErrInvalidAmount)
+	}
+	phone, err := mpesaPhone(req.Phone)
+	if err != nil {
+		return nil, err
+	}
+	reference, err := mpesaAccountReference(req.Reference)
+	if err != nil {
+		return nil, err
+	}
+	// The description is shown on the prompt; the reference stands in for one
+	// that does not fit
+	description := req.Description
+	if len(description) > mpesaDescriptionLength {
+		description = reference
+	}
+
+	token, err := p.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := time.Now().In(nairobi).Format("20060102150405")
+	payload := map[string]interface{}{
+		"BusinessShortCode": p.shortCode,
+		"Password":          base64.StdEncoding.EncodeToString([]byte(p.shortCode + p.passkey + timestamp)),
+		"Timestamp":         timestamp,
+		"TransactionType":   "CustomerPayBillOnline",
+		"Amount":            int64(req.Amount / 100),
+		"PartyA":            phone,
+		"PartyB":            p.shortCode,
+		"PhoneNumber":       phone,
+		"CallBackURL":       callbackURL(p.callbackURL, p.callbackToken, req.PaymentID),
+		"AccountReference":  reference,
+		"TransactionDesc":   description,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/mpesa/stkpush/v1/processrequest", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	var response struct {
+		CheckoutRequestID   string `json:"CheckoutRequestID"`
+		ResponseCode        string `json:"ResponseCode"`
+		ResponseDescription string `json:"ResponseDescription"`
+		CustomerMessage     string `json:"CustomerMessage"`
+	}
+	if err := p.do(httpReq, &response); err != nil {
+		return nil, fmt.Errorf("M-Pesa STK push failed: %w", err)
+	}
+	if response.ResponseCode != "0" {
+		return nil, fmt.Errorf("M-Pesa STK push rejected: %s", response.ResponseDescription)
+	}
+
+	return &PaymentInitiation{CheckoutID: response.CheckoutRequestID, CustomerMessage: response.CustomerMessage}, nil
+}
+
+// mpesaCallback is the body Daraja posts to the callback URL
+type mpesaCallback struct {
+	Body struct {
+		StkCallback struct {
+			MerchantRequestID string `json:"MerchantRequestID"`
+			CheckoutRequestID string `json:"CheckoutRequestID"`
+			ResultCode        int    `json:"ResultCode"`
+			ResultDesc        string `json:"ResultDesc"`
+			CallbackMetadata  struct {
+				Item []struct {
+					Name  string      `json:"Name"`
+					Value interface{} `json:"Value"`
+				} `json:"Item"`
+			} `json:"CallbackMetadata"`
+		} `json:"stkCallback"`
+	} `json:"Body"`
+}
+
+// ParseCallback verifies the callback token and reads the STK push result
+func (p *MpesaProvider) ParseCallback(r *http.Request) (*PaymentResult, error) {
+	return parseCallback(r, p.callbackToken)
+}
+
+// parseMpesaCallback reads a Daraja STK push callback body
+func parseMpesaCallback(r *http.Request) (*PaymentResult, error) {
+	var callback mpesaCallback
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&callback); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCallback, err)
+	}
+
+	stk := callback.Body.StkCallback
+	if stk.CheckoutRequestID == "" {
+		return nil, fmt.Errorf("%w: missing CheckoutRequestID", ErrInvalidCallback)
+	}
+
+	result := &PaymentResult{
+		CheckoutID:  stk.CheckoutRequestID,
+		Success:     stk.ResultCode == 0,
+		Description: stk.ResultDesc,
+	}
+	for _, item := range stk.CallbackMetadata.Item {
+		switch item.Name {
+		case "Amount":
+			if amount, ok := item.Value.(float64); ok {
+				result.Amount = models.Money(math.Round(amount * 100))
+			}
+		case "MpesaReceiptNumber":
+			result.Receipt = fmt.Sprint(item.Value)
+		case "PhoneNumber":
+			switch phone := item.Value.(type) {
+			case float64:
+				result.Phone = strconv.FormatFloat(phone, 'f', 0, 64)
+			case string:
+				result.Phone = phone
+			}
+		}
+	}
+	return result, nil
+}
+
+// CallbackResponse is the acknowledgement Daraja expects
+func (p *MpesaProvider) CallbackResponse(accepted bool) interface{} {
+	return mpesaCallbackResponse(accepted)
+}
+
+func mpesaCallbackResponse(accepted bool) interface{} {
+	if accepted {
+		return map[string]interface{}{"ResultCode": 0, "ResultDesc": "Accepted"}
+	}
+	return map[string]interface{}{"ResultCode": 1, "ResultDesc": "Rejected"}
+}
+
+// nairobi is the time zone Daraja expects timestamps in
+var nairobi = time.FixedZone("EAT", 3*60*60)
+
+// Daraja limits the length of the account reference and transaction description
+const (
+	mpesaReferenceLength   = 12
+	mpesaDescriptionLength = 13
+)
+
+// mpesaAccountReference returns the account reference shown to the customer
+// for a payment. References that are too long keep only their digits, so that
+// VV-20240115-0042 becomes 202401150042 and still identifies the order. It
+// fails rather than cut a reference that still does not fit.
+func mpesaAccountReference(reference string) (string, error) {
+	if len(reference) <= mpesaReferenceLength {
+		return reference, nil
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, reference)
+	if digits == "" || len(digits) > mpesaReferenceLength {
+		return "", fmt.Errorf("reference %q does not fit the %d characters M-Pesa allows", reference, mpesaReferenceLength)
+	}
+	return digits, nil
+}
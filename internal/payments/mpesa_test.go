@@ -0,0 +1,29 @@
+package payments
+
+import "testing"
+
+func TestMpesaAccountReference(t *testing.T) {
+	tests := []struct {
+		reference string
+		want      string
+		wantErr   bool
+	}{
+		{"VV-20261016-0042", "202610160042", false},
+		{"VV-20261016-0001", "202610160001", false},
+		{"ORD-1700000000", "1700000000", false},
+		{"ORD-1700000000-2", "17000000002", false},
+		{"SHORT-REF", "SHORT-REF", false},
+		{"VV-20261016-10000", "", true},
+		{"NO-DIGITS-AT-ALL", "", true},
+	}
+	for _, tt := range tests {
+		got, err := mpesaAccountReference(tt.reference)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("mpesaAccountReference(%q) error = %v, want error %t", tt.reference, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("mpesaAccountReference(%q) = %q, want %q", tt.reference, got, tt.want)
+		}
+	}
+}
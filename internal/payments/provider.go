@@ -0,0 +1,134 @@
+// Package payments integrates with mobile money payment providers. A provider
+// asks the customer to approve a payment on their phone and later reports the
+// outcome to the callback endpoint.
+package payments
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"vibanda-village-admin-backend/internal/config"
+	"vibanda-village-admin-backend/internal/models"
+)
+
+var (
+	// ErrInvalidCallback is returned for callbacks that cannot be parsed or verified
+	ErrInvalidCallback = errors.New("invalid payment callback")
+	// ErrInvalidAmount is returned for amounts the provider cannot charge
+	ErrInvalidAmount = errors.New("invalid payment amount")
+)
+
+// PaymentRequest is a payment to be approved by the customer
+type PaymentRequest struct {
+	// Reference identifies the payment to the customer, e.g. the order number
+	Reference   string
+	Phone       string
+	Amount      models.Money
+	Description string
+	// PaymentID is added to the callback URL, so that the callback can be matched
+	// to the payment even if it arrives before the checkout ID is saved
+	PaymentID string
+}
+
+// PaymentInitiation is the provider's acknowledgement of a payment request
+type PaymentInitiation struct {
+	// CheckoutID identifies the request in the callback reporting its outcome
+	CheckoutID      string
+	CustomerMessage string
+}
+
+// PaymentResult is the outcome of a payment request reported by a callback
+type PaymentResult struct {
+	CheckoutID  string
+	Success     bool
+	Description string
+	Amount      models.Money
+	// Receipt is the provider's transaction code, e.g. an M-Pesa receipt number
+	Receipt string
+	Phone   string
+	// PaymentID is the payment the callback URL was given for, if any
+	PaymentID string
+}
+
+// PaymentProvider is a mobile money payment service
+type PaymentProvider interface {
+	// Name identifies the provider on payment records
+	Name() string
+	// InitiatePayment sends the customer a prompt to approve the payment
+	InitiatePayment(ctx context.Context, req PaymentRequest) (*PaymentInitiation, error)
+	// ParseCallback verifies a callback request and returns the result it reports
+	ParseCallback(r *http.Request) (*PaymentResult, error)
+	// CallbackResponse is the body the provider expects in reply to a callback
+	CallbackResponse(accepted bool) interface{}
+}
+
+// Provider is the payment provider selected by configuration, or nil when
+// mobile payments are not configured
+var Provider PaymentProvider
+
+// Init selects the payment provider named by PAYMENT_PROVIDER. Mobile payments
+// stay off when it is unset, so that the fake provider is never used by accident.
+func Init(cfg *config.Config) error {
+	switch cfg.PaymentProvider {
+	case "mpesa":
+		provider, err := NewMpesaProvider(cfg)
+		if err != nil {
+			return err
+		}
+		Provider = provider
+	case "fake":
+		provider, err := NewFakeProvider(cfg)
+		if err != nil {
+			return err
+		}
+		Provider = provider
+	case "":
+		Provider = nil
+	default:
+		return fmt.Errorf("unknown payment provider %q", cfg.PaymentProvider)
+	}
+	return nil
+}
+
+// callbackURL returns the URL the provider should report the result of a payment
+// to. The shared token is added so that callbacks can be told apart from forged
+// requests, and the payment ID so that they can be matched to their payment.
+func callbackURL(rawURL, token, paymentID string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	query.Set("token", token)
+	if paymentID != "" {
+		query.Set("payment", paymentID)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// verifyCallbackToken checks the token a callback was sent with. Callbacks are
+// always rejected when no token is configured.
+func verifyCallbackToken(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(token)) == 1
+}
+
+// parseCallback verifies the token of an M-Pesa style callback and reads the
+// result it reports
+func parseCallback(r *http.Request, token string) (*PaymentResult, error) {
+	if !verifyCallbackToken(r, token) {
+		return nil, fmt.Errorf("%w: bad token", ErrInvalidCallback)
+	}
+	result, err := parseMpesaCallback(r)
+	if err != nil {
+		return nil, err
+	}
+	result.PaymentID = r.URL.Query().Get("payment")
+	return result, nil
+}
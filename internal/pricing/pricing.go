@@ -0,0 +1,131 @@
+// Package pricing calculates order totals from line items, discounts, service
+// charge and taxes. All amounts are integer minor units.
+package pricing
+
+import (
+	"vibanda-village-admin-backend/internal/config"
+	"vibanda-village-admin-backend/internal/models"
+)
+
+// Settings are the rates and fees used to price an order
+type Settings struct {
+	ServiceChargeRate int64
+	DeliveryFee       models.Money
+	Taxes             []models.TaxLine
+}
+
+// SettingsFromConfig returns the rates currently configured for new orders
+func SettingsFromConfig(cfg *config.Config) Settings {
+	settings := Settings{ServiceChargeRate: cfg.ServiceChargeRate}
+	for _, rate := range cfg.TaxRates {
+		settings.Taxes = append(settings.Taxes, models.TaxLine{Name: rate.Name, Rate: rate.Rate, Inclusive: rate.Inclusive})
+	}
+	return settings
+}
+
+// SettingsFromBreakdown returns the rates an order was originally priced with, so
+// that editing an order does not pick up rates changed since it was placed
+func SettingsFromBreakdown(breakdown *models.PriceBreakdown) Settings {
+	settings := Settings{ServiceChargeRate: breakdown.ServiceChargeRate}
+	for _, tax := range breakdown.Taxes {
+		settings.Taxes = append(settings.Taxes, models.TaxLine{Name: tax.Name, Rate: tax.Rate, Inclusive: tax.Inclusive})
+	}
+	return settings
+}
+
+// Line is a single priced line of an order. Promotable lines count towards the
+// promotion discount.
+type Line struct {
+	UnitPrice  models.Money
+	Quantity   int
+	Discount   *models.Discount
+	Promotable bool
+}
+
+// Promotion is a promo code discount on the promotable lines. It only applies once
+// the order reaches the minimum spend.
+type Promotion struct {
+	Discount     models.Discount
+	MinimumSpend models.Money
+}
+
+// LineTotal is the result of pricing a line
+type LineTotal struct {
+	Discount models.Money
+	Total    models.Money
+}
+
+// Result is the outcome of pricing an order
+type Result struct {
+	Lines     []LineTotal
+	Breakdown models.PriceBreakdown
+}
+
+// Calculate prices an order:
+//
+//  1. subtotal is the sum of unit price times quantity over all lines
+//  2. line discounts are taken off each line, then the promotion off the promotable
+//     lines if the order reaches its minimum spend, then the loyalty reward and the
+//     order discount off the rest
+//  3. the service charge is a share of the discounted amount
+//  4. taxes are worked out on the discounted amount plus service charge and delivery
+//     fee; inclusive taxes are the share of that amount already made up by tax,
+//     exclusive taxes are added to it
+//
+// The grand total is the discounted amount plus service charge, delivery fee and
+// exclusive taxes.
+func Calculate(lines []Line, orderDiscount *models.Discount, promotion *Promotion, reward *models.Discount, settings Settings) Result {
+	result := Result{Lines: make([]LineTotal, len(lines))}
+	breakdown := &result.Breakdown
+
+	var net, promotable models.Money
+	for i, line := range lines {
+		gross := line.UnitPrice * models.Money(line.Quantity)
+		discount := line.Discount.AmountOf(gross)
+
+		result.Lines[i] = LineTotal{Discount: discount, Total: gross - discount}
+		breakdown.Subtotal += gross
+		breakdown.LineDiscounts += discount
+		net += gross - discount
+		if line.Promotable {
+			promotable += gross - discount
+		}
+	}
+
+	if promotion != nil && net >= promotion.MinimumSpend {
+		breakdown.PromotionDiscount = promotion.Discount.AmountOf(promotable)
+	}
+	breakdown.RewardDiscount = reward.AmountOf(net - breakdown.PromotionDiscount)
+	breakdown.OrderDiscount = orderDiscount.AmountOf(net - breakdown.PromotionDiscount - breakdown.RewardDiscount)
+	discounted := net - breakdown.PromotionDiscount - breakdown.RewardDiscount - breakdown.OrderDiscount
+
+	breakdown.ServiceChargeRate = settings.ServiceChargeRate
+	breakdown.ServiceCharge = discounted.ApplyRate(settings.ServiceChargeRate)
+	breakdown.DeliveryFee = settings.DeliveryFee
+	taxable := discounted + breakdown.ServiceCharge + breakdown.DeliveryFee
+
+	var inclusiveRate int64
+	for _, tax := range settings.Taxes {
+		if tax.Inclusive {
+			inclusiveRate += tax.Rate
+		}
+	}
+
+	var exclusiveTax models.Money
+	breakdown.Taxes = make([]models.TaxLine, 0, len(settings.Taxes))
+	for _, tax := range settings.Taxes {
+		line := models.TaxLine{Name: tax.Name, Rate: tax.Rate, Inclusive: tax.Inclusive}
+		if tax.Inclusive {
+			// The tax share of a gross amount is amount * rate / (100% + all inclusive rates)
+			line.Amount = taxable.MulDiv(tax.Rate, 10000+inclusiveRate)
+		} else {
+			line.Amount = taxable.ApplyRate(tax.Rate)
+			exclusiveTax += line.Amount
+		}
+		breakdown.TaxTotal += line.Amount
+		breakdown.Taxes = append(breakdown.Taxes, line)
+	}
+
+	breakdown.GrandTotal = taxable + exclusiveTax
+	return result
+}
@@ -0,0 +1,200 @@
+package pricing
+
+import (
+	"reflect"
+	"testing"
+	"vibanda-village-admin-backend/internal/models"
+)
+
+func vat(rate int64, inclusive bool) models.TaxLine {
+	return models.TaxLine{Name: "VAT", Rate: rate, Inclusive: inclusive}
+}
+
+func percentage(value int64) *models.Discount {
+	return &models.Discount{Type: models.DiscountTypePercentage, Value: value}
+}
+
+func fixed(value int64) *models.Discount {
+	return &models.Discount{Type: models.DiscountTypeFixed, Value: value}
+}
+
+func TestCalculateTaxes(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     []Line
+		settings  Settings
+		taxes     []models.Money
+		taxTotal  models.Money
+		total     models.Money
+		discounts models.Money
+	}{
+		{
+			name:  "no taxes",
+			lines: []Line{{UnitPrice: 1000, Quantity: 2}},
+			taxes: []models.Money{},
+			total: 2000,
+		},
+		{
+			name:     "inclusive tax is part of the price",
+			lines:    []Line{{UnitPrice: 11600, Quantity: 1}},
+			settings: Settings{Taxes: []models.TaxLine{vat(1600, true)}},
+			taxes:    []models.Money{1600},
+			taxTotal: 1600,
+			total:    11600,
+		},
+		{
+			name:     "exclusive tax is added",
+			lines:    []Line{{UnitPrice: 10000, Quantity: 1}},
+			settings: Settings{Taxes: []models.TaxLine{vat(1600, false)}},
+			taxes:    []models.Money{1600},
+			taxTotal: 1600,
+			total:    11600,
+		},
+		{
+			name:  "inclusive and exclusive taxes",
+			lines: []Line{{UnitPrice: 11600, Quantity: 1}},
+			settings: Settings{Taxes: []models.TaxLine{
+				vat(1600, true),
+				{Name: "LEVY", Rate: 200, Inclusive: false},
+			}},
+			taxes:    []models.Money{1600, 232},
+			taxTotal: 1832,
+			total:    11832,
+		},
+		{
+			name:  "inclusive taxes share the gross amount",
+			lines: []Line{{UnitPrice: 11800, Quantity: 1}},
+			settings: Settings{Taxes: []models.TaxLine{
+				vat(1600, true),
+				{Name: "CATERING", Rate: 200, Inclusive: true},
+			}},
+			taxes:    []models.Money{1600, 200},
+			taxTotal: 1800,
+			total:    11800,
+		},
+		{
+			name:     "inclusive tax rounds to the nearest minor unit",
+			lines:    []Line{{UnitPrice: 999, Quantity: 1}},
+			settings: Settings{Taxes: []models.TaxLine{vat(1600, true)}},
+			taxes:    []models.Money{138},
+			taxTotal: 138,
+			total:    999,
+		},
+		{
+			name:     "service charge is taxed and rounds half up",
+			lines:    []Line{{UnitPrice: 1005, Quantity: 1}},
+			settings: Settings{ServiceChargeRate: 1000, Taxes: []models.TaxLine{vat(1600, false)}},
+			taxes:    []models.Money{177},
+			taxTotal: 177,
+			total:    1283,
+		},
+		{
+			name:     "delivery fee is taxed",
+			lines:    []Line{{UnitPrice: 1000, Quantity: 1}},
+			settings: Settings{DeliveryFee: 200, Taxes: []models.TaxLine{vat(1000, false)}},
+			taxes:    []models.Money{120},
+			taxTotal: 120,
+			total:    1320,
+		},
+		{
+			name:      "tax is worked out after discounts",
+			lines:     []Line{{UnitPrice: 2000, Quantity: 1, Discount: percentage(5000)}},
+			settings:  Settings{Taxes: []models.TaxLine{vat(1600, false)}},
+			taxes:     []models.Money{160},
+			taxTotal:  160,
+			total:     1160,
+			discounts: 1000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown := Calculate(tt.lines, nil, nil, nil, tt.settings).Breakdown
+
+			taxes := make([]models.Money, 0, len(breakdown.Taxes))
+			for _, tax := range breakdown.Taxes {
+				taxes = append(taxes, tax.Amount)
+			}
+			if !reflect.DeepEqual(taxes, tt.taxes) {
+				t.Errorf("taxes = %v, want %v", taxes, tt.taxes)
+			}
+			if breakdown.TaxTotal != tt.taxTotal {
+				t.Errorf("tax total = %d, want %d", breakdown.TaxTotal, tt.taxTotal)
+			}
+			if breakdown.LineDiscounts != tt.discounts {
+				t.Errorf("line discounts = %d, want %d", breakdown.LineDiscounts, tt.discounts)
+			}
+			if breakdown.GrandTotal != tt.total {
+				t.Errorf("grand total = %d, want %d", breakdown.GrandTotal, tt.total)
+			}
+		})
+	}
+}
+
+func TestCalculateDiscounts(t *testing.T) {
+	tests := []struct {
+		name          string
+		lines         []Line
+		orderDiscount *models.Discount
+		promotion     *Promotion
+		reward        *models.Discount
+		lineTotals    []LineTotal
+		want          models.PriceBreakdown
+	}{
+		{
+			name:          "line discount capped at the line",
+			lines:         []Line{{UnitPrice: 1000, Quantity: 1, Discount: fixed(1500)}},
+			orderDiscount: fixed(500),
+			lineTotals:    []LineTotal{{Discount: 1000, Total: 0}},
+			want:          models.PriceBreakdown{Subtotal: 1000, LineDiscounts: 1000},
+		},
+		{
+			name: "promotion on promotable lines, order discount capped at the rest",
+			lines: []Line{
+				{UnitPrice: 1000, Quantity: 2, Promotable: true},
+				{UnitPrice: 1000, Quantity: 1},
+			},
+			orderDiscount: fixed(5000),
+			promotion:     &Promotion{Discount: *percentage(5000), MinimumSpend: 3000},
+			lineTotals:    []LineTotal{{Total: 2000}, {Total: 1000}},
+			want:          models.PriceBreakdown{Subtotal: 3000, PromotionDiscount: 1000, OrderDiscount: 2000},
+		},
+		{
+			name: "promotion below the minimum spend",
+			lines: []Line{
+				{UnitPrice: 1000, Quantity: 2, Promotable: true},
+				{UnitPrice: 1000, Quantity: 1},
+			},
+			promotion:  &Promotion{Discount: *percentage(5000), MinimumSpend: 3001},
+			lineTotals: []LineTotal{{Total: 2000}, {Total: 1000}},
+			want:       models.PriceBreakdown{Subtotal: 3000, GrandTotal: 3000},
+		},
+		{
+			name:          "reward comes off before the order discount",
+			lines:         []Line{{UnitPrice: 1000, Quantity: 1}},
+			orderDiscount: percentage(1000),
+			reward:        fixed(300),
+			lineTotals:    []LineTotal{{Total: 1000}},
+			want:          models.PriceBreakdown{Subtotal: 1000, RewardDiscount: 300, OrderDiscount: 70, GrandTotal: 630},
+		},
+		{
+			name:       "reward capped at the amount left",
+			lines:      []Line{{UnitPrice: 500, Quantity: 1}},
+			reward:     fixed(800),
+			lineTotals: []LineTotal{{Total: 500}},
+			want:       models.PriceBreakdown{Subtotal: 500, RewardDiscount: 500},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Calculate(tt.lines, tt.orderDiscount, tt.promotion, tt.reward, Settings{})
+
+			if !reflect.DeepEqual(result.Lines, tt.lineTotals) {
+				t.Errorf("lines = %+v, want %+v", result.Lines, tt.lineTotals)
+			}
+			tt.want.Taxes = []models.TaxLine{}
+			if !reflect.DeepEqual(result.Breakdown, tt.want) {
+				t.Errorf("breakdown = %+v, want %+v", result.Breakdown, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package receipt
+
+import (
+	"bytes"
+	"strings"
+)
+
+// ESC/POS commands understood by most thermal receipt printers
+var (
+	escInit        = []byte{0x1b, '@'}
+	escAlignLeft   = []byte{0x1b, 'a', 0}
+	escAlignCenter = []byte{0x1b, 'a', 1}
+	escBoldOn      = []byte{0x1b, 'E', 1}
+	escBoldOff     = []byte{0x1b, 'E', 0}
+	escDoubleOn    = []byte{0x1d, '!', 0x01}
+	escDoubleOff   = []byte{0x1d, '!', 0x00}
+	escFeedAndCut  = []byte{0x1b, 'd', 4, 0x1d, 'V', 1}
+)
+
+// ESCPOS renders the receipt as ESC/POS commands for a thermal printer with
+// paper of the given width in characters. Printers use a single byte code
+// page, so characters outside ASCII are printed as '?'.
+func (r *Receipt) ESCPOS(width int) []byte {
+	var b bytes.Buffer
+	b.Write(escInit)
+	for _, l := range r.layout(width) {
+		if l.large {
+			// Double height text keeps its width, so the padded line still fits;
+			// the printer centres it instead of the leading spaces
+			b.Write(escAlignCenter)
+			b.Write(escDoubleOn)
+		}
+		if l.bold {
+			b.Write(escBoldOn)
+		}
+
+		text := l.text
+		if l.large {
+			text = strings.TrimLeft(text, " ")
+		}
+		writeASCII(&b, text)
+		b.WriteByte('\n')
+
+		if l.bold {
+			b.Write(escBoldOff)
+		}
+		if l.large {
+			b.Write(escDoubleOff)
+			b.Write(escAlignLeft)
+		}
+	}
+	b.Write(escFeedAndCut)
+	return b.Bytes()
+}
+
+// writeASCII writes text replacing characters the printer cannot print
+func writeASCII(b *bytes.Buffer, text string) {
+	for _, r := range text {
+		if r < 0x20 || r > 0x7e {
+			r = '?'
+		}
+		b.WriteByte(byte(r))
+	}
+}
@@ -0,0 +1,82 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// PDF page layout in points. Courier characters are 0.6 of the font size wide,
+// so a line of Width80mm characters fits the page with a margin either side.
+const (
+	pdfFontSize = 10
+	pdfLeading  = 12
+	pdfMargin   = 20
+	pdfWidth    = pdfMargin*2 + Width80mm*pdfFontSize*6/10
+)
+
+// PDF renders the receipt as a single page PDF document sized like an 80mm
+// receipt roll, using the built in Courier fonts so nothing has to be embedded
+func (r *Receipt) PDF() []byte {
+	lines := r.layout(Width80mm)
+	height := pdfMargin*2 + len(lines)*pdfLeading
+
+	var content bytes.Buffer
+	content.WriteString("BT\n")
+	fmt.Fprintf(&content, "%d TL\n", pdfLeading)
+	fmt.Fprintf(&content, "%d %d Td\n", pdfMargin, height-pdfMargin-pdfFontSize)
+	for _, l := range lines {
+		font := "F1"
+		if l.bold {
+			font = "F2"
+		}
+		fmt.Fprintf(&content, "/%s %d Tf\n", font, pdfFontSize)
+		content.WriteByte('(')
+		writePDFString(&content, l.text)
+		content.WriteString(") Tj T*\n")
+	}
+	content.WriteString("ET\n")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Contents 4 0 R /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> >>", pdfWidth, height),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n", len(objects)+1)
+	b.WriteString("0000000000 65535 f \n")
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+// writePDFString writes text inside a PDF string literal. Latin-1 characters
+// have the same codes in WinAnsiEncoding; anything else is printed as '?'.
+func writePDFString(b *bytes.Buffer, text string) {
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r >= 0x20 && r <= 0x7e:
+			b.WriteByte(byte(r))
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+}
@@ -0,0 +1,292 @@
+// Package receipt lays out order receipts and renders them as plain text,
+// ESC/POS printer commands or PDF.
+package receipt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"vibanda-village-admin-backend/internal/config"
+	"vibanda-village-admin-backend/internal/models"
+)
+
+// Character widths of common thermal printer paper rolls
+const (
+	Width58mm = 32
+	Width80mm = 48
+)
+
+// Business holds the restaurant details printed at the top and bottom of a receipt
+type Business struct {
+	Name    string
+	Address string
+	Phone   string
+	TaxPIN  string
+	Footer  string
+}
+
+// BusinessFromConfig returns the restaurant details from the RESTAURANT_* settings
+func BusinessFromConfig(cfg *config.Config) Business {
+	return Business{
+		Name:    cfg.RestaurantName,
+		Address: cfg.RestaurantAddress,
+		Phone:   cfg.RestaurantPhone,
+		TaxPIN:  cfg.RestaurantTaxPIN,
+		Footer:  cfg.ReceiptFooter,
+	}
+}
+
+// Receipt is the receipt of a single order
+type Receipt struct {
+	Business Business
+	Order    *models.Order
+	Payments []models.Payment
+	Location *time.Location
+}
+
+// New creates a receipt for an order and its payments, printing times in loc
+func New(business Business, order *models.Order, payments []models.Payment, loc *time.Location) *Receipt {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &Receipt{Business: business, Order: order, Payments: payments, Location: loc}
+}
+
+// line is a single line of a laid out receipt, already padded to the paper width
+type line struct {
+	text  string
+	bold  bool
+	large bool
+}
+
+// layout builds the lines of the receipt for paper of the given width in characters
+func (r *Receipt) layout(width int) []line {
+	order := r.Order
+	var lines []line
+	add := func(text string) { lines = append(lines, line{text: text}) }
+	addBold := func(text string) { lines = append(lines, line{text: text, bold: true}) }
+	rule := func() { add(strings.Repeat("-", width)) }
+
+	// Header
+	lines = append(lines, line{text: center(strings.ToUpper(r.Business.Name), width), bold: true, large: true})
+	for _, detail := range []string{r.Business.Address, r.Business.Phone} {
+		if detail != "" {
+			add(center(detail, width))
+		}
+	}
+	if r.Business.TaxPIN != "" {
+		add(center("PIN: "+r.Business.TaxPIN, width))
+	}
+	rule()
+
+	add(pair("Receipt", order.OrderNumber, width))
+	add(pair("Date", order.CreatedAt.In(r.Location).Format("02/01/2006 15:04"), width))
+	switch {
+	case order.OrderType == models.OrderTypeDineIn && order.TableNumber > 0:
+		add(pair("Dine in", fmt.Sprintf("Table %d", order.TableNumber), width))
+	case order.OrderType == models.OrderTypeTakeaway && order.PickupTime != nil:
+		add(pair("Takeaway", "Pickup "+order.PickupTime.In(r.Location).Format("15:04"), width))
+	case order.OrderType == models.OrderTypeDelivery:
+		add("Delivery")
+	}
+	if order.CustomerName != "" {
+		add(pair("Customer", order.CustomerName, width))
+	}
+	if address := order.DeliveryAddress; order.OrderType == models.OrderTypeDelivery && address != nil {
+		for _, part := range []string{address.Line1, address.Line2, address.Area, address.City} {
+			if part != "" {
+				for _, l := range wrap(part, width) {
+					add(l)
+				}
+			}
+		}
+	}
+	rule()
+
+	// Items
+	for _, item := range order.Items {
+		if item.Voided {
+			continue
+		}
+		total := item.Total
+		if total == 0 && item.DiscountAmount == 0 {
+			// Orders from before line totals were stored
+			total = item.Price * models.Money(item.Quantity)
+		}
+		gross := total + item.DiscountAmount
+		add(pair(fmt.Sprintf("%d x %s", item.Quantity, item.Name), formatMoney(gross), width))
+		if item.Quantity > 1 {
+			add("  @ " + formatMoney(item.Price))
+		}
+		if item.DiscountAmount > 0 {
+			add(pair("  Discount", "-"+formatMoney(item.DiscountAmount), width))
+		}
+	}
+	rule()
+
+	// Totals
+	if pricing := order.Pricing; pricing != nil {
+		add(pair("Subtotal", formatMoney(pricing.Subtotal), width))
+		if pricing.LineDiscounts > 0 {
+			add(pair("Item discounts", "-"+formatMoney(pricing.LineDiscounts), width))
+		}
+		if pricing.PromotionDiscount > 0 && order.Promotion != nil {
+			add(pair("Promo "+order.Promotion.Code, "-"+formatMoney(pricing.PromotionDiscount), width))
+		}
+		if pricing.RewardDiscount > 0 && order.LoyaltyReward != nil {
+			add(pair("Reward "+order.LoyaltyReward.Name, "-"+formatMoney(pricing.RewardDiscount), width))
+		}
+		if pricing.OrderDiscount > 0 {
+			add(pair("Discount", "-"+formatMoney(pricing.OrderDiscount), width))
+		}
+		if pricing.ServiceCharge > 0 {
+			add(pair("Service charge "+formatRate(pricing.ServiceChargeRate), formatMoney(pricing.ServiceCharge), width))
+		}
+		if pricing.DeliveryFee > 0 {
+			add(pair("Delivery fee", formatMoney(pricing.DeliveryFee), width))
+		}
+	}
+	addBold(pair("TOTAL "+order.Currency, formatMoney(order.TotalAmount), width))
+	if pricing := order.Pricing; pricing != nil {
+		for _, tax := range pricing.Taxes {
+			label := tax.Name + " " + formatRate(tax.Rate)
+			if tax.Inclusive {
+				label += " incl."
+			}
+			add(pair(label, formatMoney(tax.Amount), width))
+		}
+	}
+
+	// Payments
+	var paid bool
+	for _, payment := range r.Payments {
+		if !payment.IsCompleted() {
+			continue
+		}
+		if !paid {
+			rule()
+			paid = true
+		}
+		label := paymentMethodLabel(payment.Method)
+		amount := formatMoney(payment.Amount)
+		if payment.Type == models.PaymentTypeRefund {
+			label = "Refund " + label
+			amount = "-" + amount
+		}
+		if payment.Reference != "" {
+			label += " " + payment.Reference
+		}
+		add(pair(label, amount, width))
+	}
+	if paid || order.NetPaid() > 0 {
+		if due := order.BalanceDue(); due > 0 {
+			addBold(pair("BALANCE DUE", formatMoney(due), width))
+		} else {
+			add(pair("Paid", formatMoney(order.NetPaid()), width))
+		}
+	}
+
+	// Footer
+	if r.Business.Footer != "" {
+		rule()
+		for _, footerLine := range wrap(r.Business.Footer, width) {
+			add(center(footerLine, width))
+		}
+	}
+
+	return lines
+}
+
+// Text renders the receipt as plain text for paper of the given width in characters
+func (r *Receipt) Text(width int) string {
+	var b strings.Builder
+	for _, l := range r.layout(width) {
+		b.WriteString(l.text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// paymentMethodLabel returns how a payment method is printed
+func paymentMethodLabel(method models.PaymentMethod) string {
+	switch method {
+	case models.PaymentMethodCash:
+		return "Cash"
+	case models.PaymentMethodCard:
+		return "Card"
+	case models.PaymentMethodMobileMoney:
+		return "M-Pesa"
+	default:
+		return string(method)
+	}
+}
+
+// formatMoney formats an amount with thousands separators, e.g. 123450 as "1,234.50"
+func formatMoney(amount models.Money) string {
+	s := amount.String()
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, cents := s[:len(s)-3], s[len(s)-3:]
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return sign + whole + cents
+}
+
+// formatRate formats a rate in basis points as a percentage, e.g. 1650 as "16.5%"
+func formatRate(rate int64) string {
+	if rate%100 == 0 {
+		return fmt.Sprintf("%d%%", rate/100)
+	}
+	return strings.TrimRight(fmt.Sprintf("%d.%02d", rate/100, rate%100), "0") + "%"
+}
+
+// center pads text on the left so that it is centred on the line
+func center(text string, width int) string {
+	text = fit(text, width)
+	return strings.Repeat(" ", (width-utf8.RuneCountInString(text))/2) + text
+}
+
+// pair prints left and right aligned text on one line, shortening the left text if needed
+func pair(left, right string, width int) string {
+	right = fit(right, width)
+	room := width - utf8.RuneCountInString(right) - 1
+	if room < 1 {
+		return right
+	}
+	left = fit(left, room)
+	return left + strings.Repeat(" ", width-utf8.RuneCountInString(left)-utf8.RuneCountInString(right)) + right
+}
+
+// fit cuts text to at most width characters
+func fit(text string, width int) string {
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	return string([]rune(text)[:width])
+}
+
+// wrap splits text into lines of at most width characters at spaces
+func wrap(text string, width int) []string {
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(text) {
+		word = fit(word, width)
+		switch {
+		case current == "":
+			current = word
+		case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
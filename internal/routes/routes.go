@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"time"
 	"vibanda-village-admin-backend/internal/config"
 	"vibanda-village-admin-backend/internal/handlers"
 	"vibanda-village-admin-backend/internal/middleware"
@@ -27,79 +28,227 @@ func SetupRoutes(r *gin.Engine) {
 		{
 			auth.POST("/register", handlers.Register)
 			auth.POST("/login", handlers.Login)
+			auth.POST("/refresh", handlers.RefreshToken)
 		}
+
+		// Customer website routes
+		website := public.Group("/public")
+		{
+			website.GET("/availability", handlers.GetAvailability)
+			website.POST("/reservations", middleware.RateLimitMiddleware(cfg.PublicReservationRateLimit, time.Hour), handlers.CreatePublicReservation)
+		}
+
+		// Payment provider callbacks, verified by the provider itself
+		public.POST("/payments/callback", handlers.PaymentCallback)
+
+		// Kitchen display feed. Displays authenticate themselves, as browsers
+		// cannot send an Authorization header with EventSource.
+		public.GET("/kitchen/stream", handlers.KitchenStream)
 	}
 
 	// Protected routes (authentication required)
 	protected := r.Group("/api/v1")
 	protected.Use(middleware.AuthMiddleware())
+	idempotent := middleware.IdempotencyMiddleware(time.Duration(cfg.IdempotencyKeyHours) * time.Hour)
 	{
 		// Auth routes
 		auth := protected.Group("/auth")
 		{
 			auth.GET("/profile", handlers.GetProfile)
+			auth.POST("/logout", handlers.Logout)
 		}
 
-		// User management routes (admin only)
+		// Routes below are guarded by named permissions; see models.RolePermissions
+		// for which roles are granted each one.
+
+		// User management routes
 		users := protected.Group("/users")
-		users.Use(middleware.RoleMiddleware(models.RoleAdmin))
 		{
-			users.GET("", handlers.GetUsers)
-			users.GET("/:id", handlers.GetUser)
-			users.POST("", handlers.CreateUser)
-			users.PUT("/:id", handlers.UpdateUser)
-			users.DELETE("/:id", handlers.DeleteUser)
+			users.GET("", middleware.RequirePermission(models.PermissionUsersRead), handlers.GetUsers)
+			users.GET("/:id", middleware.RequirePermission(models.PermissionUsersRead), handlers.GetUser)
+			users.POST("", middleware.RequirePermission(models.PermissionUsersWrite), handlers.CreateUser)
+			users.PUT("/:id", middleware.RequirePermission(models.PermissionUsersWrite), handlers.UpdateUser)
+			users.DELETE("/:id", middleware.RequirePermission(models.PermissionUsersWrite), handlers.DeleteUser)
+		}
+
+		// Invitation routes
+		invitations := protected.Group("/invitations")
+		invitations.Use(middleware.RequirePermission(models.PermissionUsersAssignRole))
+		{
+			invitations.GET("", handlers.GetInvitations)
+			invitations.POST("", handlers.CreateInvitation)
+			invitations.DELETE("/:id", handlers.RevokeInvitation)
+		}
+
+		// Audit log routes
+		auditLogs := protected.Group("/audit-logs")
+		auditLogs.Use(middleware.RequirePermission(models.PermissionAuditRead))
+		{
+			auditLogs.GET("", handlers.GetAuditLogs)
+		}
+
+		// Report routes
+		reports := protected.Group("/reports")
+		reports.Use(middleware.RequirePermission(models.PermissionReportsRead))
+		{
+			reports.GET("/revenue", handlers.GetRevenueReport)
+			reports.GET("/orders-by-status", handlers.GetOrderStatusReport)
+			reports.GET("/top-items", handlers.GetTopItemsReport)
+			reports.GET("/average-ticket", handlers.GetAverageTicketReport)
+			reports.GET("/covers", handlers.GetCoversReport)
+			reports.GET("/cancellations", handlers.GetCancellationReport)
+			reports.GET("/events", handlers.GetEventSalesReport)
 		}
 
-		// Product routes (admin and manager)
+		// Product routes
 		products := protected.Group("/products")
-		products.Use(middleware.RoleMiddleware(models.RoleAdmin, models.RoleManager))
 		{
-			products.GET("", handlers.GetProducts)
-			products.GET("/:id", handlers.GetProduct)
-			products.POST("", handlers.CreateProduct)
-			products.PUT("/:id", handlers.UpdateProduct)
-			products.DELETE("/:id", handlers.DeleteProduct)
+			products.GET("", middleware.RequirePermission(models.PermissionProductsRead), handlers.GetProducts)
+			products.GET("/:id", middleware.RequirePermission(models.PermissionProductsRead), handlers.GetProduct)
+			products.POST("", middleware.RequirePermission(models.PermissionProductsWrite), handlers.CreateProduct)
+			products.PUT("/:id", middleware.RequirePermission(models.PermissionProductsWrite), handlers.UpdateProduct)
+			products.DELETE("/:id", middleware.RequirePermission(models.PermissionProductsWrite), handlers.DeleteProduct)
+			products.PUT("/:id/recipe", middleware.RequirePermission(models.PermissionInventoryWrite), handlers.SetProductRecipe)
 		}
 
-		// Upload routes (admin and manager)
+		// Ingredient routes
+		ingredients := protected.Group("/ingredients")
+		{
+			ingredients.GET("", middleware.RequirePermission(models.PermissionInventoryRead), handlers.GetIngredients)
+			ingredients.GET("/:id", middleware.RequirePermission(models.PermissionInventoryRead), handlers.GetIngredient)
+			ingredients.POST("", middleware.RequirePermission(models.PermissionInventoryWrite), handlers.CreateIngredient)
+			ingredients.PUT("/:id", middleware.RequirePermission(models.PermissionInventoryWrite), handlers.UpdateIngredient)
+			ingredients.POST("/:id/adjust", middleware.RequirePermission(models.PermissionInventoryWrite), handlers.AdjustIngredient)
+			ingredients.DELETE("/:id", middleware.RequirePermission(models.PermissionInventoryWrite), handlers.DeleteIngredient)
+		}
+
+		// Upload routes
 		uploads := protected.Group("/uploads")
-		uploads.Use(middleware.RoleMiddleware(models.RoleAdmin, models.RoleManager))
+		uploads.Use(middleware.RequirePermission(models.PermissionUploadsWrite))
 		{
 			uploads.POST("/image", handlers.UploadImage)
 		}
 
-		// Order routes (admin and manager)
+		// Order routes
 		orders := protected.Group("/orders")
-		orders.Use(middleware.RoleMiddleware(models.RoleAdmin, models.RoleManager))
 		{
-			orders.GET("", handlers.GetOrders)
-			orders.GET("/:id", handlers.GetOrder)
-			orders.POST("", handlers.CreateOrder)
-			orders.PUT("/:id", handlers.UpdateOrder)
-			orders.DELETE("/:id", handlers.DeleteOrder)
+			orders.GET("", middleware.RequirePermission(models.PermissionOrdersRead), handlers.GetOrders)
+			orders.GET("/by-number/:number", middleware.RequirePermission(models.PermissionOrdersRead), handlers.GetOrderByNumber)
+			orders.GET("/:id", middleware.RequirePermission(models.PermissionOrdersRead), handlers.GetOrder)
+			orders.POST("", middleware.RequirePermission(models.PermissionOrdersCreate), idempotent, handlers.CreateOrder)
+			orders.PUT("/:id", middleware.RequirePermission(models.PermissionOrdersUpdate), handlers.UpdateOrder)
+			orders.PUT("/:id/status", middleware.RequirePermission(models.PermissionOrdersUpdateStatus), handlers.UpdateOrderStatus)
+			orders.PUT("/:id/rider", middleware.RequirePermission(models.PermissionOrdersUpdateStatus), handlers.AssignOrderRider)
+			orders.DELETE("/:id", middleware.RequirePermission(models.PermissionOrdersDelete), handlers.DeleteOrder)
+
+			orders.POST("/:id/items", middleware.RequirePermission(models.PermissionOrdersEditItems), handlers.AddOrderItem)
+			orders.PUT("/:id/items/:itemId", middleware.RequirePermission(models.PermissionOrdersEditItems), handlers.UpdateOrderItem)
+			orders.POST("/:id/items/:itemId/void", middleware.RequirePermission(models.PermissionOrdersVoidItems), handlers.VoidOrderItem)
+
+			orders.GET("/:id/receipt", middleware.RequirePermission(models.PermissionOrdersRead), handlers.GetOrderReceipt)
+			orders.POST("/:id/receipt/email", middleware.RequirePermission(models.PermissionOrdersRead), handlers.EmailOrderReceipt)
+
+			orders.GET("/:id/payments", middleware.RequirePermission(models.PermissionOrdersRead), handlers.GetOrderPayments)
+			orders.POST("/:id/payments", middleware.RequirePermission(models.PermissionPaymentsCreate), idempotent, handlers.CreateOrderPayment)
+			orders.POST("/:id/payments/mobile-money", middleware.RequirePermission(models.PermissionPaymentsCreate), idempotent, handlers.RequestMobilePayment)
+			orders.POST("/:id/payments/:paymentId/refund", middleware.RequirePermission(models.PermissionPaymentsRefund), idempotent, handlers.RefundOrderPayment)
+
+			orders.POST("/:id/loyalty-reward", middleware.RequirePermission(models.PermissionLoyaltyRedeem), handlers.RedeemLoyaltyReward)
+			orders.DELETE("/:id/loyalty-reward", middleware.RequirePermission(models.PermissionLoyaltyRedeem), handlers.RemoveLoyaltyReward)
 		}
 
-		// Event routes (admin and manager)
+		// Promotion routes
+		promotions := protected.Group("/promotions")
+		{
+			promotions.GET("", middleware.RequirePermission(models.PermissionPromotionsRead), handlers.GetPromotions)
+			promotions.GET("/:id", middleware.RequirePermission(models.PermissionPromotionsRead), handlers.GetPromotion)
+			promotions.POST("", middleware.RequirePermission(models.PermissionPromotionsWrite), handlers.CreatePromotion)
+			promotions.PUT("/:id", middleware.RequirePermission(models.PermissionPromotionsWrite), handlers.UpdatePromotion)
+			promotions.DELETE("/:id", middleware.RequirePermission(models.PermissionPromotionsWrite), handlers.DeletePromotion)
+		}
+
+		// Event routes
 		events := protected.Group("/events")
-		events.Use(middleware.RoleMiddleware(models.RoleAdmin, models.RoleManager))
 		{
-			events.GET("", handlers.GetEvents)
-			events.GET("/:id", handlers.GetEvent)
-			events.POST("", handlers.CreateEvent)
-			events.PUT("/:id", handlers.UpdateEvent)
-			events.DELETE("/:id", handlers.DeleteEvent)
+			events.GET("", middleware.RequirePermission(models.PermissionEventsRead), handlers.GetEvents)
+			events.GET("/:id", middleware.RequirePermission(models.PermissionEventsRead), handlers.GetEvent)
+			events.POST("", middleware.RequirePermission(models.PermissionEventsWrite), handlers.CreateEvent)
+			events.PUT("/:id", middleware.RequirePermission(models.PermissionEventsWrite), handlers.UpdateEvent)
+			events.DELETE("/:id", middleware.RequirePermission(models.PermissionEventsWrite), handlers.DeleteEvent)
+
+			events.GET("/:id/tickets", middleware.RequirePermission(models.PermissionEventsRead), handlers.GetEventTickets)
+			events.POST("/:id/tickets", middleware.RequirePermission(models.PermissionTicketsSell), handlers.IssueTickets)
+			events.POST("/:id/tickets/check-in", middleware.RequirePermission(models.PermissionTicketsCheckIn), handlers.CheckInTicket)
+			events.POST("/:id/tickets/:ticketId/cancel", middleware.RequirePermission(models.PermissionTicketsSell), handlers.CancelTicket)
 		}
 
-		// Reservation routes (admin and manager)
+		// Reservation routes
 		reservations := protected.Group("/reservations")
-		reservations.Use(middleware.RoleMiddleware(models.RoleAdmin, models.RoleManager))
 		{
-			reservations.GET("", handlers.GetReservations)
-			reservations.GET("/:id", handlers.GetReservation)
-			reservations.POST("", handlers.CreateReservation)
-			reservations.PUT("/:id", handlers.UpdateReservation)
-			reservations.DELETE("/:id", handlers.DeleteReservation)
+			reservations.GET("", middleware.RequirePermission(models.PermissionReservationsRead), handlers.GetReservations)
+			reservations.GET("/:id", middleware.RequirePermission(models.PermissionReservationsRead), handlers.GetReservation)
+			reservations.POST("", middleware.RequirePermission(models.PermissionReservationsWrite), idempotent, handlers.CreateReservation)
+			reservations.PUT("/:id", middleware.RequirePermission(models.PermissionReservationsWrite), handlers.UpdateReservation)
+			reservations.DELETE("/:id", middleware.RequirePermission(models.PermissionReservationsDelete), handlers.DeleteReservation)
+		}
+
+		// Table routes
+		tables := protected.Group("/tables")
+		{
+			tables.GET("", middleware.RequirePermission(models.PermissionTablesRead), handlers.GetTables)
+			tables.GET("/:id", middleware.RequirePermission(models.PermissionTablesRead), handlers.GetTable)
+			tables.POST("", middleware.RequirePermission(models.PermissionTablesWrite), handlers.CreateTable)
+			tables.PUT("/:id", middleware.RequirePermission(models.PermissionTablesWrite), handlers.UpdateTable)
+			tables.DELETE("/:id", middleware.RequirePermission(models.PermissionTablesWrite), handlers.DeleteTable)
+		}
+
+		// Prep station routes
+		stations := protected.Group("/stations")
+		{
+			stations.GET("", middleware.RequirePermission(models.PermissionStationsRead), handlers.GetStations)
+			stations.POST("", middleware.RequirePermission(models.PermissionStationsWrite), handlers.CreateStation)
+			stations.PUT("/:code", middleware.RequirePermission(models.PermissionStationsWrite), handlers.UpdateStation)
+			stations.DELETE("/:code", middleware.RequirePermission(models.PermissionStationsWrite), handlers.DeleteStation)
+
+			stations.GET("/:code/tickets", middleware.RequirePermission(models.PermissionOrdersRead), handlers.GetStationTickets)
+			stations.PUT("/:code/tickets/:ticketId", middleware.RequirePermission(models.PermissionOrdersUpdateStatus), handlers.UpdateStationTicket)
+		}
+
+		// Delivery zone routes
+		deliveryZones := protected.Group("/delivery-zones")
+		{
+			deliveryZones.GET("", middleware.RequirePermission(models.PermissionDeliveryZonesRead), handlers.GetDeliveryZones)
+			deliveryZones.GET("/resolve", middleware.RequirePermission(models.PermissionDeliveryZonesRead), handlers.ResolveDeliveryZone)
+			deliveryZones.GET("/:id", middleware.RequirePermission(models.PermissionDeliveryZonesRead), handlers.GetDeliveryZone)
+			deliveryZones.POST("", middleware.RequirePermission(models.PermissionDeliveryZonesWrite), handlers.CreateDeliveryZone)
+			deliveryZones.PUT("/:id", middleware.RequirePermission(models.PermissionDeliveryZonesWrite), handlers.UpdateDeliveryZone)
+			deliveryZones.DELETE("/:id", middleware.RequirePermission(models.PermissionDeliveryZonesWrite), handlers.DeleteDeliveryZone)
+		}
+
+		// Customer directory routes
+		customers := protected.Group("/customers")
+		{
+			customers.GET("", middleware.RequirePermission(models.PermissionCustomersRead), handlers.GetCustomers)
+			customers.GET("/:id", middleware.RequirePermission(models.PermissionCustomersRead), handlers.GetCustomer)
+			customers.GET("/:id/history", middleware.RequirePermission(models.PermissionCustomersRead), handlers.GetCustomerHistory)
+			customers.POST("", middleware.RequirePermission(models.PermissionCustomersWrite), handlers.CreateCustomer)
+			customers.PUT("/:id", middleware.RequirePermission(models.PermissionCustomersWrite), handlers.UpdateCustomer)
+			customers.DELETE("/:id", middleware.RequirePermission(models.PermissionCustomersWrite), handlers.DeleteCustomer)
+			customers.POST("/:id/merge", middleware.RequirePermission(models.PermissionCustomersWrite), handlers.MergeCustomers)
+
+			customers.GET("/:id/loyalty", middleware.RequirePermission(models.PermissionLoyaltyRead), handlers.GetCustomerLoyalty)
+			customers.GET("/:id/loyalty/history", middleware.RequirePermission(models.PermissionLoyaltyRead), handlers.GetCustomerLoyaltyHistory)
+		}
+
+		// Loyalty program routes
+		loyalty := protected.Group("/loyalty")
+		{
+			loyalty.GET("/program", middleware.RequirePermission(models.PermissionLoyaltyRead), handlers.GetLoyaltyProgram)
+			loyalty.GET("/rewards", middleware.RequirePermission(models.PermissionLoyaltyRead), handlers.GetLoyaltyRewards)
+			loyalty.GET("/rewards/:id", middleware.RequirePermission(models.PermissionLoyaltyRead), handlers.GetLoyaltyReward)
+			loyalty.POST("/rewards", middleware.RequirePermission(models.PermissionLoyaltyWrite), handlers.CreateLoyaltyReward)
+			loyalty.PUT("/rewards/:id", middleware.RequirePermission(models.PermissionLoyaltyWrite), handlers.UpdateLoyaltyReward)
+			loyalty.DELETE("/rewards/:id", middleware.RequirePermission(models.PermissionLoyaltyWrite), handlers.DeleteLoyaltyReward)
 		}
 	}
 }
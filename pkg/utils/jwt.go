@@ -5,6 +5,7 @@ import (
 	"vibanda-village-admin-backend/internal/models"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type Claims struct {
@@ -15,9 +16,10 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-// GenerateToken generates a JWT token for a user
-func GenerateToken(user *models.User, secret string, expirationHours int) (string, error) {
-	expirationTime := time.Now().Add(time.Duration(expirationHours) * time.Hour)
+// GenerateToken generates a JWT access token for a user. Each token carries a
+// unique ID (jti) so that it can be revoked before it expires.
+func GenerateToken(user *models.User, secret string, expiration time.Duration) (string, error) {
+	expirationTime := time.Now().Add(expiration)
 
 	claims := &Claims{
 		UserID:   user.ID.Hex(),
@@ -30,6 +32,7 @@ func GenerateToken(user *models.User, secret string, expirationHours int) (strin
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "vibanda-village-admin-backend",
 			Subject:   user.ID.Hex(),
+			ID:        uuid.New().String(),
 		},
 	}
 
@@ -48,7 +51,7 @@ func ValidateToken(tokenString, secret string) (*Claims, error) {
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		return []byte(secret), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
 		return nil, err